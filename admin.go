@@ -0,0 +1,451 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	adminsKey       = "config_admins"    // 管理员账户, JSON 数组
+	pausedKey       = "config_paused"    // 已暂停的方法, JSON 数组, "*" 表示全部写方法
+	auditKey        = "admin_audit"      // 管理操作审计记录, field 为序号
+	auditMetaKey    = "admin_audit_meta" // 审计记录计数
+	pauseAllMethods = "*"
+)
+
+// adminMethods 管理方法, 不受暂停开关影响
+var adminMethods = map[string]bool{
+	"pauseContract":   true,
+	"unpauseContract": true,
+	"setConfig":       true,
+	"rotateAdmin":     true,
+	"runMigration":    true,
+
+	"registerInstitution": true,
+}
+
+// AuditEntry 审计记录: 管理操作、操作员代理授权及操作员代理执行的写方法
+type AuditEntry struct {
+	Seq      int    `json:"seq"`
+	Action   string `json:"action"`             // 方法名, 初始化为 InitContract
+	Account  string `json:"account"`            // 执行操作的管理员, 或代理调用的委托人
+	Operator string `json:"operator,omitempty"` // 代理调用的操作员
+	Target   string `json:"target,omitempty"`   // 操作对象(方法名、配置项、账户、通证)
+	Value    string `json:"value,omitempty"`    // 写入的新值
+	TxId     string `json:"txId"`
+	Time     string `json:"time"` // 区块时间(RFC3339)
+}
+
+// AuditPage requestAuditLog 分页结果
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+	Total      int          `json:"total"`
+}
+
+// AdminInfo requestAdminInfo 返回的管理状态
+type AdminInfo struct {
+	Admins           []string              `json:"admins"`
+	Paused           []string              `json:"paused"` // "*" 表示全部写方法
+	StrictContent    bool                  `json:"strictContent"`
+	Regulators       []string              `json:"regulators"`
+	TokenBaseURI     string                `json:"tokenBaseURI,omitempty"`
+	PaymentContract  string                `json:"paymentContract,omitempty"` // 挂单成交时付款的资产合约
+	PaymentAttestors []string              `json:"paymentAttestors"`
+	Arbitrators      []string              `json:"arbitrators"` // 受理及裁决权属争议的账户, 监管机构同样可以
+	Enums            map[string]enumBounds `json:"enums"`       // 管理员调整过取值范围的枚举
+}
+
+// saveInitAdmins 根据初始化/升级参数 admins 保存管理员, 未传入时以交易发送者为管理员
+// onlyIfEmpty 为 true 时(升级)已有管理员则不覆盖
+func saveInitAdmins(stub shim.CMStubInterface, onlyIfEmpty bool) ([]string, error) {
+	if onlyIfEmpty {
+		admins, err := readIndex(stub, adminsKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(admins) > 0 {
+			return nil, nil
+		}
+	}
+	var admins []string
+	if adminsStr := string(stub.GetArgs()["admins"]); adminsStr != "" {
+		if err := json.Unmarshal([]byte(adminsStr), &admins); err != nil {
+			return nil, newError(ErrInvalidParam, "admins", "fail to parse 'admins' json array: %s", err.Error())
+		}
+	}
+	if len(admins) == 0 {
+		sender, err := stub.GetSenderAddr()
+		if err != nil {
+			return nil, fmt.Errorf("fail to get sender: %s", err.Error())
+		}
+		admins = []string{sender}
+	}
+	for _, admin := range admins {
+		if admin == "" {
+			return nil, newError(ErrInvalidParam, "admins", "admin account can not be empty")
+		}
+	}
+	if err := putIndex(stub, adminsKey, admins); err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
+
+// putIndex 以 JSON 数组写入索引
+func putIndex(stub shim.CMStubInterface, key string, ids []string) error {
+	indexBytes, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("fail to marshal index %s: %s", key, err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(key, indexBytes); err != nil {
+		return fmt.Errorf("fail to PutState for %s: %s", key, err.Error())
+	}
+	return nil
+}
+
+// checkSender 校验调用者参数所指账户即交易发送者, 角色校验均先经此校验, 防止传入他人账户冒用其角色
+// 可代理的写方法中, 持有该账户有效代理权的操作员同样可以(见 operator.go)
+func checkSender(stub shim.CMStubInterface, account string) error {
+	sender, err := stub.GetSenderAddr()
+	if err != nil {
+		return fmt.Errorf("fail to get sender: %s", err.Error())
+	}
+	if sender == account {
+		return nil
+	}
+	method := string(stub.GetArgs()["method"])
+	if spec, ok := findMethodSpec(method); ok && delegable(spec) && checkGrant(stub, method, account, sender) == nil {
+		return nil
+	}
+	return newError(ErrForbidden, "account", "transaction sender %s is not %s", sender, account)
+}
+
+// checkAdmin 校验账户为管理员且为交易发送者
+func checkAdmin(stub shim.CMStubInterface, account string) error {
+	if err := checkSender(stub, account); err != nil {
+		return err
+	}
+	admins, err := readIndex(stub, adminsKey)
+	if err != nil {
+		return err
+	}
+	if !containsId(admins, account) {
+		return newError(ErrForbidden, "account", "only admin can perform this action: %s", account)
+	}
+	return nil
+}
+
+// containsId 判断 ID 列表是否包含 id
+func containsId(ids []string, id string) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPaused 写方法在全局或该方法被暂停时返回 PAUSED, 查询方法及管理方法不受影响
+func checkPaused(stub shim.CMStubInterface, method string) error {
+	if !pausable(method) {
+		return nil
+	}
+	paused, err := readIndex(stub, pausedKey)
+	if err != nil {
+		return err
+	}
+	if containsId(paused, pauseAllMethods) || containsId(paused, method) {
+		return newError(ErrPaused, "method", "method %s is paused by admin", method)
+	}
+	return nil
+}
+
+// pausable 是否为可暂停的写方法
+func pausable(method string) bool {
+	spec, ok := findMethodSpec(method)
+	return ok && !spec.Query && !adminMethods[method]
+}
+
+// audit 追加管理操作审计记录
+func audit(stub shim.CMStubInterface, action, account, target, value string) (*AuditEntry, string, error) {
+	return appendAudit(stub, &AuditEntry{Action: action, Account: account, Target: target, Value: value})
+}
+
+// auditOperator 追加操作员代理调用的审计记录
+func auditOperator(stub shim.CMStubInterface, action, principal, operator, target string) (*AuditEntry, string, error) {
+	return appendAudit(stub, &AuditEntry{Action: action, Account: principal, Operator: operator, Target: target})
+}
+
+// appendAudit 为审计记录分配序号、填写交易信息并写入
+func appendAudit(stub shim.CMStubInterface, entry *AuditEntry) (*AuditEntry, string, error) {
+	metaBytes, err := stub.GetStateFromKeyByte(auditMetaKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("fail to GetState for %s: %s", auditMetaKey, err.Error())
+	}
+	seq := 0
+	if len(metaBytes) > 0 {
+		if seq, err = strconv.Atoi(string(metaBytes)); err != nil {
+			return nil, "", fmt.Errorf("invalid %s: %s", auditMetaKey, string(metaBytes))
+		}
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return nil, "", fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return nil, "", err
+	}
+	entry.Seq, entry.TxId, entry.Time = seq, txId, now.Format(time.RFC3339)
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal audit entry error: %s", err.Error())
+	}
+	field := holdingField(seq)
+	if err := stub.PutStateByte(auditKey, field, entryBytes); err != nil {
+		return nil, "", fmt.Errorf("fail to PutState audit entry: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(auditMetaKey, []byte(strconv.Itoa(seq+1))); err != nil {
+		return nil, "", fmt.Errorf("fail to PutState for %s: %s", auditMetaKey, err.Error())
+	}
+	data := []string{entry.Action, entry.Account, entry.Target}
+	if entry.Operator != "" {
+		data = append(data, entry.Operator)
+	}
+	stub.EmitEvent("event_admin_audit", data)
+	return entry, auditKey + "#" + field, nil
+}
+
+// auditSuccess 记录审计并返回写方法结果, 主记录为审计记录
+func auditSuccess(rec *txRecorder, method, account, target, value string) protogo.Response {
+	entry, key, err := audit(rec, method, account, target, value)
+	if err != nil {
+		return failResponse(rec, method, err)
+	}
+	return rec.success(method, strconv.Itoa(entry.Seq), key, 1, entry)
+}
+
+// PauseContract 管理方法: 暂停全部写方法, 或指定的写方法
+// 文档: pauseContract({account, methodName?})
+func (tc *TokenContract) PauseContract(stub shim.CMStubInterface) protogo.Response {
+	return setPaused(stub, "pauseContract", true)
+}
+
+// UnpauseContract 管理方法: 恢复全部写方法, 或指定的写方法
+// 文档: unpauseContract({account, methodName?})
+func (tc *TokenContract) UnpauseContract(stub shim.CMStubInterface) protogo.Response {
+	return setPaused(stub, "unpauseContract", false)
+}
+
+// setPaused 修改暂停开关, methodName 为空时作用于全部写方法
+func setPaused(stub shim.CMStubInterface, method string, pause bool) protogo.Response {
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	target := string(args["methodName"])
+	if account == "" {
+		return missingParam(stub, method, "account")
+	}
+	if err := checkAdmin(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if target == "" {
+		target = pauseAllMethods
+	} else if !pausable(target) {
+		return errorResponse(stub, method, ErrInvalidParam, "methodName", "not a pausable write method: "+target)
+	}
+
+	paused, err := readIndex(stub, pausedKey)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	kept := make([]string, 0, len(paused)+1)
+	for _, name := range paused {
+		if name != target {
+			kept = append(kept, name)
+		}
+	}
+	if pause {
+		kept = append(kept, target)
+	}
+	if err := putIndex(stub, pausedKey, kept); err != nil {
+		return failResponse(stub, method, err)
+	}
+	return auditSuccess(rec, method, account, target, "")
+}
+
+// SetConfig 管理方法: 修改配置项, value 为 JSON 值
+// 文档: setConfig({account, name, value})
+func (tc *TokenContract) SetConfig(stub shim.CMStubInterface) protogo.Response {
+	const method = "setConfig"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	name := string(args["name"])
+	value := args["value"]
+	if field := missingArg(args, "account", "name", "value"); field != "" {
+		return missingParam(stub, method, field)
+	}
+	if err := checkAdmin(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := applyConfig(stub, name, value); err != nil {
+		return failResponse(stub, method, err)
+	}
+	return auditSuccess(rec, method, account, name, string(value))
+}
+
+// RotateAdmin 管理方法: 增加和/或移除管理员, 至少保留一名管理员
+// 文档: rotateAdmin({account, add?, remove?})
+func (tc *TokenContract) RotateAdmin(stub shim.CMStubInterface) protogo.Response {
+	const method = "rotateAdmin"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	add := string(args["add"])
+	remove := string(args["remove"])
+	if account == "" {
+		return missingParam(stub, method, "account")
+	}
+	if add == "" && remove == "" {
+		return errorResponse(stub, method, ErrMissingParam, "add", "missing required param: 'add' or 'remove'")
+	}
+	if err := checkAdmin(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	admins, err := readIndex(stub, adminsKey)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if remove != "" {
+		if !containsId(admins, remove) {
+			return errorResponse(stub, method, ErrNotFound, "remove", "not an admin: "+remove)
+		}
+		kept := make([]string, 0, len(admins))
+		for _, admin := range admins {
+			if admin != remove {
+				kept = append(kept, admin)
+			}
+		}
+		admins = kept
+	}
+	if add != "" {
+		if containsId(admins, add) {
+			return errorResponse(stub, method, ErrAlreadyExists, "add", "already an admin: "+add)
+		}
+		admins = append(admins, add)
+	}
+	if len(admins) == 0 {
+		return errorResponse(stub, method, ErrForbidden, "remove", "can not remove the last admin")
+	}
+	if err := putIndex(stub, adminsKey, admins); err != nil {
+		return failResponse(stub, method, err)
+	}
+	adminsBytes, _ := json.Marshal(admins)
+	return auditSuccess(rec, method, account, add+"/"+remove, string(adminsBytes))
+}
+
+// RequestAdminInfo 查询管理员、暂停开关及配置
+// 文档: requestAdminInfo({})
+func (tc *TokenContract) RequestAdminInfo(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestAdminInfo"
+	info := AdminInfo{Enums: map[string]enumBounds{}}
+	var err error
+	if info.Admins, err = readIndex(stub, adminsKey); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if info.Paused, err = readIndex(stub, pausedKey); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if info.StrictContent, err = isStrictContentMode(stub); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if info.Regulators, err = readIndex(stub, "config_regulators"); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if info.TokenBaseURI, err = getConfigString(stub, tokenBaseURIKey); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if info.PaymentContract, err = getConfigString(stub, paymentContractKey); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if info.PaymentAttestors, err = readIndex(stub, paymentAttestorsKey); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if info.Arbitrators, err = readIndex(stub, arbitratorsKey); err != nil {
+		return failResponse(stub, method, err)
+	}
+	for name := range configurableEnums {
+		bounds, err := getEnumBounds(stub, name)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if bounds != nil {
+			info.Enums[name] = *bounds
+		}
+	}
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal admin info error: %s", err.Error()))
+	}
+	return shim.Success(infoBytes)
+}
+
+// RequestAuditLog 分页查询管理操作审计记录, 按时间先后排列
+// 文档: requestAuditLog({cursor?, pageSize?})
+func (tc *TokenContract) RequestAuditLog(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestAuditLog"
+	args := stub.GetArgs()
+
+	pageSize, err := parsePageSize(string(args["pageSize"]))
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	cursorField, err := decodeCursor(string(args["cursor"]), len(holdingField(0)))
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	page := AuditPage{Entries: []AuditEntry{}}
+	if metaBytes, err := stub.GetStateFromKeyByte(auditMetaKey); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to GetState for %s: %s", auditMetaKey, err.Error()))
+	} else if len(metaBytes) > 0 {
+		page.Total, _ = strconv.Atoi(string(metaBytes))
+	}
+
+	var decodeErr error
+	err = rangeFields(stub, auditKey, cursorField, holdingFieldEnd, func(field string, value []byte) bool {
+		if len(page.Entries) == pageSize {
+			page.NextCursor = encodeCursor(field)
+			return false
+		}
+		var entry AuditEntry
+		if decodeErr = json.Unmarshal(value, &entry); decodeErr != nil {
+			return false
+		}
+		page.Entries = append(page.Entries, entry)
+		return true
+	})
+	if err == nil && decodeErr != nil {
+		err = fmt.Errorf("unmarshal audit entry error: %s", decodeErr.Error())
+	}
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	pageBytes, err := json.Marshal(page)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal audit page error: %s", err.Error()))
+	}
+	return shim.Success(pageBytes)
+}
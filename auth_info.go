@@ -0,0 +1,212 @@
+package main
+
+import (
+	"chainmaker/shim"
+	"fmt"
+	"time"
+)
+
+// 确权信息版本管理: 同一确权机构下 authenticationId 唯一
+// 更正时原版本标记为 superseded 并追加新版本, 全部版本保留在 TokenDetail.authenticationInfos 中
+// 登记、更正及撤销均须由已登记的确权机构提交(见 institution.go), 只能更正或撤销本机构的确权信息
+// 引入版本管理之前写入的确权信息没有 status 及签发账户, 视为有效的第 1 版, 按名称对应机构, 监管机构同样可以更正或撤销
+const (
+	authValid      = "valid"
+	authRevoked    = "revoked"
+	authSuperseded = "superseded"
+
+	authActionAdd     = "add"
+	authActionCorrect = "correct"
+	authActionRevoke  = "revoke"
+)
+
+// current 是否为当前有效的版本
+func (a *AuthenticationInfo) current() bool {
+	return a.Status == "" || a.Status == authValid
+}
+
+// sameEntry 是否为同一确权机构的同一确权编号, 未记录 institutionId 的旧数据按机构名称比较
+func (a *AuthenticationInfo) sameEntry(other *AuthenticationInfo) bool {
+	if a.AuthenticationId != other.AuthenticationId {
+		return false
+	}
+	if a.InstitutionId != "" && other.InstitutionId != "" {
+		return a.InstitutionId == other.InstitutionId
+	}
+	return a.AuthenticationInstitudeName == other.AuthenticationInstitudeName
+}
+
+// stamp 填写版本、状态及写入账户, 覆盖调用方传入的值
+func (a *AuthenticationInfo) stamp(stub shim.CMStubInterface, version int, issuer, account string) error {
+	now, err := txTime(stub)
+	if err != nil {
+		return err
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	a.Version = version
+	a.Status = authValid
+	a.Issuer = issuer
+	a.UpdatedBy = account
+	a.UpdatedAt = now.Format(time.RFC3339)
+	a.TxId = txId
+	a.RevokedBy, a.RevokedAt = "", ""
+	return nil
+}
+
+// checkAuthInfo 校验确权编号必填
+func checkAuthInfo(field string, info *AuthenticationInfo) error {
+	if info.AuthenticationId == "" {
+		return newError(ErrMissingParam, field, "missing required field: 'authenticationId'")
+	}
+	return nil
+}
+
+// stampAuthInfos 发行时登记的确权信息均为发行账户的第 1 版, 须由有效的确权机构签名或由其账户发行, 同一确权机构下编号不可重复
+func stampAuthInfos(stub shim.CMStubInterface, tokenId string, infos []AuthenticationInfo, account string) error {
+	const field = "tokenObject.authenticationInfos"
+	for i := range infos {
+		if err := checkAuthInfo(field, &infos[i]); err != nil {
+			return err
+		}
+		institution, err := resolveInstitution(stub, field, &infos[i], true)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < i; j++ {
+			if infos[j].sameEntry(&infos[i]) {
+				return newError(ErrAlreadyExists, field, "duplicate authenticationId %s of %s", infos[i].AuthenticationId, infos[i].AuthenticationInstitudeName)
+			}
+		}
+		if err := institution.authorize(stub, field, authActionAdd, tokenId, account, &infos[i]); err != nil {
+			return err
+		}
+		if err := validateDate(field+".authenticatedDate", infos[i].AuthenticatedDate); err != nil {
+			return err
+		}
+		if err := infos[i].stamp(stub, 1, account, account); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findCurrentAuthInfo 同一确权编号的当前有效版本下标, 不存在时为 -1; exists 表示是否有任一版本
+func findCurrentAuthInfo(infos []AuthenticationInfo, info *AuthenticationInfo) (idx int, exists bool) {
+	idx = -1
+	for i := range infos {
+		if !infos[i].sameEntry(info) {
+			continue
+		}
+		exists = true
+		if infos[i].current() {
+			idx = i
+		}
+	}
+	return idx, exists
+}
+
+// checkAuthIssuer 校验账户可更正或撤销该确权信息: 机构账户或机构签名, 未记录签发账户的旧数据监管机构同样可以
+func checkAuthIssuer(stub shim.CMStubInterface, institution *AuthInstitution, action, tokenId, account string, entry, info *AuthenticationInfo, proven bool) error {
+	if proven {
+		return nil
+	}
+	err := institution.authorize(stub, "authenticationInfo", action, tokenId, account, info)
+	if err == nil || entry.Issuer != "" {
+		return err
+	}
+	regulator, rerr := isRegulator(stub, account)
+	if rerr != nil {
+		return rerr
+	}
+	if !regulator {
+		return err
+	}
+	return nil
+}
+
+// applyAuthAction 按 action 登记、更正或撤销确权信息
+// proven 为 true 表示确权信息来自已由机构公钥验证的凭证, 不再校验提交账户
+func applyAuthAction(stub shim.CMStubInterface, detail *TokenDetail, action, account, reason string, info AuthenticationInfo, proven bool) error {
+	const field = "authenticationInfo"
+	if err := checkAuthInfo(field, &info); err != nil {
+		return err
+	}
+	if action == "" {
+		action = authActionAdd
+	}
+	// 撤销不要求机构仍为 active
+	institution, err := resolveInstitution(stub, field, &info, action != authActionRevoke)
+	if err != nil {
+		return err
+	}
+	idx, exists := findCurrentAuthInfo(detail.AuthenticationInfos, &info)
+	switch action {
+	case authActionAdd:
+		if !proven {
+			if err := institution.authorize(stub, field, action, detail.TokenId, account, &info); err != nil {
+				return err
+			}
+		}
+		if exists {
+			return newError(ErrAlreadyExists, field, "authenticationId %s of %s already exists, use action=correct", info.AuthenticationId, info.AuthenticationInstitudeName)
+		}
+		if err := validateDate(field+".authenticatedDate", info.AuthenticatedDate); err != nil {
+			return err
+		}
+		if err := info.stamp(stub, 1, account, account); err != nil {
+			return err
+		}
+		info.Reason = reason
+		detail.AuthenticationInfos = append(detail.AuthenticationInfos, info)
+	case authActionCorrect:
+		if idx < 0 {
+			return newError(ErrNotFound, field, "no valid authenticationId %s of %s", info.AuthenticationId, info.AuthenticationInstitudeName)
+		}
+		old := &detail.AuthenticationInfos[idx]
+		if err := checkAuthIssuer(stub, institution, action, detail.TokenId, account, old, &info, proven); err != nil {
+			return err
+		}
+		if err := validateDate(field+".authenticatedDate", info.AuthenticatedDate); err != nil {
+			return err
+		}
+		version := old.Version
+		if version == 0 {
+			version = 1
+		}
+		issuer := old.Issuer
+		if issuer == "" {
+			issuer = account
+		}
+		old.Status = authSuperseded
+		if err := info.stamp(stub, version+1, issuer, account); err != nil {
+			return err
+		}
+		info.Reason = reason
+		detail.AuthenticationInfos = append(detail.AuthenticationInfos, info)
+	case authActionRevoke:
+		if idx < 0 {
+			return newError(ErrNotFound, field, "no valid authenticationId %s of %s", info.AuthenticationId, info.AuthenticationInstitudeName)
+		}
+		entry := &detail.AuthenticationInfos[idx]
+		if err := checkAuthIssuer(stub, institution, action, detail.TokenId, account, entry, &info, proven); err != nil {
+			return err
+		}
+		now, err := txTime(stub)
+		if err != nil {
+			return err
+		}
+		if entry.Version == 0 {
+			entry.Version = 1
+		}
+		entry.Status = authRevoked
+		entry.RevokedBy = account
+		entry.RevokedAt = now.Format(time.RFC3339)
+		entry.Reason = reason
+	default:
+		return newError(ErrInvalidParam, "action", "action must be 'add', 'correct' or 'revoke', got: %s", action)
+	}
+	return nil
+}
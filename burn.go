@@ -0,0 +1,326 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 通证类型(销毁时区分存储位置)
+const (
+	TokenTypeCopyright = 1 // 版权通证 publish_token_
+	TokenTypeApprove   = 2 // 授权通证 approve_token_
+	TokenTypeLicense   = 3 // 许可 pub_token_tx_
+)
+
+// Tombstone 通证销毁记录, 销毁后保留用于溯源查询
+type Tombstone struct {
+	TokenId     string          `json:"tokenId"`
+	TokenType   int             `json:"tokenType"`             // 1=版权通证,2=授权通证,3=许可
+	Token       string          `json:"token"`                 // 通证名称(类别)
+	Owner       string          `json:"owner"`                 // 销毁前的持有者
+	BurnedBy    string          `json:"burnedBy"`              // 发起销毁的账户
+	Reason      string          `json:"reason,omitempty"`      // 销毁原因
+	CascadeFrom string          `json:"cascadeFrom,omitempty"` // 级联销毁时的上级通证
+	TxId        string          `json:"txId"`                  // 销毁交易ID
+	Record      json.RawMessage `json:"record"`                // 销毁前的通证数据
+
+	RecordVersion int `json:"recordVersion"` // 销毁前的记录版本号 + 1
+}
+
+// burnTarget 待销毁通证的统一视图
+type burnTarget struct {
+	tokenType  int
+	tokenId    string
+	storeKey   string
+	token      string
+	owner      string
+	coOwners   []string
+	frozen     bool
+	disputeId  string // 版权通证处于争议状态时为受理中的争议
+	record     []byte
+	version    int      // 记录版本号
+	dependents []string // 依赖本通证的下级通证ID
+}
+
+// BuildBurnTokenTx 通证销毁(退役)
+// 文档: buildBurnTokenTx({account, tokenId, tokenType, reason?, cascade?, signers?})
+// 持有者发起(多人共有时需全部共有人签名), 或由监管机构发起;
+// 存在下级授权通证/许可时, 需 cascade=true 级联销毁, 否则拒绝
+func (tc *TokenContract) BuildBurnTokenTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildBurnTokenTx"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	tokenId := string(args["tokenId"])
+	tokenTypeStr := string(args["tokenType"])
+	reason := string(args["reason"])
+	cascadeStr := string(args["cascade"])
+	signersStr := string(args["signers"]) // JSON数组, 共有人签名账户
+
+	if name := missingArg(args, "account", "tokenId", "tokenType"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	tokenType, err := strconv.Atoi(tokenTypeStr)
+	if err != nil || !validEnum(stub, "tokenType", tokenType) {
+		return errorResponse(stub, method, ErrInvalidEnum, "tokenType", "tokenType must be 1(版权通证), 2(授权通证) or 3(许可), got: "+tokenTypeStr)
+	}
+	cascade := false
+	if cascadeStr != "" {
+		cascade, err = strconv.ParseBool(cascadeStr)
+		if err != nil {
+			return errorResponse(stub, method, ErrInvalidParam, "cascade", "cascade must be bool, got: "+cascadeStr)
+		}
+	}
+	var signers []string
+	if signersStr != "" {
+		if err := json.Unmarshal([]byte(signersStr), &signers); err != nil {
+			return errorResponse(stub, method, ErrInvalidParam, "signers", "fail to parse 'signers': "+err.Error())
+		}
+	}
+
+	// 1. 读取待销毁通证
+	target, err := loadBurnTarget(stub, tokenType, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if target == nil {
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no token found for tokenId="+tokenId)
+	}
+	if err := checkExpectedVersion(args, target.version); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 2. 权限校验: 监管机构, 或持有者 + 全部共有人签名
+	regulator, err := isRegulator(stub, account)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if !regulator {
+		if target.owner != account {
+			return errorResponse(stub, method, ErrForbidden, "account", "account is neither the owner nor a regulator: "+account)
+		}
+		if target.frozen {
+			return errorResponse(stub, method, ErrFrozen, "tokenId", "token is frozen, only a regulator can burn it: "+tokenId)
+		}
+		if target.disputeId != "" {
+			return errorResponse(stub, method, ErrDisputed, "tokenId", "token is under dispute "+target.disputeId+", only a regulator can burn it: "+tokenId)
+		}
+		if tokenType == TokenTypeCopyright {
+			if err := checkEscrow(stub, tokenId, account, ""); err != nil {
+				return failResponse(stub, method, err)
+			}
+		}
+		signerMap := map[string]bool{account: true}
+		for _, s := range signers {
+			signerMap[s] = true
+		}
+		for _, coOwner := range target.coOwners {
+			if !signerMap[coOwner] {
+				return errorResponse(stub, method, ErrForbidden, "signers", "multi-sign failed, missing co-owner: "+coOwner)
+			}
+		}
+	}
+
+	// 3. 下级通证: 未指定级联时拒绝
+	if len(target.dependents) > 0 && !cascade {
+		return errorResponse(stub, method, ErrHasDependents, "cascade", fmt.Sprintf("token %s has %d active dependent tokens (%s), burn them first or set cascade=true",
+			tokenId, len(target.dependents), strings.Join(target.dependents, ",")))
+	}
+
+	// 4. 销毁(含级联)
+	// 被销毁的全部通证可由返回的 keys 及 event_burn_token 事件得到
+	tombstones, err := burnToken(stub, target, account, reason, "")
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	tombstone := tombstones[len(tombstones)-1]
+	return rec.success(method, tokenId, "tombstone_"+tokenId, tombstone.RecordVersion, tombstone)
+}
+
+// RequestTombstone 查询通证销毁记录
+// 文档: requestTombstone({tokenId})
+func (tc *TokenContract) RequestTombstone(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestTombstone"
+	tokenId := string(stub.GetArgs()["tokenId"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+	tombstoneBytes, err := stub.GetStateFromKeyByte("tombstone_" + tokenId)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("GetState failed: %s", err.Error()))
+	}
+	if len(tombstoneBytes) == 0 {
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no tombstone found for tokenId: "+tokenId)
+	}
+	return shim.Success(tombstoneBytes)
+}
+
+// isBurned 判断通证ID是否已被销毁(销毁后不可重新发行)
+func isBurned(stub shim.CMStubInterface, tokenId string) (bool, error) {
+	tombstoneBytes, err := stub.GetStateFromKeyByte("tombstone_" + tokenId)
+	if err != nil {
+		return false, fmt.Errorf("fail to GetState tombstone for %s: %s", tokenId, err.Error())
+	}
+	return len(tombstoneBytes) > 0, nil
+}
+
+// loadBurnTarget 按通证类型读取通证, 不存在时返回 nil
+func loadBurnTarget(stub shim.CMStubInterface, tokenType int, tokenId string) (*burnTarget, error) {
+	target := &burnTarget{tokenType: tokenType, tokenId: tokenId}
+	var dependentIndex string
+	switch tokenType {
+	case TokenTypeCopyright:
+		target.storeKey = "publish_token_" + tokenId
+	case TokenTypeApprove:
+		target.storeKey = "approve_token_" + tokenId
+		dependentIndex = "license_index_" + tokenId
+	default:
+		target.storeKey = "pub_token_tx_" + tokenId
+	}
+
+	record, err := stub.GetStateFromKeyByte(target.storeKey)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for %s: %s", target.storeKey, err.Error())
+	}
+	if len(record) == 0 {
+		return nil, nil
+	}
+	target.record = record
+
+	switch tokenType {
+	case TokenTypeCopyright:
+		var detail TokenDetail
+		if err := json.Unmarshal(record, &detail); err != nil {
+			return nil, fmt.Errorf("unmarshal token %s error: %s", tokenId, err.Error())
+		}
+		target.token = detail.Token
+		target.owner = detail.OwnerAccount
+		target.frozen = detail.Frozen
+		target.disputeId = detail.DisputeId
+		target.version = detail.RecordVersion
+		for _, cu := range detail.CopyrightUnits {
+			if cu.Address != detail.OwnerAccount {
+				target.coOwners = append(target.coOwners, cu.Address)
+			}
+		}
+	case TokenTypeApprove:
+		var approveToken ApproveToken
+		if err := json.Unmarshal(record, &approveToken); err != nil {
+			return nil, fmt.Errorf("unmarshal approve token %s error: %s", tokenId, err.Error())
+		}
+		target.token = approveToken.Token
+		target.owner = approveToken.Receiver
+		target.version = approveToken.RecordVersion
+	default:
+		var pubTx PubTokenTx
+		if err := json.Unmarshal(record, &pubTx); err != nil {
+			return nil, fmt.Errorf("unmarshal license %s error: %s", tokenId, err.Error())
+		}
+		target.token = pubTx.Token
+		target.owner = pubTx.Receiver
+		target.version = pubTx.RecordVersion
+	}
+
+	// 仍然有效的下级通证
+	var ids []string
+	if tokenType == TokenTypeCopyright {
+		ids, err = approveRefs(stub, tokenId)
+	} else if dependentIndex != "" {
+		ids, err = readIndex(stub, dependentIndex)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		burned, err := isBurned(stub, id)
+		if err != nil {
+			return nil, err
+		}
+		if !burned {
+			target.dependents = append(target.dependents, id)
+		}
+	}
+	return target, nil
+}
+
+// burnToken 销毁通证及其下级通证, 返回全部销毁记录, 本通证的记录在最后
+func burnToken(stub shim.CMStubInterface, target *burnTarget, account, reason, cascadeFrom string) ([]*Tombstone, error) {
+	var burned []*Tombstone
+
+	// 先级联销毁下级通证
+	for _, dependentId := range target.dependents {
+		dependent, err := loadBurnTarget(stub, target.tokenType+1, dependentId)
+		if err != nil {
+			return nil, err
+		}
+		if dependent == nil {
+			continue
+		}
+		tombstones, err := burnToken(stub, dependent, account, reason, target.tokenId)
+		if err != nil {
+			return nil, err
+		}
+		burned = append(burned, tombstones...)
+	}
+
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return nil, fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	tombstone := &Tombstone{
+		TokenId:       target.tokenId,
+		TokenType:     target.tokenType,
+		Token:         target.token,
+		Owner:         target.owner,
+		BurnedBy:      account,
+		Reason:        reason,
+		CascadeFrom:   cascadeFrom,
+		TxId:          txId,
+		Record:        target.record,
+		RecordVersion: target.version + 1,
+	}
+	tombstoneBytes, err := json.Marshal(tombstone)
+	if err != nil {
+		return nil, fmt.Errorf("marshal tombstone error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte("tombstone_"+target.tokenId, tombstoneBytes); err != nil {
+		return nil, fmt.Errorf("fail to PutState tombstone: %s", err.Error())
+	}
+	if err := stub.DelStateFromKey(target.storeKey); err != nil {
+		return nil, fmt.Errorf("fail to DelState %s: %s", target.storeKey, err.Error())
+	}
+
+	if target.tokenType == TokenTypeCopyright {
+		if err := removeHolding(stub, target.owner, target.tokenId); err != nil {
+			return nil, err
+		}
+		// 释放内容哈希绑定, 允许重新登记
+		var detail TokenDetail
+		if err := json.Unmarshal(target.record, &detail); err == nil && detail.ContentHash != "" {
+			binding, err := getContentBinding(stub, detail.ContentHash)
+			if err != nil {
+				return nil, err
+			}
+			if binding != nil && binding.TokenId == target.tokenId {
+				if err := stub.DelStateFromKey("content_hash_" + detail.ContentHash); err != nil {
+					return nil, fmt.Errorf("fail to DelState content binding: %s", err.Error())
+				}
+			}
+		}
+	}
+
+	if target.token != "" {
+		if err := recordBurned(stub, target.token); err != nil {
+			return nil, err
+		}
+	}
+
+	stub.EmitEvent("event_burn_token", []string{target.tokenId, strconv.Itoa(target.tokenType), account, cascadeFrom})
+	return append(burned, tombstone), nil
+}
@@ -0,0 +1,113 @@
+// Package client 构建 TokenContract 调用参数并解析返回结果
+//
+// 合约方法从 map[string][]byte 读取参数, 其中部分参数为 JSON 字符串,
+// 且参数名并不统一(如 reference_flag / referenceFlag, referenceID / referenceId)。
+// 本包为每个方法提供带类型的请求结构, 由 Build 生成与合约完全一致的参数表,
+// 业务服务无需再手工拼装参数。
+//
+//	args, err := client.Build(&client.PublishTokenRequest{...})
+//	// 通过长安链 SDK 发送交易, 合约名 + args
+//	detail, err := client.DecodeTokenDetail(payload)
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Request 合约方法调用请求
+type Request interface {
+	// Method 合约方法名
+	Method() string
+	// Args 方法参数(不含 method)
+	Args() (map[string][]byte, error)
+}
+
+// Build 生成 InvokeContract 调用参数, 包含 method 字段
+func Build(req Request) (map[string][]byte, error) {
+	args, err := req.Args()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", req.Method(), err.Error())
+	}
+	args["method"] = []byte(req.Method())
+	return args, nil
+}
+
+// BuildAsOperator 生成操作员代理调用参数, 请求中的调用者(account / publisher)为委托人
+// 委托人须已通过 grantOperator 授予 operator 覆盖该调用的代理权, 交易须由 operator 签名发送
+func BuildAsOperator(req Request, operator string) (map[string][]byte, error) {
+	if operator == "" {
+		return nil, fmt.Errorf("%s: operator is required", req.Method())
+	}
+	args, err := Build(req)
+	if err != nil {
+		return nil, err
+	}
+	args["operatorAccount"] = []byte(operator)
+	return args, nil
+}
+
+// argMap 参数表构建辅助
+type argMap map[string][]byte
+
+// str 写入字符串参数, 空字符串不写入
+func (a argMap) str(key, value string) {
+	if value != "" {
+		a[key] = []byte(value)
+	}
+}
+
+// num 写入整数参数
+func (a argMap) num(key string, value int) {
+	a[key] = []byte(strconv.Itoa(value))
+}
+
+// optNum 写入可选整数参数, nil 不写入
+func (a argMap) optNum(key string, value *int) {
+	if value != nil {
+		a.num(key, *value)
+	}
+}
+
+// boolean 写入布尔参数
+func (a argMap) boolean(key string, value bool) {
+	a[key] = []byte(strconv.FormatBool(value))
+}
+
+// json 写入 JSON 字符串参数, nil 不写入
+func (a argMap) json(key string, value interface{}) error {
+	if isNil(value) {
+		return nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %s", key, err.Error())
+	}
+	a[key] = b
+	return nil
+}
+
+// isNil 判断切片/指针/映射参数是否为空
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// Int 返回整数指针, 用于可选整数字段
+func Int(v int) *int {
+	return &v
+}
+
+// Bool 返回布尔指针, 用于可选布尔字段
+func Bool(v bool) *bool {
+	return &v
+}
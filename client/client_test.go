@@ -0,0 +1,144 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBuildArgs(t *testing.T) {
+	cases := []struct {
+		name string
+		req  Request
+		want map[string]string
+	}{
+		{
+			name: "token issue uses reference_flag",
+			req: &TokenIssueRequest{Account: "0xa", Publisher: "0xp", Token: "T", Number: 10,
+				Flag: 0, Version: "v2", ReferenceFlag: 1},
+			want: map[string]string{"method": "buildTokenIssueTx", "account": "0xa", "publisher": "0xp",
+				"token": "T", "number": "10", "flag": "0", "version": "v2", "reference_flag": "1"},
+		},
+		{
+			name: "publish token uses referenceFlag",
+			req: &PublishTokenRequest{Publisher: "0xp", Receiver: "0xr", Token: "T", ReferenceFlag: 1,
+				TokenObject: &TokenObject{TokenId: "id", WorkId: "w"}},
+			want: map[string]string{"method": "buildPublishTokenTx", "publisher": "0xp", "receiver": "0xr",
+				"token": "T", "referenceFlag": "1",
+				"tokenObject": `{"flag":0,"tokenId":"id","authenticationInfos":null,"copyrightType":0,"copyrightGetType":0,"copyrightUnits":null,"constraintExplain":"","constraintExpand":0,"copyrightConstraint":null,"apprConstraint":null,"licenseConstraint":null,"workId":"w","copyrightStatus":null}`},
+		},
+		{
+			name: "approve token uses referenceID",
+			req: &PublishApproveTokenRequest{Publisher: "0xp", Receiver: "0xr", Token: "A", TokenId: "b",
+				ReferenceID: "a", ApproveType: 1, DerivativeUses: []int{0, 1}, Time: "2025-01-01/2026-01-01"},
+			want: map[string]string{"method": "buildPublishApproveTokenTx", "publisher": "0xp", "receiver": "0xr",
+				"token": "A", "tokenId": "b", "referenceID": "a", "approveType": "1",
+				"derivativeUses": "[0,1]", "time": "2025-01-01/2026-01-01"},
+		},
+		{
+			name: "license uses referenceId",
+			req: &PubTokenRequest{Publisher: "0xp", Receiver: "0xr", Token: "L", TokenId: "c", ReferenceId: "b",
+				TokenInfos: []TokenInfo{{Type: "k", Data: "v"}}},
+			want: map[string]string{"method": "buildPubTokenTx", "publisher": "0xp", "receiver": "0xr",
+				"token": "L", "tokenId": "c", "referenceId": "b", "tokenInfos": `[{"type":"k","data":"v"}]`},
+		},
+		{
+			name: "account token without flag",
+			req:  &RequestAccountTokenRequest{Account: "0xa", Version: "v1"},
+			want: map[string]string{"method": "requestAccountToken", "account": "0xa", "version": "v1"},
+		},
+		{
+			name: "account token with zero flag",
+			req:  &RequestAccountTokenRequest{Account: "0xa", Version: "v2", Flag: Int(0)},
+			want: map[string]string{"method": "requestAccountToken", "account": "0xa", "version": "v2", "flag": "0"},
+		},
+		{
+			name: "modify flag method name",
+			req:  &ModifyCopyrightTokenFlagRequest{Account: "0xreg", TokenId: "a", Flag: 1},
+			want: map[string]string{"method": "BuildModifyCopyrightTokenFlagTx", "account": "0xreg", "tokenId": "a", "flag": "1"},
+		},
+		{
+			name: "burn with cascade and signers",
+			req:  &BurnTokenRequest{Account: "0xa", TokenId: "a", TokenType: 1, Cascade: true, Signers: []string{"0xb"}},
+			want: map[string]string{"method": "buildBurnTokenTx", "account": "0xa", "tokenId": "a", "tokenType": "1",
+				"cascade": "true", "signers": `["0xb"]`},
+		},
+		{
+			name: "constraint update",
+			req: &ModifyConstraintRequest{TokenId: "a", Constraint: &ConstraintUpdate{TokenId: "a",
+				Constraint: Constraint{CopyrightLimit: 2}, Signers: []string{"0xa"}}},
+			want: map[string]string{"method": "buildModifyConstraintTx", "tokenId": "a",
+				"constraint": `{"tokenId":"a","constraint":{"copyrightLimit":2,"apprConstraint":{"channel":"","area":"","time":"","transferType":0,"reapproveType":0},"licenseConstraint":{"type":"","area":"","time":""},"constraintExplain":"","constraintExpand":0},"signers":["0xa"]}`},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			args, err := Build(c.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got := make(map[string]string, len(args))
+			for k, v := range args {
+				got[k] = string(v)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("args mismatch\n got: %v\nwant: %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestBuildMissingObject(t *testing.T) {
+	if _, err := Build(&PublishTokenRequest{Publisher: "0xp"}); err == nil {
+		t.Fatal("expected error for missing tokenObject")
+	}
+}
+
+func TestInitArgs(t *testing.T) {
+	args, err := (&InitArgs{StrictContent: true, Regulators: []string{"0xreg"}}).Args()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(args["strictContent"]) != "true" || string(args["regulators"]) != `["0xreg"]` {
+		t.Fatalf("unexpected init args: %v", args)
+	}
+	if _, ok := args["method"]; ok {
+		t.Fatal("init args must not carry method")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	detail := TokenDetail{TokenId: "a", Version: "v2", Flag: 1, OwnerAccount: "0xr",
+		CopyrightUnits: []CopyrightUnit{{Address: "0xr", Proportion: "100"}}}
+	payload, _ := json.Marshal(detail)
+	got, err := DecodeTokenDetail(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(*got, detail) {
+		t.Fatalf("decoded %+v, want %+v", *got, detail)
+	}
+
+	page, err := DecodeAccountTokens([]byte(`{"tokens":null,"total":0}`))
+	if err != nil || page.Tokens == nil || len(page.Tokens) != 0 {
+		t.Fatalf("expected empty page, got %v, %v", page, err)
+	}
+	if _, err := DecodeWork(nil); err == nil {
+		t.Fatal("expected error for empty payload")
+	}
+	if err := (&Response{Status: 500, Message: "[x] boom"}).Err(); err == nil || err.Error() != "[x] boom" {
+		t.Fatalf("unexpected response error: %v", err)
+	}
+	result, err := DecodeTxResult([]byte(`{"method":"buildPublishApproveTokenTx","id":"b1","recordVersion":2,"record":{"tokenId":"b1","recordVersion":2},"events":[{"id":"tx1#0","topic":"event_approve_token"}]}`))
+	if err != nil || result.Id != "b1" || len(result.Events) != 1 {
+		t.Fatalf("unexpected tx result: %+v, %v", result, err)
+	}
+	var approveToken ApproveToken
+	if err := result.DecodeRecord(&approveToken); err != nil || approveToken.RecordVersion != 2 {
+		t.Fatalf("unexpected record: %+v, %v", approveToken, err)
+	}
+	err = (&Response{Status: 500, Message: `{"code":"NOT_FOUND","message":"no token found","field":"tokenId","method":"requestTokenInfo"}`}).Err()
+	if ce, ok := err.(*ContractError); !ok || ce.Code != "NOT_FOUND" || ce.Field != "tokenId" {
+		t.Fatalf("expected structured error, got %#v", err)
+	}
+}
@@ -0,0 +1,1189 @@
+package client
+
+import "errors"
+
+// InitArgs 合约初始化(InitContract)参数, 不经 Build 添加 method
+type InitArgs struct {
+	StrictContent bool                   // 开启内容哈希严格校验模式
+	Regulators    []string               // 监管机构账户
+	Admins        []string               // 管理员账户, 为空时合约以部署交易的发送者为管理员
+	Config        map[string]interface{} // 配置项, 同 SetConfigRequest 的 Name/Value
+}
+
+// Args 生成 InitContract 参数
+func (r *InitArgs) Args() (map[string][]byte, error) {
+	a := argMap{}
+	if r.StrictContent {
+		a.boolean("strictContent", true)
+	}
+	if err := a.json("regulators", r.Regulators); err != nil {
+		return nil, err
+	}
+	if err := a.json("admins", r.Admins); err != nil {
+		return nil, err
+	}
+	if err := a.json("config", r.Config); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// TokenIssueRequest 通证初始化 buildTokenIssueTx
+type TokenIssueRequest struct {
+	Account         string
+	Publisher       string
+	Token           string
+	Number          int
+	Flag            int
+	Version         string
+	Roles           []TokenRole
+	ReferenceFlag   int  // 参数名为 reference_flag
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *TokenIssueRequest) Method() string { return "buildTokenIssueTx" }
+
+func (r *TokenIssueRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("publisher", r.Publisher)
+	a.str("token", r.Token)
+	a.num("number", r.Number)
+	a.num("flag", r.Flag)
+	a.str("version", r.Version)
+	a.num("reference_flag", r.ReferenceFlag)
+	if err := a.json("roles", r.Roles); err != nil {
+		return nil, err
+	}
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// PublishTokenRequest 一般通证发行 buildPublishTokenTx
+type PublishTokenRequest struct {
+	Publisher       string
+	Receiver        string
+	Token           string
+	ReferenceFlag   int // 参数名为 referenceFlag
+	TokenObject     *TokenObject
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *PublishTokenRequest) Method() string { return "buildPublishTokenTx" }
+
+func (r *PublishTokenRequest) Args() (map[string][]byte, error) {
+	if r.TokenObject == nil {
+		return nil, errors.New("tokenObject is required")
+	}
+	a := argMap{}
+	a.str("publisher", r.Publisher)
+	a.str("receiver", r.Receiver)
+	a.str("token", r.Token)
+	a.num("referenceFlag", r.ReferenceFlag)
+	if err := a.json("tokenObject", r.TokenObject); err != nil {
+		return nil, err
+	}
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// PublishApproveTokenRequest 授权通证发行 buildPublishApproveTokenTx
+type PublishApproveTokenRequest struct {
+	Publisher          string
+	Receiver           string
+	Token              string
+	TokenId            string
+	ReferenceID        string // 参数名为 referenceID
+	ApproveType        int
+	ApproveConstraints []ApproveConstraint
+	Duty               []DutyInfo
+	DerivativeUses     []int
+	Time               string // ISO-8601 区间 start/end
+	ExpectedVersion    *int   // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *PublishApproveTokenRequest) Method() string { return "buildPublishApproveTokenTx" }
+
+func (r *PublishApproveTokenRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("publisher", r.Publisher)
+	a.str("receiver", r.Receiver)
+	a.str("token", r.Token)
+	a.str("tokenId", r.TokenId)
+	a.str("referenceID", r.ReferenceID)
+	a.num("approveType", r.ApproveType)
+	a.str("time", r.Time)
+	if err := a.json("approveConstraints", r.ApproveConstraints); err != nil {
+		return nil, err
+	}
+	if err := a.json("duty", r.Duty); err != nil {
+		return nil, err
+	}
+	if err := a.json("derivativeUses", r.DerivativeUses); err != nil {
+		return nil, err
+	}
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// PubTokenRequest 通证许可 buildPubTokenTx
+type PubTokenRequest struct {
+	Publisher       string
+	Receiver        string
+	Token           string
+	TokenId         string
+	ReferenceId     string // 参数名为 referenceId
+	TokenInfos      []TokenInfo
+	Time            string // ISO-8601 区间 start/end
+	ExpectedVersion *int   // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *PubTokenRequest) Method() string { return "buildPubTokenTx" }
+
+func (r *PubTokenRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("publisher", r.Publisher)
+	a.str("receiver", r.Receiver)
+	a.str("token", r.Token)
+	a.str("tokenId", r.TokenId)
+	a.str("referenceId", r.ReferenceId)
+	a.str("time", r.Time)
+	if err := a.json("tokenInfos", r.TokenInfos); err != nil {
+		return nil, err
+	}
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// OwnerSignRequest 许可 owner 签名 ownerSign
+type OwnerSignRequest struct {
+	Account         string
+	Secret          string
+	TokenId         string
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *OwnerSignRequest) Method() string { return "ownerSign" }
+
+func (r *OwnerSignRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("secret", r.Secret)
+	a.str("tokenId", r.TokenId)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// RequestAccountTokenRequest 分页查询账户所持通证 requestAccountToken
+type RequestAccountTokenRequest struct {
+	Account  string
+	Version  string
+	Flag     *int  // 仅 v2 有效, 为空时合约默认 1
+	Frozen   *bool // 为空时不按冻结状态过滤
+	Token    string
+	WorkId   string
+	Order    string // asc(默认) / desc
+	Cursor   string // 上一页返回的 NextCursor
+	PageSize *int   // 为空时合约默认 50
+}
+
+func (r *RequestAccountTokenRequest) Method() string { return "requestAccountToken" }
+
+func (r *RequestAccountTokenRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("version", r.Version)
+	a.optNum("flag", r.Flag)
+	if r.Frozen != nil {
+		a.boolean("frozen", *r.Frozen)
+	}
+	a.str("token", r.Token)
+	a.str("workId", r.WorkId)
+	a.str("order", r.Order)
+	a.str("cursor", r.Cursor)
+	a.optNum("pageSize", r.PageSize)
+	return a, nil
+}
+
+// RequestTokenInfoRequest 查询单个通证 requestTokenInfo
+type RequestTokenInfoRequest struct {
+	TokenId string
+	Version string
+}
+
+func (r *RequestTokenInfoRequest) Method() string { return "requestTokenInfo" }
+
+func (r *RequestTokenInfoRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	a.str("version", r.Version)
+	return a, nil
+}
+
+// SaveFactRequest 文件存证 saveFact
+type SaveFactRequest struct {
+	FileHash string
+	FileName string
+	Time     int
+}
+
+func (r *SaveFactRequest) Method() string { return "saveFact" }
+
+func (r *SaveFactRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("fileHash", r.FileHash)
+	a.str("fileName", r.FileName)
+	a.num("time", r.Time)
+	return a, nil
+}
+
+// FindByFileHashRequest 查询存证 findByFileHash
+type FindByFileHashRequest struct {
+	FileHash string
+}
+
+func (r *FindByFileHashRequest) Method() string { return "findByFileHash" }
+
+func (r *FindByFileHashRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("fileHash", r.FileHash)
+	return a, nil
+}
+
+// RegisterWorkRequest 作品登记 buildRegisterWorkTx
+type RegisterWorkRequest struct {
+	Account string
+	Work    *Work
+}
+
+func (r *RegisterWorkRequest) Method() string { return "buildRegisterWorkTx" }
+
+func (r *RegisterWorkRequest) Args() (map[string][]byte, error) {
+	if r.Work == nil {
+		return nil, errors.New("work is required")
+	}
+	a := argMap{}
+	a.str("account", r.Account)
+	if err := a.json("work", r.Work); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// RequestWorkInfoRequest 查询作品 requestWorkInfo
+type RequestWorkInfoRequest struct {
+	WorkId string
+}
+
+func (r *RequestWorkInfoRequest) Method() string { return "requestWorkInfo" }
+
+func (r *RequestWorkInfoRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("workId", r.WorkId)
+	return a, nil
+}
+
+// RequestWorkTokensRequest 查询作品下全部通证 requestWorkTokens
+type RequestWorkTokensRequest struct {
+	WorkId string
+}
+
+func (r *RequestWorkTokensRequest) Method() string { return "requestWorkTokens" }
+
+func (r *RequestWorkTokensRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("workId", r.WorkId)
+	return a, nil
+}
+
+// RequestTokenLineageRequest 衍生作品溯源 requestTokenLineage
+type RequestTokenLineageRequest struct {
+	TokenId string
+	Depth   *int // 为空时合约取最大深度
+}
+
+func (r *RequestTokenLineageRequest) Method() string { return "requestTokenLineage" }
+
+func (r *RequestTokenLineageRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	a.optNum("depth", r.Depth)
+	return a, nil
+}
+
+// BurnTokenRequest 通证销毁 buildBurnTokenTx
+type BurnTokenRequest struct {
+	Account         string
+	TokenId         string
+	TokenType       int // 1=版权通证,2=授权通证,3=许可
+	Reason          string
+	Cascade         bool
+	Signers         []string
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *BurnTokenRequest) Method() string { return "buildBurnTokenTx" }
+
+func (r *BurnTokenRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	a.num("tokenType", r.TokenType)
+	a.str("reason", r.Reason)
+	if r.Cascade {
+		a.boolean("cascade", true)
+	}
+	if err := a.json("signers", r.Signers); err != nil {
+		return nil, err
+	}
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// RequestTombstoneRequest 查询销毁记录 requestTombstone
+type RequestTombstoneRequest struct {
+	TokenId string
+}
+
+func (r *RequestTombstoneRequest) Method() string { return "requestTombstone" }
+
+func (r *RequestTombstoneRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// RequestExpiringTokensRequest 查询即将到期的通证 requestExpiringTokens
+type RequestExpiringTokensRequest struct {
+	Days      int
+	TokenType *int // 为空时查询全部类型
+}
+
+func (r *RequestExpiringTokensRequest) Method() string { return "requestExpiringTokens" }
+
+func (r *RequestExpiringTokensRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.num("days", r.Days)
+	a.optNum("tokenType", r.TokenType)
+	return a, nil
+}
+
+// ModifyCopyrightTokenFlagRequest 冻结/解冻 BuildModifyCopyrightTokenFlagTx
+type ModifyCopyrightTokenFlagRequest struct {
+	Account         string
+	TokenId         string
+	Flag            int  // 0=解冻,1=冻结
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+// Method 合约中该方法名首字母大写
+func (r *ModifyCopyrightTokenFlagRequest) Method() string { return "BuildModifyCopyrightTokenFlagTx" }
+
+func (r *ModifyCopyrightTokenFlagRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	a.num("flag", r.Flag)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// ModifyAuthenticationInfoRequest 修改确权信息 buildModifyAuthenticationInfoTx
+type ModifyAuthenticationInfoRequest struct {
+	Account            string
+	TokenId            string
+	AuthenticationInfo *AuthenticationInfo
+	Action             string // add(默认) / correct / revoke
+	Reason             string
+	ExpectedVersion    *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *ModifyAuthenticationInfoRequest) Method() string { return "buildModifyAuthenticationInfoTx" }
+
+func (r *ModifyAuthenticationInfoRequest) Args() (map[string][]byte, error) {
+	if r.AuthenticationInfo == nil {
+		return nil, errors.New("authenticationInfo is required")
+	}
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	if err := a.json("authenticationInfo", r.AuthenticationInfo); err != nil {
+		return nil, err
+	}
+	a.str("action", r.Action)
+	a.str("reason", r.Reason)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// ImportCredentialRequest 导入可验证凭证作为确权信息 buildImportCredentialTx
+type ImportCredentialRequest struct {
+	Account         string
+	TokenId         string
+	Credential      []byte // 机构签发的 W3C 可验证凭证原文(JSON), 合约记录其 sha256
+	Action          string // add(默认) / correct
+	Reason          string
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *ImportCredentialRequest) Method() string { return "buildImportCredentialTx" }
+
+func (r *ImportCredentialRequest) Args() (map[string][]byte, error) {
+	if len(r.Credential) == 0 {
+		return nil, errors.New("credential is required")
+	}
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	a["credential"] = r.Credential
+	a.str("action", r.Action)
+	a.str("reason", r.Reason)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// ModifyCopyrightUnitRequest 修改权利主体 buildModifyCopyrightUnitTx
+type ModifyCopyrightUnitRequest struct {
+	Account         string
+	TokenId         string
+	Address         string
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *ModifyCopyrightUnitRequest) Method() string { return "buildModifyCopyrightUnitTx" }
+
+func (r *ModifyCopyrightUnitRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	a.str("address", r.Address)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// ModifyConstraintRequest 修改通证约束 buildModifyConstraintTx
+type ModifyConstraintRequest struct {
+	TokenId         string
+	Constraint      *ConstraintUpdate
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *ModifyConstraintRequest) Method() string { return "buildModifyConstraintTx" }
+
+func (r *ModifyConstraintRequest) Args() (map[string][]byte, error) {
+	if r.Constraint == nil {
+		return nil, errors.New("constraint is required")
+	}
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	if err := a.json("constraint", r.Constraint); err != nil {
+		return nil, err
+	}
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// TokenChangeRequest 通证变更 buildTokenChangeTx
+type TokenChangeRequest struct {
+	Account         string // 当前持有者
+	TokenId         string
+	Flags           *int // 1=冻结, 为空不修改; 持有者不能解冻
+	TokenInfos      []TokenInfo
+	To              string // 新的持有者, 受让方 acceptTokenChange 后生效
+	ExpiresAt       string // 接受期限 YYYY-MM-DD, 为空时默认 7 天
+	ExpectedVersion *int   // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *TokenChangeRequest) Method() string { return "buildTokenChangeTx" }
+
+func (r *TokenChangeRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	a.optNum("flags", r.Flags)
+	if err := a.json("tokenInfos", r.TokenInfos); err != nil {
+		return nil, err
+	}
+	a.str("to", r.To)
+	a.str("expiresAt", r.ExpiresAt)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// AcceptTokenChangeRequest 受让方接受持有者变更 acceptTokenChange
+type AcceptTokenChangeRequest struct {
+	Account         string
+	TokenId         string
+	ExpectedVersion *int // 期望的变更记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *AcceptTokenChangeRequest) Method() string { return "acceptTokenChange" }
+
+func (r *AcceptTokenChangeRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// CancelTokenChangeRequest 撤回或拒绝持有者变更 cancelTokenChange
+type CancelTokenChangeRequest struct {
+	Account string // 持有者或受让方
+	TokenId string
+}
+
+func (r *CancelTokenChangeRequest) Method() string { return "cancelTokenChange" }
+
+func (r *CancelTokenChangeRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// RequestTokenChangeRequest 查询通证最近一笔持有者变更 requestTokenChange
+type RequestTokenChangeRequest struct {
+	TokenId string
+}
+
+func (r *RequestTokenChangeRequest) Method() string { return "requestTokenChange" }
+
+func (r *RequestTokenChangeRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// TransferProportionRequest 版权份额转让 buildTransferProportionTx
+type TransferProportionRequest struct {
+	Account         string
+	TokenId         string
+	CopyrightUnits  []CopyrightUnit
+	Terms           string // 转让条件, 触发优先购买通知时其他权利主体按此条件受让
+	Price           string // 转给非权利主体部分的总价, 触发优先购买通知时必填
+	Asset           string // 计价资产
+	ExpectedVersion *int   // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *TransferProportionRequest) Method() string { return "buildTransferProportionTx" }
+
+func (r *TransferProportionRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	if err := a.json("copyrightUnits", r.CopyrightUnits); err != nil {
+		return nil, err
+	}
+	a.str("terms", r.Terms)
+	a.str("price", r.Price)
+	a.str("asset", r.Asset)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// SetPreemptionRuleRequest 设置优先购买规则 setPreemptionRule
+type SetPreemptionRuleRequest struct {
+	Account         string // 持有者; 缩短或取消时也可为表示同意的权利主体
+	TokenId         string
+	NoticeDays      int  // 通知期天数, 0 为取消
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *SetPreemptionRuleRequest) Method() string { return "setPreemptionRule" }
+
+func (r *SetPreemptionRuleRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	a.num("noticeDays", r.NoticeDays)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// RespondPreemptionRequest 主张或放弃优先购买 respondPreemption
+type RespondPreemptionRequest struct {
+	Account  string
+	NoticeId string
+	Decision string // claim / waive
+}
+
+func (r *RespondPreemptionRequest) Method() string { return "respondPreemption" }
+
+func (r *RespondPreemptionRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("noticeId", r.NoticeId)
+	a.str("decision", r.Decision)
+	return a, nil
+}
+
+// SettlePreemptionRequest 结算优先购买通知 settlePreemption
+type SettlePreemptionRequest struct {
+	NoticeId string
+}
+
+func (r *SettlePreemptionRequest) Method() string { return "settlePreemption" }
+
+func (r *SettlePreemptionRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("noticeId", r.NoticeId)
+	return a, nil
+}
+
+// RequestPreemptionNoticesRequest 查询通证下的优先购买通知 requestPreemptionNotices
+type RequestPreemptionNoticesRequest struct {
+	TokenId string
+}
+
+func (r *RequestPreemptionNoticesRequest) Method() string { return "requestPreemptionNotices" }
+
+func (r *RequestPreemptionNoticesRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// FileDisputeRequest 提出权属争议 fileDispute
+type FileDisputeRequest struct {
+	Account  string
+	TokenId  string
+	Claim    string   // 争议主张
+	Evidence []string // 证据的存证哈希, 须已 saveFact
+}
+
+func (r *FileDisputeRequest) Method() string { return "fileDispute" }
+
+func (r *FileDisputeRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("tokenId", r.TokenId)
+	a.str("claim", r.Claim)
+	if err := a.json("evidence", r.Evidence); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// AddDisputeEvidenceRequest 补充争议证据 addDisputeEvidence
+type AddDisputeEvidenceRequest struct {
+	Account   string // 争议提出者或通证的权利主体
+	DisputeId string
+	Evidence  []string
+	Note      string
+}
+
+func (r *AddDisputeEvidenceRequest) Method() string { return "addDisputeEvidence" }
+
+func (r *AddDisputeEvidenceRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("disputeId", r.DisputeId)
+	if err := a.json("evidence", r.Evidence); err != nil {
+		return nil, err
+	}
+	a.str("note", r.Note)
+	return a, nil
+}
+
+// AcceptDisputeRequest 受理争议 acceptDispute
+type AcceptDisputeRequest struct {
+	Account   string // 监管机构或仲裁账户
+	DisputeId string
+	Note      string
+}
+
+func (r *AcceptDisputeRequest) Method() string { return "acceptDispute" }
+
+func (r *AcceptDisputeRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("disputeId", r.DisputeId)
+	a.str("note", r.Note)
+	return a, nil
+}
+
+// ResolveDisputeRequest 裁决或驳回争议 resolveDispute
+type ResolveDisputeRequest struct {
+	Account        string // 监管机构或仲裁账户
+	DisputeId      string
+	Ruling         string
+	CopyrightUnits []CopyrightUnit // 裁决的权利主体份额, 为空时不变
+	Owner          string          // 裁决的持有者, 为空时不变; 裁决份额移除当前持有者时必填
+	Dismiss        bool            // 驳回争议
+}
+
+func (r *ResolveDisputeRequest) Method() string { return "resolveDispute" }
+
+func (r *ResolveDisputeRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("disputeId", r.DisputeId)
+	a.str("ruling", r.Ruling)
+	if err := a.json("copyrightUnits", r.CopyrightUnits); err != nil {
+		return nil, err
+	}
+	a.str("owner", r.Owner)
+	if r.Dismiss {
+		a.boolean("dismiss", true)
+	}
+	return a, nil
+}
+
+// RequestDisputesRequest 查询通证下的争议 requestDisputes
+type RequestDisputesRequest struct {
+	TokenId string
+}
+
+func (r *RequestDisputesRequest) Method() string { return "requestDisputes" }
+
+func (r *RequestDisputesRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// OwnerOfRequest ERC-721 查询持有者 ownerOf, 返回账户字符串
+type OwnerOfRequest struct {
+	TokenId string
+}
+
+func (r *OwnerOfRequest) Method() string { return "ownerOf" }
+
+func (r *OwnerOfRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// BalanceOfRequest ERC-721 查询持有数量 balanceOf, 返回见 DecodeBalance
+type BalanceOfRequest struct {
+	Owner string
+}
+
+func (r *BalanceOfRequest) Method() string { return "balanceOf" }
+
+func (r *BalanceOfRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("owner", r.Owner)
+	return a, nil
+}
+
+// TokenURIRequest ERC-721 查询元数据 URI tokenURI, 内嵌元数据见 DecodeTokenMetadata
+type TokenURIRequest struct {
+	TokenId string
+}
+
+func (r *TokenURIRequest) Method() string { return "tokenURI" }
+
+func (r *TokenURIRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// ApproveRequest ERC-721 授权转移单个通证 approve
+type ApproveRequest struct {
+	Account string // 持有者或其操作员
+	To      string // 为空时取消授权
+	TokenId string
+}
+
+func (r *ApproveRequest) Method() string { return "approve" }
+
+func (r *ApproveRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("to", r.To)
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// GetApprovedRequest ERC-721 查询单个通证的被授权账户 getApproved, 返回账户字符串
+type GetApprovedRequest struct {
+	TokenId string
+}
+
+func (r *GetApprovedRequest) Method() string { return "getApproved" }
+
+func (r *GetApprovedRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	return a, nil
+}
+
+// SetApprovalForAllRequest ERC-721 授权或取消操作员 setApprovalForAll
+type SetApprovalForAllRequest struct {
+	Account  string // 持有者
+	Operator string
+	Approved bool
+}
+
+func (r *SetApprovalForAllRequest) Method() string { return "setApprovalForAll" }
+
+func (r *SetApprovalForAllRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("operator", r.Operator)
+	a.boolean("approved", r.Approved)
+	return a, nil
+}
+
+// IsApprovedForAllRequest ERC-721 查询操作员授权 isApprovedForAll, 返回见 DecodeApprovedForAll
+type IsApprovedForAllRequest struct {
+	Owner    string
+	Operator string
+}
+
+func (r *IsApprovedForAllRequest) Method() string { return "isApprovedForAll" }
+
+func (r *IsApprovedForAllRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("owner", r.Owner)
+	a.str("operator", r.Operator)
+	return a, nil
+}
+
+// TransferFromRequest ERC-721 转移通证 transferFrom / safeTransferFrom
+// Safe 为 true 时调用 safeTransferFrom, ReceiverContract 非空时须接收合约确认
+type TransferFromRequest struct {
+	Account          string // 持有者、被授权账户或操作员
+	From             string
+	To               string
+	TokenId          string
+	Safe             bool
+	ReceiverContract string
+	Data             string
+	ExpectedVersion  *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *TransferFromRequest) Method() string {
+	if r.Safe {
+		return "safeTransferFrom"
+	}
+	return "transferFrom"
+}
+
+func (r *TransferFromRequest) Args() (map[string][]byte, error) {
+	if !r.Safe && (r.ReceiverContract != "" || r.Data != "") {
+		return nil, errors.New("receiverContract and data require Safe")
+	}
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("from", r.From)
+	a.str("to", r.To)
+	a.str("tokenId", r.TokenId)
+	a.str("receiverContract", r.ReceiverContract)
+	a.str("data", r.Data)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// CreateOfferRequest 创建挂单或出价 createOffer
+type CreateOfferRequest struct {
+	Account    string // ask 为卖方, bid 为买方
+	Side       string // ask / bid
+	Kind       string // token / share
+	TokenId    string
+	Proportion string // kind=share 时必填
+	Price      string
+	Asset      string
+	Seller     string // bid 的卖方, kind=token 时默认为持有者
+	Buyer      string // ask 限定的买方
+	Payment    string // contract / attested, 为空时按配置
+	ExpiresAt  string // YYYY-MM-DD
+}
+
+func (r *CreateOfferRequest) Method() string { return "createOffer" }
+
+func (r *CreateOfferRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("side", r.Side)
+	a.str("kind", r.Kind)
+	a.str("tokenId", r.TokenId)
+	a.str("proportion", r.Proportion)
+	a.str("price", r.Price)
+	a.str("asset", r.Asset)
+	a.str("seller", r.Seller)
+	a.str("buyer", r.Buyer)
+	a.str("payment", r.Payment)
+	a.str("expiresAt", r.ExpiresAt)
+	return a, nil
+}
+
+// AcceptOfferRequest 接受挂单或出价 acceptOffer
+type AcceptOfferRequest struct {
+	Account         string
+	OfferId         string
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *AcceptOfferRequest) Method() string { return "acceptOffer" }
+
+func (r *AcceptOfferRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("offerId", r.OfferId)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// AttestPaymentRequest 登记链外付款记录 attestPayment
+type AttestPaymentRequest struct {
+	Account    string // 付款见证账户
+	OfferId    string
+	PaymentRef string
+}
+
+func (r *AttestPaymentRequest) Method() string { return "attestPayment" }
+
+func (r *AttestPaymentRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("offerId", r.OfferId)
+	a.str("paymentRef", r.PaymentRef)
+	return a, nil
+}
+
+// CancelOfferRequest 撤销挂单或出价 cancelOffer
+type CancelOfferRequest struct {
+	Account string
+	OfferId string
+}
+
+func (r *CancelOfferRequest) Method() string { return "cancelOffer" }
+
+func (r *CancelOfferRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("offerId", r.OfferId)
+	return a, nil
+}
+
+// RequestOffersRequest 查询通证下的挂单/出价 requestOffers
+type RequestOffersRequest struct {
+	TokenId string
+	Status  string // 为空时查询全部
+}
+
+func (r *RequestOffersRequest) Method() string { return "requestOffers" }
+
+func (r *RequestOffersRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("tokenId", r.TokenId)
+	a.str("status", r.Status)
+	return a, nil
+}
+
+// GrantOperatorRequest 授予操作员代理权 grantOperator
+type GrantOperatorRequest struct {
+	Account         string // 委托人
+	Operator        string
+	Scope           *OperatorScope // 为空时不限
+	ExpiresAt       string         // YYYY-MM-DD, 当天结束失效
+	ExpectedVersion *int           // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *GrantOperatorRequest) Method() string { return "grantOperator" }
+
+func (r *GrantOperatorRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("operator", r.Operator)
+	if err := a.json("scope", r.Scope); err != nil {
+		return nil, err
+	}
+	a.str("expiresAt", r.ExpiresAt)
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// RevokeOperatorRequest 撤销操作员代理权 revokeOperator
+type RevokeOperatorRequest struct {
+	Account  string // 委托人
+	Operator string
+}
+
+func (r *RevokeOperatorRequest) Method() string { return "revokeOperator" }
+
+func (r *RevokeOperatorRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("operator", r.Operator)
+	return a, nil
+}
+
+// RequestOperatorGrantsRequest 查询委托人授予的代理权 requestOperatorGrants
+type RequestOperatorGrantsRequest struct {
+	Account string
+}
+
+func (r *RequestOperatorGrantsRequest) Method() string { return "requestOperatorGrants" }
+
+func (r *RequestOperatorGrantsRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	return a, nil
+}
+
+// PauseContractRequest 暂停写方法 pauseContract
+type PauseContractRequest struct {
+	Account    string // 管理员账户
+	MethodName string // 为空时暂停全部写方法
+}
+
+func (r *PauseContractRequest) Method() string { return "pauseContract" }
+
+func (r *PauseContractRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("methodName", r.MethodName)
+	return a, nil
+}
+
+// UnpauseContractRequest 恢复写方法 unpauseContract
+type UnpauseContractRequest struct {
+	Account    string // 管理员账户
+	MethodName string // 为空时解除全局暂停
+}
+
+func (r *UnpauseContractRequest) Method() string { return "unpauseContract" }
+
+func (r *UnpauseContractRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("methodName", r.MethodName)
+	return a, nil
+}
+
+// SetConfigRequest 修改配置项 setConfig
+type SetConfigRequest struct {
+	Account string      // 管理员账户
+	Name    string      // strictContent / regulators / tokenBaseURI / paymentContract / paymentAttestors / enum.<枚举名>
+	Value   interface{} // 配置值, 如 true、[]string、EnumBounds
+}
+
+func (r *SetConfigRequest) Method() string { return "setConfig" }
+
+func (r *SetConfigRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("name", r.Name)
+	if r.Value == nil {
+		return nil, errors.New("setConfig: Value is required")
+	}
+	if err := a.json("value", r.Value); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// RotateAdminRequest 增加和/或移除管理员 rotateAdmin
+type RotateAdminRequest struct {
+	Account string // 管理员账户
+	Add     string
+	Remove  string
+}
+
+func (r *RotateAdminRequest) Method() string { return "rotateAdmin" }
+
+func (r *RotateAdminRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.str("add", r.Add)
+	a.str("remove", r.Remove)
+	return a, nil
+}
+
+// RequestAdminInfoRequest 查询管理状态 requestAdminInfo
+type RequestAdminInfoRequest struct{}
+
+func (r *RequestAdminInfoRequest) Method() string { return "requestAdminInfo" }
+
+func (r *RequestAdminInfoRequest) Args() (map[string][]byte, error) {
+	return argMap{}, nil
+}
+
+// RequestAuditLogRequest 分页查询审计记录 requestAuditLog
+type RequestAuditLogRequest struct {
+	Cursor   string
+	PageSize *int
+}
+
+func (r *RequestAuditLogRequest) Method() string { return "requestAuditLog" }
+
+func (r *RequestAuditLogRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("cursor", r.Cursor)
+	a.optNum("pageSize", r.PageSize)
+	return a, nil
+}
+
+// RegisterInstitutionRequest 登记或更新确权机构 registerInstitution
+type RegisterInstitutionRequest struct {
+	Account         string // 管理员账户
+	Institution     *AuthInstitution
+	ExpectedVersion *int // 期望的记录版本号, 不一致时返回 VERSION_CONFLICT
+}
+
+func (r *RegisterInstitutionRequest) Method() string { return "registerInstitution" }
+
+func (r *RegisterInstitutionRequest) Args() (map[string][]byte, error) {
+	if r.Institution == nil {
+		return nil, errors.New("institution is required")
+	}
+	a := argMap{}
+	a.str("account", r.Account)
+	if err := a.json("institution", r.Institution); err != nil {
+		return nil, err
+	}
+	a.optNum("expectedVersion", r.ExpectedVersion)
+	return a, nil
+}
+
+// RequestInstitutionsRequest 查询确权机构 requestInstitutions
+type RequestInstitutionsRequest struct{}
+
+func (r *RequestInstitutionsRequest) Method() string { return "requestInstitutions" }
+
+func (r *RequestInstitutionsRequest) Args() (map[string][]byte, error) {
+	return argMap{}, nil
+}
+
+// RunMigrationRequest 分批执行数据迁移 runMigration
+type RunMigrationRequest struct {
+	Account   string // 管理员账户
+	BatchSize *int   // 为空时合约默认 200
+}
+
+func (r *RunMigrationRequest) Method() string { return "runMigration" }
+
+func (r *RunMigrationRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("account", r.Account)
+	a.optNum("batchSize", r.BatchSize)
+	return a, nil
+}
+
+// RequestMigrationStatusRequest 查询数据迁移进度 requestMigrationStatus
+type RequestMigrationStatusRequest struct{}
+
+func (r *RequestMigrationStatusRequest) Method() string { return "requestMigrationStatus" }
+
+func (r *RequestMigrationStatusRequest) Args() (map[string][]byte, error) {
+	return argMap{}, nil
+}
+
+// DescribeRequest 查询方法目录及 JSON Schema describe
+type DescribeRequest struct {
+	MethodName string // 为空时返回完整目录
+}
+
+func (r *DescribeRequest) Method() string { return "describe" }
+
+func (r *DescribeRequest) Args() (map[string][]byte, error) {
+	a := argMap{}
+	a.str("methodName", r.MethodName)
+	return a, nil
+}
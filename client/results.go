@@ -0,0 +1,290 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StatusOK 合约调用成功状态码, 同 shim.OK
+const StatusOK = 200
+
+// Response 合约返回, 字段对应 protogo.Response
+type Response struct {
+	Status  int32
+	Message string
+	Payload []byte
+}
+
+// ContractError 合约返回的结构化错误, 错误码见 describe 返回的 errorCodes
+type ContractError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+	Method  string `json:"method,omitempty"`
+}
+
+func (e *ContractError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("[%s] %s (%s): %s", e.Method, e.Code, e.Field, e.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", e.Method, e.Code, e.Message)
+}
+
+// Err 调用失败时返回合约错误信息
+// 结构化错误返回 *ContractError, 可按 Code 判断错误类型
+func (r *Response) Err() error {
+	if r.Status == StatusOK {
+		return nil
+	}
+	if r.Message == "" {
+		return fmt.Errorf("contract error, status %d", r.Status)
+	}
+	var ce ContractError
+	if err := json.Unmarshal([]byte(r.Message), &ce); err == nil && ce.Code != "" {
+		return &ce
+	}
+	return errors.New(r.Message)
+}
+
+// decode 解析 JSON 返回
+func decode(payload []byte, v interface{}) error {
+	if len(payload) == 0 {
+		return errors.New("empty payload")
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("decode payload: %s", err.Error())
+	}
+	return nil
+}
+
+// DecodeTokenDetail 解析 requestTokenInfo 返回
+func DecodeTokenDetail(payload []byte) (*TokenDetail, error) {
+	var detail TokenDetail
+	if err := decode(payload, &detail); err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// DecodeAccountTokens 解析 requestAccountToken 返回的分页结果, 无通证时 Tokens 为空切片
+func DecodeAccountTokens(payload []byte) (*AccountTokenPage, error) {
+	var page AccountTokenPage
+	if err := decode(payload, &page); err != nil {
+		return nil, err
+	}
+	if page.Tokens == nil {
+		page.Tokens = []TokenDetail{}
+	}
+	return &page, nil
+}
+
+// DecodeFact 解析 findByFileHash 返回
+func DecodeFact(payload []byte) (*Fact, error) {
+	var fact Fact
+	if err := decode(payload, &fact); err != nil {
+		return nil, err
+	}
+	return &fact, nil
+}
+
+// DecodeWork 解析 requestWorkInfo 返回
+func DecodeWork(payload []byte) (*Work, error) {
+	var work Work
+	if err := decode(payload, &work); err != nil {
+		return nil, err
+	}
+	return &work, nil
+}
+
+// DecodeWorkTokenTree 解析 requestWorkTokens 返回
+func DecodeWorkTokenTree(payload []byte) (*WorkTokenTree, error) {
+	var tree WorkTokenTree
+	if err := decode(payload, &tree); err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
+// DecodeTokenLineage 解析 requestTokenLineage 返回
+func DecodeTokenLineage(payload []byte) (*TokenLineage, error) {
+	var lineage TokenLineage
+	if err := decode(payload, &lineage); err != nil {
+		return nil, err
+	}
+	return &lineage, nil
+}
+
+// DecodeTombstone 解析 requestTombstone 返回
+func DecodeTombstone(payload []byte) (*Tombstone, error) {
+	var tombstone Tombstone
+	if err := decode(payload, &tombstone); err != nil {
+		return nil, err
+	}
+	return &tombstone, nil
+}
+
+// DecodeExpiringTokens 解析 requestExpiringTokens 返回
+func DecodeExpiringTokens(payload []byte) ([]ExpiringToken, error) {
+	var tokens []ExpiringToken
+	if err := decode(payload, &tokens); err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []ExpiringToken{}
+	}
+	return tokens, nil
+}
+
+// DecodeAdminInfo 解析 requestAdminInfo 返回
+func DecodeAdminInfo(payload []byte) (*AdminInfo, error) {
+	var info AdminInfo
+	if err := decode(payload, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// DecodeAuditLog 解析 requestAuditLog 返回
+func DecodeAuditLog(payload []byte) (*AuditPage, error) {
+	var page AuditPage
+	if err := decode(payload, &page); err != nil {
+		return nil, err
+	}
+	if page.Entries == nil {
+		page.Entries = []AuditEntry{}
+	}
+	return &page, nil
+}
+
+// DecodeTokenChange 解析 requestTokenChange 返回
+func DecodeTokenChange(payload []byte) (*TokenChange, error) {
+	var change TokenChange
+	if err := decode(payload, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// DecodeDisputes 解析 requestDisputes 返回
+func DecodeDisputes(payload []byte) ([]Dispute, error) {
+	var disputes []Dispute
+	if err := decode(payload, &disputes); err != nil {
+		return nil, err
+	}
+	if disputes == nil {
+		disputes = []Dispute{}
+	}
+	return disputes, nil
+}
+
+// DecodeInstitutions 解析 requestInstitutions 返回
+func DecodeInstitutions(payload []byte) ([]AuthInstitution, error) {
+	var institutions []AuthInstitution
+	if err := decode(payload, &institutions); err != nil {
+		return nil, err
+	}
+	if institutions == nil {
+		institutions = []AuthInstitution{}
+	}
+	return institutions, nil
+}
+
+// DecodeOffers 解析 requestOffers 返回
+func DecodeOffers(payload []byte) ([]SaleOffer, error) {
+	var offers []SaleOffer
+	if err := decode(payload, &offers); err != nil {
+		return nil, err
+	}
+	if offers == nil {
+		offers = []SaleOffer{}
+	}
+	return offers, nil
+}
+
+// DecodePreemptionNotices 解析 requestPreemptionNotices 返回
+func DecodePreemptionNotices(payload []byte) ([]PreemptionNotice, error) {
+	var notices []PreemptionNotice
+	if err := decode(payload, &notices); err != nil {
+		return nil, err
+	}
+	if notices == nil {
+		notices = []PreemptionNotice{}
+	}
+	return notices, nil
+}
+
+// DecodeOperatorGrants 解析 requestOperatorGrants 返回
+func DecodeOperatorGrants(payload []byte) ([]OperatorGrant, error) {
+	var grants []OperatorGrant
+	if err := decode(payload, &grants); err != nil {
+		return nil, err
+	}
+	if grants == nil {
+		grants = []OperatorGrant{}
+	}
+	return grants, nil
+}
+
+// DecodeMigrationStatus 解析 requestMigrationStatus 返回
+func DecodeMigrationStatus(payload []byte) (*MigrationStatus, error) {
+	var status MigrationStatus
+	if err := decode(payload, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// DecodeBalance 解析 balanceOf 返回
+func DecodeBalance(payload []byte) (int, error) {
+	var balance int
+	if err := decode(payload, &balance); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// DecodeApprovedForAll 解析 isApprovedForAll 返回
+func DecodeApprovedForAll(payload []byte) (bool, error) {
+	var approved bool
+	if err := decode(payload, &approved); err != nil {
+		return false, err
+	}
+	return approved, nil
+}
+
+// tokenMetadataURIPrefix 未配置 tokenBaseURI 时 tokenURI 返回的内嵌元数据前缀
+const tokenMetadataURIPrefix = "data:application/json;base64,"
+
+// DecodeTokenMetadata 解析 tokenURI 返回的内嵌元数据, 配置了 tokenBaseURI 时需自行获取
+func DecodeTokenMetadata(payload []byte) (*TokenMetadata, error) {
+	uri := string(payload)
+	if !strings.HasPrefix(uri, tokenMetadataURIPrefix) {
+		return nil, fmt.Errorf("not an embedded metadata uri: %s", uri)
+	}
+	metadataBytes, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, tokenMetadataURIPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("decode metadata uri: %s", err.Error())
+	}
+	var metadata TokenMetadata
+	if err := decode(metadataBytes, &metadata); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// DecodeTxResult 解析写方法(build*、saveFact、ownerSign)的返回
+func DecodeTxResult(payload []byte) (*TxResult, error) {
+	var result TxResult
+	if err := decode(payload, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DecodeRecord 将写入后的主记录解析到 v, 如 *TokenDetail、*ApproveToken
+func (r *TxResult) DecodeRecord(v interface{}) error {
+	return decode(r.Record, v)
+}
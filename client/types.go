@@ -0,0 +1,529 @@
+package client
+
+import "encoding/json"
+
+// 以下结构与合约中的 JSON 结构保持一致
+
+// TokenIssue 通证类别
+type TokenIssue struct {
+	Account       string      `json:"account"`
+	Publisher     string      `json:"publisher"`
+	Token         string      `json:"token"`
+	Number        int         `json:"number"`
+	Flag          int         `json:"flag"`
+	Version       string      `json:"version"`
+	Roles         []TokenRole `json:"roles,omitempty"`
+	ReferenceFlag int         `json:"reference_flag"`
+	Issued        int         `json:"issued"`
+	Burned        int         `json:"burned"`
+	RecordVersion int         `json:"recordVersion"`
+}
+
+// TokenRole 通证权限项
+type TokenRole struct {
+	Role string `json:"role"`
+	Type int    `json:"type"`
+}
+
+// TokenObject 一般通证发行参数
+type TokenObject struct {
+	Flag                int                   `json:"flag"`
+	TokenId             string                `json:"tokenId"`
+	ContentHash         string                `json:"contentHash,omitempty"`
+	AuthenticationInfos []AuthenticationInfo  `json:"authenticationInfos"`
+	CopyrightType       int                   `json:"copyrightType"`
+	CopyrightGetType    int                   `json:"copyrightGetType"`
+	CopyrightUnits      []CopyrightUnit       `json:"copyrightUnits"`
+	ConstraintExplain   string                `json:"constraintExplain"`
+	ConstraintExpand    int                   `json:"constraintExpand"`
+	CopyrightConstraint []CopyrightConstraint `json:"copyrightConstraint"`
+	ApprConstraint      []ApprConstraint      `json:"apprConstraint"`
+	LicenseConstraint   []LicenseConstraint   `json:"licenseConstraint"`
+	WorkId              string                `json:"workId"`
+	ParentLinks         []ParentLink          `json:"parentLinks,omitempty"`
+	CopyrightStatus     []CopyrightStatus     `json:"copyrightStatus"`
+	Term                string                `json:"term,omitempty"`
+}
+
+// AuthenticationInfo 确权信息
+type AuthenticationInfo struct {
+	AuthenticationInstitudeName string `json:"authenticationInstitudeName"`
+	AuthenticationId            string `json:"authenticationId"`
+	AuthenticatedDate           string `json:"authenticatedDate"`
+	InstitutionId               string `json:"institutionId,omitempty"` // 已登记的确权机构
+	Signature                   string `json:"signature,omitempty"`     // 机构签名(base64), 提交账户不是机构账户时必填
+	// 签名时必填: 同一机构下只能使用一次的 nonce 及签名有效期(ISO-8601)
+	Nonce              string `json:"nonce,omitempty"`
+	SignatureExpiresAt string `json:"signatureExpiresAt,omitempty"`
+	// 以下由合约填写
+	Version   int    `json:"version,omitempty"`
+	Status    string `json:"status,omitempty"` // valid / revoked / superseded
+	Issuer    string `json:"issuer,omitempty"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+	TxId      string `json:"txId,omitempty"`
+	RevokedBy string `json:"revokedBy,omitempty"`
+	RevokedAt string `json:"revokedAt,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	// 导入可验证凭证时填写
+	CredentialId   string `json:"credentialId,omitempty"`
+	CredentialHash string `json:"credentialHash,omitempty"` // 凭证原文 sha256
+}
+
+// CopyrightUnit 版权单元
+type CopyrightUnit struct {
+	Address          string `json:"address"`
+	Proportion       string `json:"proportion"`
+	CopyrightExplain string `json:"copyrightExplain"`
+}
+
+// CopyrightConstraint 版权限制
+type CopyrightConstraint struct {
+	CopyrightLimit int `json:"copyrightLimit"`
+}
+
+// ApprConstraint 授权约束
+type ApprConstraint struct {
+	Channel       string `json:"channel"`
+	Area          string `json:"area"`
+	Time          string `json:"time"`
+	TransferType  int    `json:"transferType"`
+	ReapproveType int    `json:"reapproveType"`
+}
+
+// LicenseConstraint 许可约束
+type LicenseConstraint struct {
+	Type string `json:"type"`
+	Area string `json:"area"`
+	Time string `json:"time"`
+}
+
+// CopyrightStatus 发行状态信息
+type CopyrightStatus struct {
+	PublishStatus  int    `json:"publishStatus"`
+	PublishCity    string `json:"publishCity"`
+	PublishCountry string `json:"publishCountry"`
+	PublishDate    string `json:"publishDate"`
+	ComeoutStatus  int    `json:"comeoutStatus"`
+	ComeoutCity    string `json:"comeoutCity"`
+	ComeoutCountry string `json:"comeoutCountry"`
+	ComeoutDate    string `json:"comeoutDate"`
+	IssueStatus    int    `json:"issueStatus"`
+	IssueCity      string `json:"issueCity"`
+	IssueCountry   string `json:"issueCountry"`
+	IssueDate      string `json:"issueDate"`
+}
+
+// ParentLink 衍生作品对原作品版权通证的关联
+type ParentLink struct {
+	TokenId        string `json:"tokenId"`
+	RelationType   int    `json:"relationType"`
+	ApproveTokenId string `json:"approveTokenId"`
+}
+
+// ApproveConstraint 单个授权约束
+type ApproveConstraint struct {
+	ApproveChannel int `json:"approveChannel"`
+	ApproveArea    int `json:"approveArea"`
+	ApproveTime    int `json:"approveTime"`
+	ApproveStatus  int `json:"approveStatus"`
+	ReapproveType  int `json:"reapproveType"`
+}
+
+// DutyInfo 计酬信息
+type DutyInfo struct {
+	DistributionMethod int    `json:"distributionMethod"`
+	DistributionDesc   string `json:"distributionDesc"`
+	ReceivablePayment  string `json:"receivablePayment"`
+	ReceivedPayment    string `json:"receivedPayment"`
+	ToReceivePayment   string `json:"toReceivePayment"`
+	BalanceDate        string `json:"balanceDate"`
+}
+
+// TokenInfo 通证属性
+type TokenInfo struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+// Constraint 修改通证约束的内容
+type Constraint struct {
+	CopyrightLimit    int               `json:"copyrightLimit"`
+	ApprConstraint    ApprConstraint    `json:"apprConstraint"`
+	LicenseConstraint LicenseConstraint `json:"licenseConstraint"`
+	ConstraintExplain string            `json:"constraintExplain"`
+	ConstraintExpand  int               `json:"constraintExpand"`
+}
+
+// ConstraintUpdate buildModifyConstraintTx 的 constraint 参数
+type ConstraintUpdate struct {
+	TokenId    string     `json:"tokenId"`
+	Constraint Constraint `json:"constraint"`
+	Signers    []string   `json:"signers"` // 版权单元全部账户签名
+}
+
+// Work 作品登记信息
+type Work struct {
+	WorkId        string   `json:"workId"`
+	Title         string   `json:"title"`
+	Creators      []string `json:"creators"`
+	CopyrightType int      `json:"copyrightType"`
+	CreationDate  string   `json:"creationDate"`
+	Registrant    string   `json:"registrant"`
+}
+
+// TokenDetail 通证详情
+type TokenDetail struct {
+	TokenId             string                `json:"tokenId"`
+	Version             string                `json:"version"`
+	Flag                int                   `json:"flag"`
+	OwnerAccount        string                `json:"ownerAccount"`
+	Frozen              bool                  `json:"frozen"`
+	DisputeId           string                `json:"disputeId,omitempty"` // 处于争议状态时为受理中的争议
+	AuthenticationInfos []AuthenticationInfo  `json:"authenticationInfos,omitempty"`
+	Publisher           string                `json:"publisher,omitempty"`
+	Token               string                `json:"token,omitempty"`
+	CirculationFlag     int                   `json:"circulationFlag"`
+	WorkId              string                `json:"workId,omitempty"`
+	ContentHash         string                `json:"contentHash,omitempty"`
+	CopyrightType       int                   `json:"copyrightType"`
+	CopyrightGetType    int                   `json:"copyrightGetType"`
+	ParentLinks         []ParentLink          `json:"parentLinks,omitempty"`
+	CopyrightStatus     []CopyrightStatus     `json:"copyrightStatus,omitempty"`
+	ConstraintExplain   string                `json:"constraintExplain,omitempty"`
+	ConstraintExpand    int                   `json:"constraintExpand"`
+	Term                string                `json:"term,omitempty"`
+	PublishedAt         string                `json:"publishedAt,omitempty"`
+	TokenInfos          []TokenInfo           `json:"tokenInfos,omitempty"`
+	CopyrightUnits      []CopyrightUnit       `json:"copyrightUnits,omitempty"`
+	CopyrightConstraint []CopyrightConstraint `json:"copyrightConstraint,omitempty"`
+	ApprConstraint      []ApprConstraint      `json:"apprConstraint,omitempty"`
+	LicenseConstraint   []LicenseConstraint   `json:"licenseConstraint,omitempty"`
+	RecordVersion       int                   `json:"recordVersion"`
+}
+
+// AccountTokenPage requestAccountToken 分页结果, NextCursor 为空表示已无更多数据
+type AccountTokenPage struct {
+	Tokens     []TokenDetail `json:"tokens"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+	Total      int           `json:"total"` // 满足过滤条件的通证总数
+}
+
+// ApproveToken 授权通证
+type ApproveToken struct {
+	Publisher          string              `json:"publisher"`
+	Receiver           string              `json:"receiver"`
+	Token              string              `json:"token"`
+	TokenId            string              `json:"tokenId"`
+	ReferenceID        string              `json:"referenceID"`
+	ApproveType        int                 `json:"approveType"`
+	ApproveConstraints []ApproveConstraint `json:"approveConstraints"`
+	Duty               []DutyInfo          `json:"duty"`
+	DerivativeUses     []int               `json:"derivativeUses,omitempty"`
+	Time               string              `json:"time,omitempty"`
+	RecordVersion      int                 `json:"recordVersion"`
+}
+
+// PubTokenTx 许可
+type PubTokenTx struct {
+	Publisher      string      `json:"publisher"`
+	Receiver       string      `json:"receiver"`
+	Token          string      `json:"token"`
+	TokenId        string      `json:"tokenId"`
+	ReferenceId    string      `json:"referenceId"`
+	TokenInfos     []TokenInfo `json:"tokenInfos,omitempty"`
+	Time           string      `json:"time,omitempty"`
+	OwnerSigned    bool        `json:"ownerSigned"`
+	OwnerAccount   string      `json:"ownerAccount"`
+	OwnerSignature string      `json:"ownerSignature"`
+	RecordVersion  int         `json:"recordVersion"`
+}
+
+// Fact 文件存证
+type Fact struct {
+	FileHash string
+	FileName string
+	Time     int
+}
+
+// WorkTokenTree 作品下的全部通证
+type WorkTokenTree struct {
+	Work            Work                 `json:"work"`
+	CopyrightTokens []CopyrightTokenNode `json:"copyrightTokens"`
+}
+
+// CopyrightTokenNode 版权通证及其授权通证
+type CopyrightTokenNode struct {
+	TokenId   string          `json:"tokenId"`
+	Token     json.RawMessage `json:"token"`
+	Approvals []ApprovalNode  `json:"approvals"`
+}
+
+// ApprovalNode 授权通证及其许可
+type ApprovalNode struct {
+	ApproveToken ApproveToken  `json:"approveToken"`
+	Active       bool          `json:"active"`
+	Licenses     []LicenseNode `json:"licenses"`
+}
+
+// LicenseNode 许可
+type LicenseNode struct {
+	License PubTokenTx `json:"license"`
+	Active  bool       `json:"active"`
+}
+
+// LineageEdge 溯源图中的一条边
+type LineageEdge struct {
+	ParentId     string `json:"parentId"`
+	ChildId      string `json:"childId"`
+	RelationType int    `json:"relationType"`
+	Depth        int    `json:"depth"`
+}
+
+// TokenLineage 溯源结果
+type TokenLineage struct {
+	TokenId     string        `json:"tokenId"`
+	Ancestors   []LineageEdge `json:"ancestors"`
+	Descendants []LineageEdge `json:"descendants"`
+}
+
+// Tombstone 通证销毁记录
+type Tombstone struct {
+	TokenId       string          `json:"tokenId"`
+	TokenType     int             `json:"tokenType"`
+	Token         string          `json:"token"`
+	Owner         string          `json:"owner"`
+	BurnedBy      string          `json:"burnedBy"`
+	Reason        string          `json:"reason,omitempty"`
+	CascadeFrom   string          `json:"cascadeFrom,omitempty"`
+	TxId          string          `json:"txId"`
+	Record        json.RawMessage `json:"record"`
+	RecordVersion int             `json:"recordVersion"`
+}
+
+// ExpiringToken 即将到期的通证
+type ExpiringToken struct {
+	TokenId   string `json:"tokenId"`
+	TokenType int    `json:"tokenType"`
+	Window    string `json:"window"`
+	EndDate   string `json:"endDate"`
+}
+
+// EnumBounds 可调整枚举的取值范围(闭区间)
+type EnumBounds struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// AdminInfo 管理员、暂停开关及配置
+type AdminInfo struct {
+	Admins           []string              `json:"admins"`
+	Paused           []string              `json:"paused"`
+	StrictContent    bool                  `json:"strictContent"`
+	Regulators       []string              `json:"regulators"`
+	TokenBaseURI     string                `json:"tokenBaseURI,omitempty"`
+	PaymentContract  string                `json:"paymentContract,omitempty"`
+	PaymentAttestors []string              `json:"paymentAttestors"`
+	Arbitrators      []string              `json:"arbitrators"`
+	Enums            map[string]EnumBounds `json:"enums"`
+}
+
+// SaleOffer 挂单(ask)或出价(bid)
+type SaleOffer struct {
+	OfferId       string `json:"offerId"`
+	Side          string `json:"side"`
+	Kind          string `json:"kind"`
+	TokenId       string `json:"tokenId"`
+	Proportion    string `json:"proportion,omitempty"`
+	Price         string `json:"price"`
+	Asset         string `json:"asset,omitempty"`
+	Seller        string `json:"seller"`
+	Buyer         string `json:"buyer,omitempty"`
+	Payment       string `json:"payment"`
+	PaymentRef    string `json:"paymentRef,omitempty"`
+	Status        string `json:"status"` // open / accepted / settled / cancelled / expired
+	ExpiresAt     string `json:"expiresAt"`
+	CreatedAt     string `json:"createdAt"`
+	AcceptedAt    string `json:"acceptedAt,omitempty"`
+	SettledAt     string `json:"settledAt,omitempty"`
+	SettleTxId    string `json:"settleTxId,omitempty"`
+	RecordVersion int    `json:"recordVersion"`
+	NoticeId      string `json:"noticeId,omitempty"` // 优先购买结算生成的挂单所属通知
+}
+
+// PreemptionRule 通证的优先购买规则
+type PreemptionRule struct {
+	TokenId       string `json:"tokenId"`
+	NoticeDays    int    `json:"noticeDays"`
+	SetBy         string `json:"setBy"`
+	UpdatedAt     string `json:"updatedAt"`
+	TxId          string `json:"txId"`
+	RecordVersion int    `json:"recordVersion"`
+	// Pending 缩短或取消通知期的提议, 持有者及全部权利主体均同意后生效
+	Pending *PreemptionRelax `json:"pending,omitempty"`
+}
+
+// PreemptionRelax 缩短或取消通知期的提议
+type PreemptionRelax struct {
+	NoticeDays int      `json:"noticeDays"`
+	ProposedBy string   `json:"proposedBy"`
+	Consents   []string `json:"consents"`
+}
+
+// PreemptionNotice 份额转让的优先购买通知
+type PreemptionNotice struct {
+	NoticeId      string          `json:"noticeId"`
+	TokenId       string          `json:"tokenId"`
+	Seller        string          `json:"seller"`
+	Proportion    string          `json:"proportion"`
+	Units         []CopyrightUnit `json:"units"`
+	Terms         string          `json:"terms,omitempty"`
+	Price         string          `json:"price"` // 转给非权利主体部分的总价
+	Asset         string          `json:"asset,omitempty"`
+	Holders       []CopyrightUnit `json:"holders"`
+	Claims        []string        `json:"claims"`
+	Waivers       []string        `json:"waivers"`
+	Deadline      string          `json:"deadline"`
+	Status        string          `json:"status"` // pending / completed / preempted
+	CreatedAt     string          `json:"createdAt"`
+	SettledAt     string          `json:"settledAt,omitempty"`
+	SettleTxId    string          `json:"settleTxId,omitempty"`
+	RecordVersion int             `json:"recordVersion"`
+	Offers        []string        `json:"offers,omitempty"` // 结算时向主张者生成的挂单
+}
+
+// TokenChange 持有者变更记录
+type TokenChange struct {
+	TokenId       string `json:"tokenId"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Status        string `json:"status"` // pending / accepted / cancelled / expired
+	ExpiresAt     string `json:"expiresAt"`
+	CreatedAt     string `json:"createdAt"`
+	TxId          string `json:"txId"`
+	CompletedAt   string `json:"completedAt,omitempty"`
+	CompleteTxId  string `json:"completeTxId,omitempty"`
+	RecordVersion int    `json:"recordVersion"`
+}
+
+// DisputeStep 争议处理环节
+type DisputeStep struct {
+	Action   string   `json:"action"` // file / evidence / accept / resolve / dismiss
+	Account  string   `json:"account"`
+	Note     string   `json:"note,omitempty"`
+	Evidence []string `json:"evidence,omitempty"`
+	At       string   `json:"at"`
+	TxId     string   `json:"txId"`
+}
+
+// Dispute 权属争议
+type Dispute struct {
+	DisputeId     string          `json:"disputeId"`
+	TokenId       string          `json:"tokenId"`
+	Claimant      string          `json:"claimant"`
+	Claim         string          `json:"claim"`
+	Evidence      []string        `json:"evidence"`
+	Status        string          `json:"status"` // filed / accepted / resolved / dismissed
+	Arbitrator    string          `json:"arbitrator,omitempty"`
+	Ruling        string          `json:"ruling,omitempty"`
+	Units         []CopyrightUnit `json:"units,omitempty"`
+	Owner         string          `json:"owner,omitempty"`
+	Steps         []DisputeStep   `json:"steps"`
+	RecordVersion int             `json:"recordVersion"`
+}
+
+// AuthInstitution 确权机构
+type AuthInstitution struct {
+	InstitutionId string   `json:"institutionId"`
+	Name          string   `json:"name"`
+	Accounts      []string `json:"accounts"`
+	PublicKeys    []string `json:"publicKeys"`        // PEM 或 base64 编码的 PKIX 公钥(Ed25519 / ECDSA)
+	Issuers       []string `json:"issuers,omitempty"` // 签发可验证凭证时的 issuer 标识(如 DID)
+	// 凭证 proof.verificationMethod -> 该方法的公钥, 格式同 PublicKeys
+	VerificationMethods map[string]string `json:"verificationMethods,omitempty"`
+	Status              string            `json:"status"` // active / suspended
+	UpdatedBy           string            `json:"updatedBy,omitempty"`
+	UpdatedAt           string            `json:"updatedAt,omitempty"`
+	TxId                string            `json:"txId,omitempty"`
+	RecordVersion       int               `json:"recordVersion,omitempty"`
+}
+
+// TokenMetadata tokenURI 内嵌的通证元数据
+type TokenMetadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TokenId     string `json:"tokenId"`
+	WorkId      string `json:"workId,omitempty"`
+	ContentHash string `json:"contentHash,omitempty"`
+	Owner       string `json:"owner"`
+	PublishedAt string `json:"publishedAt,omitempty"`
+}
+
+// AuditEntry 审计记录, 代理调用时 Account 为委托人, Operator 为操作员
+type AuditEntry struct {
+	Seq      int    `json:"seq"`
+	Action   string `json:"action"`
+	Account  string `json:"account"`
+	Operator string `json:"operator,omitempty"`
+	Target   string `json:"target,omitempty"`
+	Value    string `json:"value,omitempty"`
+	TxId     string `json:"txId"`
+	Time     string `json:"time"`
+}
+
+// OperatorScope 操作员代理范围, 各项为空时不限
+type OperatorScope struct {
+	Methods         []string `json:"methods,omitempty"`
+	TokenIds        []string `json:"tokenIds,omitempty"`
+	ApproveChannels []int    `json:"approveChannels,omitempty"`
+	ApproveAreas    []int    `json:"approveAreas,omitempty"`
+}
+
+// OperatorGrant 操作员代理授权
+type OperatorGrant struct {
+	Principal     string        `json:"principal"`
+	Operator      string        `json:"operator"`
+	Scope         OperatorScope `json:"scope"`
+	ExpiresAt     string        `json:"expiresAt"`
+	GrantedAt     string        `json:"grantedAt"`
+	TxId          string        `json:"txId"`
+	RecordVersion int           `json:"recordVersion"`
+}
+
+// AuditPage 审计记录分页结果
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+	Total      int          `json:"total"`
+}
+
+// MigrationStatus 数据迁移进度
+type MigrationStatus struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	TargetVersion int      `json:"targetVersion"`
+	Step          string   `json:"step,omitempty"`
+	Cursor        string   `json:"cursor,omitempty"`
+	Processed     int      `json:"processed"`
+	Pending       []string `json:"pending"`
+	Done          bool     `json:"done"`
+	LastTxId      string   `json:"lastTxId,omitempty"`
+}
+
+// TxResult 写方法返回结果
+type TxResult struct {
+	Method        string          `json:"method"`
+	TxId          string          `json:"txId"`
+	Id            string          `json:"id"`
+	Key           string          `json:"key"`
+	Keys          []string        `json:"keys"`
+	RecordVersion int             `json:"recordVersion"`
+	Record        json.RawMessage `json:"record"`
+	Digest        string          `json:"digest"`
+	Events        []EventRef      `json:"events"`
+}
+
+// EventRef 写方法发出的合约事件
+type EventRef struct {
+	Id    string `json:"id"`
+	Topic string `json:"topic"`
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 可由管理员调整的配置项, 以 JSON 值传入 InitContract 的 config 参数或 setConfig
+//
+//	strictContent        bool      内容哈希严格校验模式      -> config_strict_content
+//	regulators           []string  监管机构账户              -> config_regulators
+//	tokenBaseURI         string    tokenURI 的前缀          -> config_token_base_uri
+//	paymentContract      string    挂单成交时付款的资产合约   -> config_payment_contract
+//	paymentAttestors     []string  登记链外付款记录的账户     -> config_payment_attestors
+//	arbitrators          []string  受理及裁决权属争议的账户   -> config_arbitrators
+//	enum.<枚举名>         {min,max} 接口规范定义的枚举取值范围 -> config_enum_<枚举名>
+const (
+	enumConfigPrefix    = "enum."
+	tokenBaseURIKey     = "config_token_base_uri"
+	paymentContractKey  = "config_payment_contract"
+	paymentAttestorsKey = "config_payment_attestors"
+)
+
+// configurableEnums 取值范围可调整的枚举, 其含义由接口规范定义, 规范修订时可能扩展
+var configurableEnums = map[string]bool{
+	"copyrightType":      true,
+	"copyrightGetType":   true,
+	"approveChannel":     true,
+	"approveArea":        true,
+	"approveTime":        true,
+	"approveStatus":      true,
+	"distributionMethod": true,
+}
+
+// enumBounds 可调整枚举的取值范围(闭区间)
+type enumBounds struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// applyConfig 校验并写入一个配置项
+func applyConfig(stub shim.CMStubInterface, name string, value []byte) error {
+	switch {
+	case name == "strictContent":
+		var strict bool
+		if err := json.Unmarshal(value, &strict); err != nil {
+			return newError(ErrInvalidParam, "value", "strictContent must be bool, got: %s", string(value))
+		}
+		return putStrictContentMode(stub, strict)
+	case name == "regulators":
+		var regulators []string
+		if err := json.Unmarshal(value, &regulators); err != nil {
+			return newError(ErrInvalidParam, "value", "regulators must be json string array: %s", err.Error())
+		}
+		return putRegulators(stub, regulators)
+	case name == "tokenBaseURI":
+		return putConfigString(stub, name, tokenBaseURIKey, value)
+	case name == "paymentContract":
+		return putConfigString(stub, name, paymentContractKey, value)
+	case name == "paymentAttestors":
+		var attestors []string
+		if err := json.Unmarshal(value, &attestors); err != nil {
+			return newError(ErrInvalidParam, "value", "paymentAttestors must be json string array: %s", err.Error())
+		}
+		return putIndex(stub, paymentAttestorsKey, attestors)
+	case name == "arbitrators":
+		var arbitrators []string
+		if err := json.Unmarshal(value, &arbitrators); err != nil {
+			return newError(ErrInvalidParam, "value", "arbitrators must be json string array: %s", err.Error())
+		}
+		return putIndex(stub, arbitratorsKey, arbitrators)
+	case strings.HasPrefix(name, enumConfigPrefix):
+		enumName := strings.TrimPrefix(name, enumConfigPrefix)
+		if !configurableEnums[enumName] {
+			return newError(ErrInvalidParam, "name", "enum %s is not configurable", enumName)
+		}
+		var bounds enumBounds
+		if err := json.Unmarshal(value, &bounds); err != nil {
+			return newError(ErrInvalidParam, "value", "enum bounds must be {\"min\":int,\"max\":int}: %s", err.Error())
+		}
+		if bounds.Min < 0 || bounds.Max < bounds.Min {
+			return newError(ErrInvalidParam, "value", "invalid enum bounds: %d-%d", bounds.Min, bounds.Max)
+		}
+		boundsBytes, err := json.Marshal(bounds)
+		if err != nil {
+			return fmt.Errorf("marshal enum bounds error: %s", err.Error())
+		}
+		if err := stub.PutStateFromKeyByte("config_enum_"+enumName, boundsBytes); err != nil {
+			return fmt.Errorf("fail to save enum bounds for %s: %s", enumName, err.Error())
+		}
+		return nil
+	default:
+		return newError(ErrInvalidParam, "name", "unknown config: %s", name)
+	}
+}
+
+// saveInitConfig 根据初始化参数 config(JSON 对象) 写入配置项, 按名称顺序处理
+func saveInitConfig(stub shim.CMStubInterface) error {
+	configStr := string(stub.GetArgs()["config"])
+	if configStr == "" {
+		return nil
+	}
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(configStr), &config); err != nil {
+		return newError(ErrInvalidParam, "config", "fail to parse 'config' json object: %s", err.Error())
+	}
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := applyConfig(stub, name, config[name]); err != nil {
+			if ce, ok := err.(*ContractError); ok {
+				ce.Field = "config." + name
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// putConfigString 写入字符串配置项
+func putConfigString(stub shim.CMStubInterface, name, key string, value []byte) error {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return newError(ErrInvalidParam, "value", "%s must be string, got: %s", name, string(value))
+	}
+	if err := stub.PutStateFromKeyByte(key, []byte(s)); err != nil {
+		return fmt.Errorf("fail to save %s: %s", name, err.Error())
+	}
+	return nil
+}
+
+// getConfigString 读取字符串配置项, 未配置时为空串
+func getConfigString(stub shim.CMStubInterface, key string) (string, error) {
+	value, err := stub.GetStateFromKeyByte(key)
+	if err != nil {
+		return "", fmt.Errorf("fail to GetState for %s: %s", key, err.Error())
+	}
+	return string(value), nil
+}
+
+// getEnumBounds 读取管理员调整后的枚举取值范围, 未调整时返回 nil
+func getEnumBounds(stub shim.CMStubInterface, name string) (*enumBounds, error) {
+	if !configurableEnums[name] {
+		return nil, nil
+	}
+	boundsBytes, err := stub.GetStateFromKeyByte("config_enum_" + name)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for config_enum_%s: %s", name, err.Error())
+	}
+	if len(boundsBytes) == 0 {
+		return nil, nil
+	}
+	var bounds enumBounds
+	if err := json.Unmarshal(boundsBytes, &bounds); err != nil {
+		return nil, fmt.Errorf("unmarshal enum bounds for %s error: %s", name, err.Error())
+	}
+	return &bounds, nil
+}
+
+// enumRange 枚举当前的取值范围说明, 如 "0-16", 用于错误信息
+func enumRange(stub shim.CMStubInterface, name string) string {
+	if bounds, err := getEnumBounds(stub, name); err == nil && bounds != nil {
+		return strconv.Itoa(bounds.Min) + "-" + strconv.Itoa(bounds.Max)
+	}
+	values := contractEnums[name].Values
+	if len(values) == 0 {
+		return ""
+	}
+	return strconv.Itoa(values[0].Value) + "-" + strconv.Itoa(values[len(values)-1].Value)
+}
@@ -0,0 +1,17 @@
+//go:build !simulator
+
+package main
+
+import (
+	"chainmaker/shim"
+	"log"
+)
+
+func main() {
+
+	//运行合约
+	err := shim.Start(new(TokenContract))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
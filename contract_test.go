@@ -0,0 +1,2072 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	copyrightId = strings.Repeat("a", 64)
+	approveId   = strings.Repeat("b", 64)
+	licenseId   = strings.Repeat("c", 64)
+	derivedId   = strings.Repeat("d", 64)
+)
+
+// testCase 表驱动测试用例, wantErr 为空表示期望成功, 否则为期望错误信息的子串
+type testCase struct {
+	name    string
+	args    map[string]string
+	wantErr string
+}
+
+func mustOK(t *testing.T, resp protogo.Response) {
+	t.Helper()
+	if resp.Status != shim.OK {
+		t.Fatalf("unexpected error: %s", resp.Message)
+	}
+}
+
+func expectResult(t *testing.T, resp protogo.Response, wantErr string) {
+	t.Helper()
+	if wantErr == "" {
+		mustOK(t, resp)
+		return
+	}
+	if resp.Status == shim.OK {
+		t.Fatalf("expected error containing %q, got success: %s", wantErr, resp.Payload)
+	}
+	if !strings.Contains(resp.Message, wantErr) {
+		t.Fatalf("expected error containing %q, got: %s", wantErr, resp.Message)
+	}
+}
+
+// runCases 每个用例在独立的状态上执行
+func runCases(t *testing.T, setup func(t *testing.T) *mockStub, method string, cases []testCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := setup(t)
+			expectResult(t, stub.invoke(method, tc.args), tc.wantErr)
+		})
+	}
+}
+
+// with 复制参数并覆盖/删除(值为 "-")指定字段
+func with(base map[string]string, overrides map[string]string) map[string]string {
+	args := make(map[string]string, len(base))
+	for k, v := range base {
+		args[k] = v
+	}
+	for k, v := range overrides {
+		if v == "-" {
+			delete(args, k)
+			continue
+		}
+		args[k] = v
+	}
+	return args
+}
+
+func toJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+func issueArgs() map[string]string {
+	return map[string]string{
+		"account":        "0xissuer",
+		"publisher":      "0xissuer",
+		"token":          "MediaToken",
+		"number":         "100",
+		"flag":           "0",
+		"version":        "v2",
+		"roles":          `[{"role":"0xadmin","type":1}]`,
+		"reference_flag": "1",
+	}
+}
+
+func workArgs() map[string]string {
+	return map[string]string{
+		"account": "0xalice",
+		"work": toJSON(map[string]interface{}{
+			"workId":        "work-1",
+			"title":         "Song",
+			"creators":      []string{"Alice", "Bob"},
+			"copyrightType": 1,
+			"creationDate":  "2024-01-01",
+		}),
+	}
+}
+
+// tokenObject 默认的版权通证 tokenObject, mutate 可修改字段
+func tokenObject(mutate func(m map[string]interface{})) string {
+	m := map[string]interface{}{
+		"flag":             0,
+		"tokenId":          copyrightId,
+		"copyrightType":    1,
+		"copyrightGetType": 0,
+		"copyrightUnits": []map[string]string{
+			{"address": "0xalice", "proportion": "0.6"},
+			{"address": "0xbob", "proportion": "0.4"},
+		},
+		"workId": "work-1",
+		"term":   "2024-01-01/2094-12-31",
+	}
+	if mutate != nil {
+		mutate(m)
+	}
+	return toJSON(m)
+}
+
+func publishArgs() map[string]string {
+	return map[string]string{
+		"publisher":     "0xissuer",
+		"receiver":      "0xalice",
+		"token":         "MediaToken",
+		"referenceFlag": "1",
+		"tokenObject":   tokenObject(nil),
+	}
+}
+
+func approveArgs() map[string]string {
+	return map[string]string{
+		"publisher":          "0xalice",
+		"receiver":           "0xcarol",
+		"token":              "MediaToken",
+		"tokenId":            approveId,
+		"referenceID":        copyrightId,
+		"approveType":        "1",
+		"approveConstraints": `[{"approveChannel":1,"approveArea":0,"approveTime":1,"approveStatus":1,"reapproveType":0}]`,
+		"duty":               `[{"distributionMethod":1,"distributionDesc":"per play","receivablePayment":"100"}]`,
+		"time":               "2024-06-01/2026-06-01",
+	}
+}
+
+func licenseArgs() map[string]string {
+	return map[string]string{
+		"publisher":   "0xcarol",
+		"receiver":    "0xdave",
+		"token":       "MediaToken",
+		"tokenId":     licenseId,
+		"referenceId": approveId,
+		"tokenInfos":  `[{"type":"platform","data":"web"}]`,
+		"time":        "2025-01-01/2025-12-31",
+	}
+}
+
+func setupEmpty(t *testing.T) *mockStub {
+	return newMockStub()
+}
+
+func setupIssued(t *testing.T) *mockStub {
+	stub := newMockStub()
+	// 监管机构 0xreg, 直接写入配置, 不经 InitContract 以免标记数据版本及写入审计记录
+	stub.state["config_regulators"] = []byte(`["0xreg"]`)
+	mustOK(t, stub.invoke("buildTokenIssueTx", issueArgs()))
+	mustOK(t, stub.invoke("buildRegisterWorkTx", workArgs()))
+	return stub
+}
+
+func setupPublished(t *testing.T) *mockStub {
+	stub := setupIssued(t)
+	mustOK(t, stub.invoke("buildPublishTokenTx", publishArgs()))
+	return stub
+}
+
+func setupApproved(t *testing.T) *mockStub {
+	stub := setupPublished(t)
+	mustOK(t, stub.invoke("buildPublishApproveTokenTx", approveArgs()))
+	return stub
+}
+
+func setupLicensed(t *testing.T) *mockStub {
+	stub := setupApproved(t)
+	mustOK(t, stub.invoke("buildPubTokenTx", licenseArgs()))
+	return stub
+}
+
+func TestInvalidMethod(t *testing.T) {
+	expectResult(t, newMockStub().invoke("noSuchMethod", nil), "invalid method")
+}
+
+// decodeError 解析 Response.Message 中的结构化错误
+func decodeError(t *testing.T, resp protogo.Response) ContractError {
+	t.Helper()
+	if resp.Status == shim.OK {
+		t.Fatalf("expected error, got success: %s", resp.Payload)
+	}
+	var ce ContractError
+	if err := json.Unmarshal([]byte(resp.Message), &ce); err != nil {
+		t.Fatalf("error is not structured JSON: %s", resp.Message)
+	}
+	return ce
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestErrorCodes(t *testing.T) {
+	cases := []struct {
+		method string
+		args   map[string]string
+		code   string
+		field  string
+	}{
+		{"requestTokenInfo", map[string]string{"tokenId": copyrightId}, ErrMissingParam, "version"},
+		{"requestTokenInfo", map[string]string{"tokenId": derivedId, "version": "v2"}, ErrNotFound, "tokenId"},
+		{"requestTokenInfo", map[string]string{"tokenId": copyrightId, "version": "v1"}, ErrVersionMismatch, "version"},
+		{"buildTokenIssueTx", with(issueArgs(), map[string]string{"number": "x"}), ErrInvalidParam, "number"},
+		{"buildPublishTokenTx", with(publishArgs(), map[string]string{"referenceFlag": "9"}), ErrInvalidEnum, "referenceFlag"},
+		{"buildPublishApproveTokenTx", with(approveArgs(), map[string]string{"time": "2020-01-01/2020-02-01"}), ErrInvalidDate, "time"},
+		{"buildModifyCopyrightUnitTx", map[string]string{"account": "0xnobody", "tokenId": copyrightId, "address": "0xnew"}, ErrForbidden, "account"},
+		{"buildTransferProportionTx", map[string]string{"account": "0xalice", "tokenId": copyrightId, "copyrightUnits": `[{"address":"0xdave","proportion":"0.1"}]`}, ErrSumMismatch, "copyrightUnits"},
+		{"buildBurnTokenTx", map[string]string{"account": "0xalice", "tokenId": copyrightId, "tokenType": "1", "signers": `["0xbob"]`}, ErrHasDependents, "cascade"},
+		{"noSuchMethod", nil, ErrInvalidParam, "method"},
+	}
+	for _, tc := range cases {
+		stub := setupLicensed(t)
+		ce := decodeError(t, stub.invoke(tc.method, tc.args))
+		if ce.Code != tc.code || ce.Field != tc.field || ce.Method != tc.method {
+			t.Fatalf("%s: got %+v, want code=%s field=%s", tc.method, ce, tc.code, tc.field)
+		}
+		// 返回的错误码须在 describe 目录中列出
+		if spec, ok := findMethodSpec(tc.method); ok && !containsString(spec.Errors, ce.Code) {
+			t.Fatalf("%s returned %s which describe does not list", tc.method, ce.Code)
+		}
+	}
+
+	stub := setupLicensed(t)
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1"}))
+	if ce := decodeError(t, stub.invoke("buildBurnTokenTx", map[string]string{"account": "0xalice", "tokenId": copyrightId, "tokenType": "1"})); ce.Code != ErrFrozen {
+		t.Fatalf("frozen burn: got %+v", ce)
+	}
+}
+
+// decodeTxResult 解析写方法的返回结果
+func decodeTxResult(t *testing.T, resp protogo.Response) TxResult {
+	t.Helper()
+	mustOK(t, resp)
+	var result TxResult
+	if err := json.Unmarshal(resp.Payload, &result); err != nil {
+		t.Fatalf("unmarshal result: %v, payload: %s", err, resp.Payload)
+	}
+	return result
+}
+
+func TestTxResult(t *testing.T) {
+	stub := setupPublished(t)
+
+	result := decodeTxResult(t, stub.invoke("buildPublishApproveTokenTx", approveArgs()))
+	if result.Id != approveId || result.Key != "approve_token_"+approveId || result.RecordVersion != 1 {
+		t.Fatalf("unexpected approve result: %+v", result)
+	}
+	if !containsString(result.Keys, "approve_ref_"+copyrightId+"#"+approveId) || !containsString(result.Keys, "token_issue_MediaToken") {
+		t.Fatalf("affected keys incomplete: %v", result.Keys)
+	}
+	if len(result.Events) != 1 || result.Events[0].Topic != "event_approve_token" || result.Events[0].Id != result.TxId+"#0" {
+		t.Fatalf("unexpected events: %+v", result.Events)
+	}
+	var approveToken ApproveToken
+	if err := json.Unmarshal(result.Record, &approveToken); err != nil || approveToken.TokenId != approveId {
+		t.Fatalf("unexpected record: %s", result.Record)
+	}
+
+	// 每次写入版本号递增, 返回的记录与链上状态一致
+	result = decodeTxResult(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1"}))
+	if result.RecordVersion != 2 {
+		t.Fatalf("recordVersion = %d, want 2", result.RecordVersion)
+	}
+	stored := stub.state["publish_token_"+copyrightId]
+	if string(stored) != string(result.Record) {
+		t.Fatalf("record differs from state:\n%s\n%s", result.Record, stored)
+	}
+
+	result = decodeTxResult(t, stub.invoke("buildBurnTokenTx", map[string]string{"account": "0xcarol", "tokenId": approveId, "tokenType": "2"}))
+	if result.Key != "tombstone_"+approveId || result.RecordVersion != 2 || !containsString(result.Keys, "approve_token_"+approveId) {
+		t.Fatalf("unexpected burn result: %+v", result)
+	}
+}
+
+func TestExpectedVersion(t *testing.T) {
+	stub := setupPublished(t)
+	flagArgs := map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1", "expectedVersion": "1"}
+
+	// 两个操作员基于同一版本修改, 后提交者冲突
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", flagArgs))
+	ce := decodeError(t, stub.invoke("buildTokenChangeTx", map[string]string{"account": "0xalice", "tokenId": copyrightId, "expectedVersion": "1"}))
+	if ce.Code != ErrVersionConflict || ce.Field != "expectedVersion" {
+		t.Fatalf("unexpected error: %+v", ce)
+	}
+	mustOK(t, stub.invoke("buildTokenChangeTx", map[string]string{"account": "0xalice", "tokenId": copyrightId, "expectedVersion": "2"}))
+	expectResult(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", with(flagArgs, map[string]string{"expectedVersion": "x"})), "expectedVersion must be integer")
+
+	// expectedVersion=0 表示记录尚不存在
+	expectResult(t, stub.invoke("buildPublishApproveTokenTx", with(approveArgs(), map[string]string{"expectedVersion": "0"})), "")
+
+	// 已发行的授权通证不可重新发行覆盖发行者及接收者
+	ce = decodeError(t, stub.invoke("buildPublishApproveTokenTx", with(approveArgs(), map[string]string{"publisher": "0xbob", "receiver": "0xbob"})))
+	if ce.Code != ErrAlreadyExists || ce.Field != "tokenId" {
+		t.Fatalf("unexpected error: %+v", ce)
+	}
+	if approve, _ := getApproveToken(stub, approveId); approve.Publisher != "0xalice" || approve.Receiver != "0xcarol" || approve.RecordVersion != 1 {
+		t.Fatalf("approval overwritten by re-publish: %+v", approve)
+	}
+
+	// 已发行的版权通证不可重新发行覆盖持有者, 持有者变更须经两步变更
+	ce = decodeError(t, stub.invoke("buildPublishTokenTx", with(publishArgs(), map[string]string{"receiver": "0xmallory"})))
+	if ce.Code != ErrAlreadyExists || ce.Field != "tokenObject.tokenId" {
+		t.Fatalf("unexpected error: %+v", ce)
+	}
+	if detail, _ := getTokenDetail(stub, copyrightId); detail.OwnerAccount != "0xalice" {
+		t.Fatalf("owner overwritten by re-publish: %+v", detail)
+	}
+}
+
+func TestInitContract(t *testing.T) {
+	stub := newMockStub()
+	mustOK(t, stub.init(map[string]string{"strictContent": "true", "regulators": `["0xreg"]`}))
+	if string(stub.state["config_strict_content"]) != "1" {
+		t.Fatalf("strict mode not saved")
+	}
+	expectResult(t, newMockStub().init(map[string]string{"strictContent": "maybe"}), "strictContent must be bool")
+	expectResult(t, newMockStub().init(map[string]string{"regulators": "0xreg"}), "regulators")
+}
+
+func TestBuildTokenIssueTx(t *testing.T) {
+	runCases(t, setupEmpty, "buildTokenIssueTx", []testCase{
+		{"ok", issueArgs(), ""},
+		{"missing account", with(issueArgs(), map[string]string{"account": "-"}), "missing required param"},
+		{"number not int", with(issueArgs(), map[string]string{"number": "x"}), "number must be integer"},
+		{"flag not int", with(issueArgs(), map[string]string{"flag": "x"}), "flag must be integer"},
+		{"reference_flag not int", with(issueArgs(), map[string]string{"reference_flag": "x"}), "reference_flag must be integer"},
+		{"reference_flag out of range", with(issueArgs(), map[string]string{"reference_flag": "4"}), "reference_flag out of valid range"},
+		{"bad roles", with(issueArgs(), map[string]string{"roles": "{"}), "fail to parse 'roles'"},
+	})
+
+	t.Run("reissue keeps counters", func(t *testing.T) {
+		stub := setupPublished(t)
+		mustOK(t, stub.invoke("buildTokenIssueTx", issueArgs()))
+		issue, _ := getTokenIssue(stub, "MediaToken")
+		if issue.Issued != 1 {
+			t.Fatalf("issued counter lost, got %d", issue.Issued)
+		}
+		expectResult(t, stub.invoke("buildTokenIssueTx", with(issueArgs(), map[string]string{"number": "0"})), "")
+	})
+}
+
+func TestBuildPublishTokenTx(t *testing.T) {
+	runCases(t, setupIssued, "buildPublishTokenTx", []testCase{
+		{"ok", publishArgs(), ""},
+		{"missing receiver", with(publishArgs(), map[string]string{"receiver": "-"}), "missing required param"},
+		{"referenceFlag not int", with(publishArgs(), map[string]string{"referenceFlag": "x"}), "referenceFlag must be integer"},
+		{"referenceFlag out of range", with(publishArgs(), map[string]string{"referenceFlag": "0"}), "out of valid range"},
+		{"bad tokenObject", with(publishArgs(), map[string]string{"tokenObject": "{"}), "fail to parse tokenObject"},
+		{"missing tokenId", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			delete(m, "tokenId")
+		})}), "missing required field: 'tokenId'"},
+		{"copyrightType out of range", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["copyrightType"] = 17
+		})}), "copyrightType out of valid range"},
+		{"copyrightGetType out of range", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["copyrightGetType"] = 6
+		})}), "copyrightGetType out of valid range"},
+		{"bad term", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["term"] = "2024-01-01"
+		})}), "invalid ISO-8601 interval"},
+		{"bad publish date", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["copyrightStatus"] = []map[string]interface{}{{"publishDate": "01/02/2024"}}
+		})}), "copyrightStatus[0].publishDate"},
+		{"bad apprConstraint time", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["apprConstraint"] = []map[string]interface{}{{"time": "2025-01-01/2024-01-01"}}
+		})}), "ends before it starts"},
+		{"missing workId", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			delete(m, "workId")
+		})}), "requires 'workId'"},
+		{"unregistered work", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["workId"] = "work-x"
+		})}), "workId not registered"},
+		{"class not issued", with(publishArgs(), map[string]string{"token": "Other"}), "token class not issued"},
+		{"parentLinks on non-copyright", with(publishArgs(), map[string]string{
+			"referenceFlag": "2",
+			"tokenObject": tokenObject(func(m map[string]interface{}) {
+				m["parentLinks"] = []map[string]interface{}{{"tokenId": copyrightId, "approveTokenId": approveId}}
+			}),
+		}), "parentLinks only allowed"},
+	})
+
+	t.Run("stores detail and indexes", func(t *testing.T) {
+		stub := setupPublished(t)
+		detail, err := getTokenDetail(stub, copyrightId)
+		if err != nil || detail == nil {
+			t.Fatalf("detail not stored: %v", err)
+		}
+		if detail.OwnerAccount != "0xalice" || detail.Version != "v2" || detail.Flag != 1 || len(detail.CopyrightUnits) != 2 {
+			t.Fatalf("unexpected detail: %+v", detail)
+		}
+		if pos, _ := getHoldingPos(stub, "0xalice", copyrightId); pos == nil || pos.Field != holdingSortField(detail, 0) ||
+			string(stub.state["account_holding_0xalice#"+pos.Field]) != copyrightId ||
+			string(stub.state["account_holding_desc_0xalice#"+reverseField(pos.Field)]) != copyrightId {
+			t.Fatalf("account index not updated: %+v", pos)
+		}
+		issue, _ := getTokenIssue(stub, "MediaToken")
+		if issue.Issued != 1 {
+			t.Fatalf("issued counter = %d, want 1", issue.Issued)
+		}
+	})
+
+	t.Run("supply exhausted", func(t *testing.T) {
+		stub := newMockStub()
+		mustOK(t, stub.invoke("buildTokenIssueTx", with(issueArgs(), map[string]string{"number": "1"})))
+		mustOK(t, stub.invoke("buildRegisterWorkTx", workArgs()))
+		mustOK(t, stub.invoke("buildPublishTokenTx", publishArgs()))
+		expectResult(t, stub.invoke("buildPublishTokenTx", with(publishArgs(), map[string]string{
+			"tokenObject": tokenObject(func(m map[string]interface{}) { m["tokenId"] = derivedId }),
+		})), "supply exhausted")
+	})
+}
+
+func TestStrictContentMode(t *testing.T) {
+	setup := func(t *testing.T) *mockStub {
+		stub := setupIssued(t)
+		mustOK(t, stub.init(map[string]string{"strictContent": "true"}))
+		mustOK(t, stub.invoke("saveFact", map[string]string{"fileHash": copyrightId, "fileName": "song.mp3", "time": "1700000000"}))
+		return stub
+	}
+	runCases(t, setup, "buildPublishTokenTx", []testCase{
+		{"ok", publishArgs(), ""},
+		{"not a hash", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["tokenId"] = "token-1"
+		})}), "must be hash256"},
+		{"no fact", with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["tokenId"] = derivedId
+		})}), "no registered fact"},
+	})
+
+	t.Run("collision names existing token and owner", func(t *testing.T) {
+		stub := setup(t)
+		mustOK(t, stub.invoke("buildPublishTokenTx", publishArgs()))
+		resp := stub.invoke("buildPublishTokenTx", with(publishArgs(), map[string]string{
+			"receiver": "0xmallory",
+			"tokenObject": tokenObject(func(m map[string]interface{}) {
+				m["tokenId"] = derivedId
+				m["contentHash"] = copyrightId
+			}),
+		}))
+		expectResult(t, resp, "already claimed by token "+copyrightId+" (owner: 0xalice)")
+
+		// 转让后报告当前持有者
+		stub.state["content_hash_"+copyrightId] = []byte(`{"contentHash":"` + copyrightId + `","tokenId":"` + copyrightId + `","owner":"0xstale"}`)
+		detail, _ := getTokenDetail(stub, copyrightId)
+		detail.OwnerAccount = "0xdave"
+		stub.state["publish_token_"+copyrightId] = []byte(toJSON(detail))
+		resp = stub.invoke("buildPublishTokenTx", with(publishArgs(), map[string]string{
+			"receiver": "0xmallory",
+			"tokenObject": tokenObject(func(m map[string]interface{}) {
+				m["tokenId"] = derivedId
+				m["contentHash"] = copyrightId
+			}),
+		}))
+		expectResult(t, resp, "already claimed by token "+copyrightId+" (owner: 0xdave)")
+	})
+}
+
+func TestSaveFactAndFind(t *testing.T) {
+	factArgs := map[string]string{"fileHash": copyrightId, "fileName": "song.mp3", "time": "1700000000"}
+	runCases(t, setupEmpty, "saveFact", []testCase{
+		{"ok", factArgs, ""},
+		{"missing fileName", with(factArgs, map[string]string{"fileName": "-"}), "missing required param"},
+		{"not a hash", with(factArgs, map[string]string{"fileHash": "abc"}), "must be hash256"},
+		{"time not int", with(factArgs, map[string]string{"time": "x"}), "time must be integer"},
+	})
+
+	stub := newMockStub()
+	mustOK(t, stub.invoke("saveFact", factArgs))
+	expectResult(t, stub.invoke("saveFact", factArgs), "fact already exists")
+	if len(stub.events) != 0 {
+		t.Fatalf("failed save must not emit events")
+	}
+	resp := stub.invoke("findByFileHash", map[string]string{"fileHash": strings.ToUpper(copyrightId)})
+	mustOK(t, resp)
+	var fact Fact
+	if err := json.Unmarshal(resp.Payload, &fact); err != nil || fact.FileName != "song.mp3" {
+		t.Fatalf("unexpected fact: %s", resp.Payload)
+	}
+	expectResult(t, stub.invoke("findByFileHash", nil), "missing required param")
+	expectResult(t, stub.invoke("findByFileHash", map[string]string{"fileHash": derivedId}), "no fact found")
+}
+
+func TestBuildRegisterWorkTx(t *testing.T) {
+	work := func(mutate func(m map[string]interface{})) string {
+		m := map[string]interface{}{
+			"workId": "work-2", "title": "Film", "creators": []string{"Eve"},
+			"copyrightType": 2, "creationDate": "2024-03-01",
+		}
+		mutate(m)
+		return toJSON(m)
+	}
+	runCases(t, setupIssued, "buildRegisterWorkTx", []testCase{
+		{"ok", map[string]string{"account": "0xeve", "work": work(func(m map[string]interface{}) {})}, ""},
+		{"missing account", map[string]string{"work": work(func(m map[string]interface{}) {})}, "missing required param"},
+		{"bad json", map[string]string{"account": "0xeve", "work": "{"}, "fail to parse work"},
+		{"missing title", map[string]string{"account": "0xeve", "work": work(func(m map[string]interface{}) { delete(m, "title") })}, "missing required fields"},
+		{"bad type", map[string]string{"account": "0xeve", "work": work(func(m map[string]interface{}) { m["copyrightType"] = 20 })}, "copyrightType out of valid range"},
+		{"bad date", map[string]string{"account": "0xeve", "work": work(func(m map[string]interface{}) { m["creationDate"] = "2024/03/01" })}, "creationDate"},
+		{"duplicate", workArgs(), "work already registered"},
+	})
+
+	stub := setupIssued(t)
+	resp := stub.invoke("requestWorkInfo", map[string]string{"workId": "work-1"})
+	mustOK(t, resp)
+	var got Work
+	if err := json.Unmarshal(resp.Payload, &got); err != nil || got.Registrant != "0xalice" {
+		t.Fatalf("unexpected work: %s", resp.Payload)
+	}
+	expectResult(t, stub.invoke("requestWorkInfo", nil), "missing required param")
+	expectResult(t, stub.invoke("requestWorkInfo", map[string]string{"workId": "nope"}), "no work found")
+}
+
+func TestBuildPublishApproveTokenTx(t *testing.T) {
+	runCases(t, setupPublished, "buildPublishApproveTokenTx", []testCase{
+		{"ok", approveArgs(), ""},
+		{"missing tokenId", with(approveArgs(), map[string]string{"tokenId": "-"}), "missing required param"},
+		{"approveType not int", with(approveArgs(), map[string]string{"approveType": "x"}), "approveType must be integer"},
+		{"unknown copyright token", with(approveArgs(), map[string]string{"referenceID": derivedId}), "referenceID not found"},
+		{"unit holder", with(approveArgs(), map[string]string{"publisher": "0xbob"}), ""},
+		{"not a holder", with(approveArgs(), map[string]string{"publisher": "0xcarol"}), "neither the owner nor a copyright unit holder"},
+		{"bad window", with(approveArgs(), map[string]string{"time": "soon"}), "invalid ISO-8601 interval"},
+		{"window outside term", with(approveArgs(), map[string]string{"time": "2020-01-01/2021-01-01"}), "within the copyright term"},
+		{"missing window with term", with(approveArgs(), map[string]string{"time": "-"}), "within the copyright term"},
+		{"bad constraints", with(approveArgs(), map[string]string{"approveConstraints": "{"}), "fail to parse 'approveConstraints'"},
+		{"bad duty", with(approveArgs(), map[string]string{"duty": "{"}), "fail to parse 'duty'"},
+		{"status out of range", with(approveArgs(), map[string]string{"approveConstraints": `[{"approveStatus":2}]`}), "ApproveStatus out of range"},
+		{"channel out of range", with(approveArgs(), map[string]string{"approveConstraints": `[{"approveChannel":5}]`}), "ApproveChannel out of range"},
+		{"area out of range", with(approveArgs(), map[string]string{"approveConstraints": `[{"approveArea":3}]`}), "ApproveArea out of range"},
+		{"time out of range", with(approveArgs(), map[string]string{"approveConstraints": `[{"approveTime":4}]`}), "ApproveTime out of range"},
+		{"distribution out of range", with(approveArgs(), map[string]string{"duty": `[{"distributionMethod":4}]`}), "DistributionMethod out of range"},
+		{"bad derivativeUses", with(approveArgs(), map[string]string{"derivativeUses": "x"}), "fail to parse 'derivativeUses'"},
+		{"derivativeUses out of range", with(approveArgs(), map[string]string{"derivativeUses": "[4]"}), "derivativeUses[0] out of range"},
+	})
+}
+
+func TestBuildPubTokenTxAndOwnerSign(t *testing.T) {
+	runCases(t, setupApproved, "buildPubTokenTx", []testCase{
+		{"ok", licenseArgs(), ""},
+		{"missing referenceId", with(licenseArgs(), map[string]string{"referenceId": "-"}), "missing required param"},
+		{"bad tokenInfos", with(licenseArgs(), map[string]string{"tokenInfos": "{"}), "fail to parse tokenInfos"},
+		{"unknown approve token", with(licenseArgs(), map[string]string{"referenceId": derivedId}), "no approve token found"},
+		{"bad window", with(licenseArgs(), map[string]string{"time": "x/y"}), "invalid ISO-8601 date"},
+		{"window outside approval", with(licenseArgs(), map[string]string{"time": "2025-01-01/2027-01-01"}), "within the approve token window"},
+	})
+
+	t.Run("expired approval", func(t *testing.T) {
+		stub := setupApproved(t)
+		stub.timestamp = 1790000000 // 2026-09
+		expectResult(t, stub.invoke("buildPubTokenTx", licenseArgs()), "approve token is not active")
+	})
+
+	signArgs := map[string]string{"account": "0xalice", "secret": "s3cret", "tokenId": licenseId}
+	runCases(t, setupLicensed, "ownerSign", []testCase{
+		{"ok", signArgs, ""},
+		{"missing secret", with(signArgs, map[string]string{"secret": "-"}), "missing required param"},
+		{"missing tokenId", with(signArgs, map[string]string{"tokenId": "-"}), "missing required param: 'tokenId'"},
+		{"unknown license", with(signArgs, map[string]string{"tokenId": derivedId}), "no such pubTokenTx"},
+	})
+
+	t.Run("expired license", func(t *testing.T) {
+		stub := setupLicensed(t)
+		stub.timestamp = 1770000000 // 2026-02
+		expectResult(t, stub.invoke("ownerSign", signArgs), "license is not active")
+	})
+}
+
+func TestRequestAccountToken(t *testing.T) {
+	args := map[string]string{"account": "0xalice", "version": "v2"}
+	runCases(t, setupPublished, "requestAccountToken", []testCase{
+		{"ok", args, ""},
+		{"missing version", with(args, map[string]string{"version": "-"}), "missing required param"},
+		{"bad flag", with(args, map[string]string{"flag": "x"}), "invalid 'flag' param"},
+		{"bad frozen", with(args, map[string]string{"frozen": "x"}), "frozen must be bool"},
+		{"bad order", with(args, map[string]string{"order": "up"}), "order must be"},
+		{"bad page size", with(args, map[string]string{"pageSize": "0"}), "pageSize must be"},
+		{"bad cursor", with(args, map[string]string{"cursor": "!"}), "invalid cursor"},
+	})
+
+	query := func(stub *mockStub, extra map[string]string) AccountTokenPage {
+		t.Helper()
+		resp := stub.invoke("requestAccountToken", with(args, extra))
+		mustOK(t, resp)
+		var page AccountTokenPage
+		if err := json.Unmarshal(resp.Payload, &page); err != nil {
+			t.Fatalf("bad page: %s", resp.Payload)
+		}
+		return page
+	}
+
+	stub := setupPublished(t)
+	page := query(stub, nil)
+	if len(page.Tokens) != 1 || page.Tokens[0].TokenId != copyrightId || page.Total != 1 || page.NextCursor != "" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	if page.Tokens[0].PublishedAt == "" {
+		t.Fatal("publishedAt not recorded")
+	}
+	if page = query(stub, map[string]string{"flag": "2"}); len(page.Tokens) != 0 {
+		t.Fatalf("flag filter not applied: %+v", page)
+	}
+	if page = query(stub, map[string]string{"account": "0xnobody"}); page.Tokens == nil || len(page.Tokens) != 0 || page.Total != 0 {
+		t.Fatalf("expected empty page, got %+v", page)
+	}
+	// 重新发行被拒绝, 不为新接收方登记持有, 原持有者的持有索引不变
+	expectResult(t, stub.invoke("buildPublishTokenTx", with(publishArgs(), map[string]string{"receiver": "0xmallory"})), "already published")
+	if page = query(stub, map[string]string{"account": "0xmallory"}); page.Total != 0 {
+		t.Fatalf("stale holding for re-publish receiver: %+v", page)
+	}
+
+	// 再发行两个通证, 其中一个冻结, 按发行时间分页
+	ids := []string{copyrightId, strings.Repeat("1", 64), strings.Repeat("2", 64)}
+	for _, id := range ids[1:] {
+		stub.timestamp++
+		tokenId := id
+		mustOK(t, stub.invoke("buildPublishTokenTx", with(publishArgs(), map[string]string{
+			"tokenObject": tokenObject(func(m map[string]interface{}) { m["tokenId"] = tokenId }),
+		})))
+	}
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": ids[1], "flag": "1"}))
+
+	var got []string
+	cursor := ""
+	for {
+		page = query(stub, map[string]string{"pageSize": "2", "cursor": cursor, "order": "desc"})
+		if page.Total != 3 {
+			t.Fatalf("unexpected total: %+v", page)
+		}
+		for _, d := range page.Tokens {
+			got = append(got, d.TokenId)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if len(got) != 3 || got[0] != ids[2] || got[2] != ids[0] {
+		t.Fatalf("unexpected desc order: %v", got)
+	}
+	// total 为过滤后的总数
+	if page = query(stub, map[string]string{"frozen": "true"}); len(page.Tokens) != 1 || page.Tokens[0].TokenId != ids[1] || page.Total != 1 {
+		t.Fatalf("frozen filter not applied: %+v", page)
+	}
+	if page = query(stub, map[string]string{"frozen": "false"}); page.Total != 2 {
+		t.Fatalf("unexpected filtered total: %+v", page)
+	}
+	if page = query(stub, map[string]string{"workId": "work-2", "token": "MediaToken"}); len(page.Tokens) != 0 || page.Total != 0 {
+		t.Fatalf("workId filter not applied: %+v", page)
+	}
+
+	// 按通证发行时间而非取得先后排列: 最早发行的通证转出再转回后仍排在最前
+	for _, change := range [][2]string{{"0xalice", "0xdave"}, {"0xdave", "0xalice"}} {
+		mustOK(t, stub.invoke("buildTokenChangeTx", map[string]string{"account": change[0], "tokenId": ids[0], "to": change[1]}))
+		mustOK(t, stub.invoke("acceptTokenChange", map[string]string{"account": change[1], "tokenId": ids[0]}))
+	}
+	got = nil
+	for _, d := range query(stub, nil).Tokens {
+		got = append(got, d.TokenId)
+	}
+	if len(got) != 3 || got[0] != ids[0] || got[1] != ids[1] || got[2] != ids[2] {
+		t.Fatalf("unexpected publish time order: %v", got)
+	}
+	// 转出时同时移除倒序索引
+	if n := len(stub.keysWithPrefix("account_holding_desc_0xalice#")); n != 3 || len(stub.keysWithPrefix("account_holding_desc_0xdave#")) != 0 {
+		t.Fatalf("unexpected desc index entries: %d", n)
+	}
+	if page = query(stub, map[string]string{"order": "desc", "pageSize": "1"}); len(page.Tokens) != 1 || page.Tokens[0].TokenId != ids[2] {
+		t.Fatalf("unexpected desc page: %+v", page)
+	}
+}
+
+func TestMigrateIndexLayout(t *testing.T) {
+	stub := setupApproved(t)
+	// 还原为旧布局: JSON 数组索引
+	for _, k := range append(stub.keysWithPrefix("account_holding"), stub.keysWithPrefix("approve_ref_")...) {
+		delete(stub.state, k)
+	}
+	second := strings.Repeat("1", 64)
+	stub.state["account_tokens_0xalice"] = []byte(toJSON([]string{second, copyrightId}))
+	stub.state["approve_index_"+copyrightId] = []byte(toJSON([]string{approveId}))
+
+	mustOK(t, new(TokenContract).UpgradeContract(stub))
+	if len(stub.keysWithPrefix("account_tokens_")) != 0 || len(stub.keysWithPrefix("approve_index_")) != 0 {
+		t.Fatal("legacy indexes not removed")
+	}
+	// 旧索引中已不存在的通证不登记
+	if pos, _ := getHoldingPos(stub, "0xalice", copyrightId); pos == nil || string(stub.state["account_holding_0xalice#"+pos.Field]) != copyrightId {
+		t.Fatalf("legacy holding not migrated: %+v", pos)
+	}
+	if pos, _ := getHoldingPos(stub, "0xalice", second); pos != nil {
+		t.Fatalf("missing token migrated: %+v", pos)
+	}
+	if ids, _ := approveRefs(stub, copyrightId); len(ids) != 1 || ids[0] != approveId {
+		t.Fatalf("approve refs not migrated: %v", ids)
+	}
+	if count, _ := holdingCount(stub, "0xalice"); count != 1 {
+		t.Fatalf("holding count = %d, want 1", count)
+	}
+
+	// 重复执行不产生重复条目
+	mustOK(t, new(TokenContract).UpgradeContract(stub))
+	resp := stub.invoke("requestAccountToken", map[string]string{"account": "0xalice", "version": "v2"})
+	mustOK(t, resp)
+	var page AccountTokenPage
+	if err := json.Unmarshal(resp.Payload, &page); err != nil || page.Total != 1 || len(page.Tokens) != 1 {
+		t.Fatalf("unexpected page after migration: %s", resp.Payload)
+	}
+	expectResult(t, stub.invoke("buildBurnTokenTx", map[string]string{"account": "0xalice", "tokenId": copyrightId, "tokenType": "1", "signers": `["0xbob"]`}), "dependent")
+
+	// 单个旧索引超出批次预算时分批转换, 未转换的部分写回旧键
+	for _, k := range stub.keysWithPrefix("account_holding") {
+		delete(stub.state, k)
+	}
+	large := []string{strings.Repeat("2", 64), strings.Repeat("3", 64), copyrightId, strings.Repeat("4", 64), strings.Repeat("5", 64)}
+	stub.state["account_tokens_0xalice"] = []byte(toJSON(large))
+	batches := 0
+	for done := false; !done; batches++ {
+		_, processed, finished, err := migrateIndexLayout(stub, "", 2)
+		if err != nil || processed > 2 {
+			t.Fatalf("batch %d: processed %d, err %v", batches, processed, err)
+		}
+		done = finished
+		if rest, ok := stub.state["account_tokens_0xalice"]; ok && !done && string(rest) != toJSON(large[2*(batches+1):]) {
+			t.Fatalf("unexpected remaining tail after batch %d: %s", batches, rest)
+		}
+	}
+	if batches != 3 || len(stub.keysWithPrefix("account_tokens_")) != 0 {
+		t.Fatalf("large legacy index migrated in %d batches", batches)
+	}
+	if count, _ := holdingCount(stub, "0xalice"); count != 1 {
+		t.Fatalf("holding count = %d after batched migration, want 1", count)
+	}
+}
+
+func TestRunMigration(t *testing.T) {
+	stub := setupApproved(t)
+	mustOK(t, stub.init(map[string]string{"admins": `["0xreg"]`}))
+	status := func() MigrationStatus {
+		t.Helper()
+		resp := stub.invoke("requestMigrationStatus", nil)
+		mustOK(t, resp)
+		var s MigrationStatus
+		if err := json.Unmarshal(resp.Payload, &s); err != nil {
+			t.Fatalf("bad status: %s", resp.Payload)
+		}
+		return s
+	}
+	if s := status(); !s.Done || s.SchemaVersion != latestSchemaVersion() {
+		t.Fatalf("fresh deployment should be up to date: %+v", s)
+	}
+
+	// 模拟升级前的数据: 无数据版本, 旧索引, 记录版本号为 0
+	delete(stub.state, "schema_version")
+	for i := 0; i < 3; i++ {
+		stub.state["account_tokens_0xold"+strconv.Itoa(i)] = []byte(toJSON([]string{copyrightId}))
+	}
+	detail, _ := getTokenDetail(stub, copyrightId)
+	detail.RecordVersion = 0
+	stub.state["publish_token_"+copyrightId] = []byte(toJSON(detail))
+
+	resp := new(TokenContract).UpgradeContract(stub)
+	mustOK(t, resp)
+	if s := status(); !s.Done || s.SchemaVersion != latestSchemaVersion() {
+		t.Fatalf("small dataset should migrate during upgrade: %+v", s)
+	}
+
+	args := map[string]string{"account": "0xreg", "batchSize": "1"}
+	setupRegulated := func(t *testing.T) *mockStub {
+		s := newMockStub()
+		mustOK(t, s.init(map[string]string{"admins": `["0xreg"]`}))
+		return s
+	}
+	runCases(t, setupRegulated, "runMigration", []testCase{
+		{"up to date", args, ""},
+		{"forbidden", with(args, map[string]string{"account": "0xnobody"}), "only admin"},
+		{"bad batch", with(args, map[string]string{"account": "0xreg", "batchSize": "0"}), "batchSize must be"},
+	})
+
+	// 为分批执行重新构造未迁移的状态
+	delete(stub.state, "schema_version")
+	delete(stub.state, "migration_status")
+	for i := 0; i < 3; i++ {
+		stub.state["account_tokens_0xold"+strconv.Itoa(i)] = []byte(toJSON([]string{copyrightId}))
+	}
+	stub.state["publish_token_"+copyrightId] = []byte(toJSON(detail))
+	// 早期版本原样写入的发行参数, 须转换为 TokenDetail 而不是被回填步骤清空
+	const legacyId = "legacy-token-01"
+	stub.state["publish_token_"+legacyId] = []byte(toJSON(map[string]interface{}{
+		"publisher":     "0xpub",
+		"receiver":      "0xlegacy",
+		"tokenName":     "MediaToken",
+		"referenceFlag": 1,
+		"tokenObject": json.RawMessage(tokenObject(func(m map[string]interface{}) {
+			m["tokenId"] = legacyId
+		})),
+	}))
+	for i := 0; i < 20 && !status().Done; i++ {
+		mustOK(t, stub.invoke("runMigration", args))
+		if s := status(); !s.Done && s.Step == "index_layout" && s.Processed > 3 {
+			t.Fatalf("batch size not honoured: %+v", s)
+		}
+	}
+	if s := status(); !s.Done || s.SchemaVersion != latestSchemaVersion() || s.Step != "" {
+		t.Fatalf("migration not finished: %+v", s)
+	}
+	if len(stub.keysWithPrefix("account_tokens_")) != 0 {
+		t.Fatal("legacy indexes not removed")
+	}
+	if detail, _ = getTokenDetail(stub, copyrightId); detail.RecordVersion != 1 {
+		t.Fatalf("record version not backfilled: %d", detail.RecordVersion)
+	}
+	if approve, _ := getApproveToken(stub, approveId); approve.RecordVersion != 1 {
+		t.Fatalf("approve record changed: %d", approve.RecordVersion)
+	}
+	legacy, _ := getTokenDetail(stub, legacyId)
+	if legacy == nil || legacy.TokenId != legacyId || legacy.OwnerAccount != "0xlegacy" || legacy.Publisher != "0xpub" ||
+		legacy.Flag != 1 || legacy.WorkId != "work-1" || len(legacy.CopyrightUnits) != 2 || legacy.RecordVersion != 1 {
+		t.Fatalf("legacy record not converted: %+v", legacy)
+	}
+	if pos, _ := getHoldingPos(stub, "0xlegacy", legacyId); pos == nil {
+		t.Fatal("legacy token not indexed for receiver")
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	setupAdmin := func(t *testing.T) *mockStub {
+		stub := setupIssued(t)
+		mustOK(t, stub.init(map[string]string{
+			"admins": `["0xadmin"]`,
+			"config": `{"enum.copyrightType":{"min":0,"max":20}}`,
+		}))
+		return stub
+	}
+	expectResult(t, newMockStub().init(map[string]string{"config": `{"unknown":1}`}), "unknown config")
+	expectResult(t, newMockStub().init(map[string]string{"config": `{"enum.referenceFlag":{"min":0,"max":9}}`}), "not configurable")
+
+	admin := map[string]string{"account": "0xadmin"}
+	runCases(t, setupAdmin, "setConfig", []testCase{
+		{"ok", with(admin, map[string]string{"name": "strictContent", "value": "true"}), ""},
+		{"not admin", map[string]string{"account": "0xeve", "name": "strictContent", "value": "true"}, "only admin"},
+		{"bad value", with(admin, map[string]string{"name": "regulators", "value": "x"}), "json string array"},
+		{"bad bounds", with(admin, map[string]string{"name": "enum.approveArea", "value": `{"min":3,"max":1}`}), "invalid enum bounds"},
+	})
+	runCases(t, setupAdmin, "pauseContract", []testCase{
+		{"global", admin, ""},
+		{"one method", with(admin, map[string]string{"methodName": "buildPublishTokenTx"}), ""},
+		{"query method", with(admin, map[string]string{"methodName": "requestTokenInfo"}), "not a pausable"},
+		{"not admin", map[string]string{"account": "0xeve"}, "only admin"},
+	})
+	runCases(t, setupAdmin, "rotateAdmin", []testCase{
+		{"add", with(admin, map[string]string{"add": "0xadmin2"}), ""},
+		{"missing target", admin, "'add' or 'remove'"},
+		{"last admin", with(admin, map[string]string{"remove": "0xadmin"}), "last admin"},
+		{"unknown", with(admin, map[string]string{"remove": "0xeve"}), "not an admin"},
+	})
+
+	stub := setupAdmin(t)
+	// 冒用管理员账户: 交易发送者须为 account
+	expectResult(t, stub.invokeAs("0xeve", "pauseContract", admin), "transaction sender 0xeve is not 0xadmin")
+	expectResult(t, stub.invokeAs("0xeve", "setConfig", with(admin, map[string]string{"name": "arbitrators", "value": `["0xeve"]`})), "transaction sender")
+
+	// 调整后的枚举范围生效
+	mustOK(t, stub.invoke("buildRegisterWorkTx", map[string]string{"account": "0xalice", "work": toJSON(map[string]interface{}{
+		"workId": "work-20", "title": "Film", "creators": []string{"Alice"}, "copyrightType": 20, "creationDate": "2024-01-01",
+	})}))
+
+	// 指定方法暂停
+	mustOK(t, stub.invoke("pauseContract", with(admin, map[string]string{"methodName": "buildPublishTokenTx"})))
+	if ce := decodeError(t, stub.invoke("buildPublishTokenTx", publishArgs())); ce.Code != ErrPaused {
+		t.Fatalf("expected PAUSED, got %+v", ce)
+	}
+	mustOK(t, stub.invoke("unpauseContract", with(admin, map[string]string{"methodName": "buildPublishTokenTx"})))
+	mustOK(t, stub.invoke("buildPublishTokenTx", publishArgs()))
+
+	// 全局暂停: 写方法拒绝, 查询及管理方法不受影响
+	mustOK(t, stub.invoke("pauseContract", admin))
+	if ce := decodeError(t, stub.invoke("buildPublishApproveTokenTx", approveArgs())); ce.Code != ErrPaused {
+		t.Fatalf("expected PAUSED, got %+v", ce)
+	}
+	mustOK(t, stub.invoke("requestTokenInfo", map[string]string{"tokenId": copyrightId, "version": "v2"}))
+	mustOK(t, stub.invoke("rotateAdmin", with(admin, map[string]string{"add": "0xadmin2"})))
+	mustOK(t, stub.invoke("rotateAdmin", map[string]string{"account": "0xadmin2", "remove": "0xadmin"}))
+	expectResult(t, stub.invoke("unpauseContract", admin), "only admin")
+	mustOK(t, stub.invoke("unpauseContract", map[string]string{"account": "0xadmin2"}))
+	mustOK(t, stub.invoke("buildPublishApproveTokenTx", approveArgs()))
+
+	resp := stub.invoke("requestAdminInfo", nil)
+	mustOK(t, resp)
+	var info AdminInfo
+	if err := json.Unmarshal(resp.Payload, &info); err != nil || len(info.Admins) != 1 || info.Admins[0] != "0xadmin2" ||
+		len(info.Paused) != 0 || info.Enums["copyrightType"].Max != 20 {
+		t.Fatalf("unexpected admin info: %s", resp.Payload)
+	}
+
+	// 审计记录: 初始化 + 6 次管理操作
+	var actions []string
+	cursor := ""
+	for {
+		resp = stub.invoke("requestAuditLog", map[string]string{"pageSize": "4", "cursor": cursor})
+		mustOK(t, resp)
+		var page AuditPage
+		if err := json.Unmarshal(resp.Payload, &page); err != nil || page.Total != 7 {
+			t.Fatalf("unexpected audit page: %s", resp.Payload)
+		}
+		for _, entry := range page.Entries {
+			actions = append(actions, entry.Action+":"+entry.Account)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	want := []string{"InitContract:0xadmin", "pauseContract:0xadmin", "unpauseContract:0xadmin", "pauseContract:0xadmin",
+		"rotateAdmin:0xadmin", "rotateAdmin:0xadmin2", "unpauseContract:0xadmin2"}
+	if strings.Join(actions, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected audit trail: %v", actions)
+	}
+}
+
+func TestRequestTokenInfo(t *testing.T) {
+	args := map[string]string{"tokenId": copyrightId, "version": "v2"}
+	runCases(t, setupPublished, "requestTokenInfo", []testCase{
+		{"ok", args, ""},
+		{"missing version", with(args, map[string]string{"version": "-"}), "missing required param"},
+		{"unknown token", with(args, map[string]string{"tokenId": derivedId}), "no token found"},
+		{"version mismatch", with(args, map[string]string{"version": "v1"}), "version mismatch"},
+	})
+}
+
+func TestBuildModifyCopyrightTokenFlagTx(t *testing.T) {
+	args := map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1"}
+	runCases(t, setupPublished, "BuildModifyCopyrightTokenFlagTx", []testCase{
+		{"freeze", args, ""},
+		{"unfreeze", with(args, map[string]string{"flag": "0"}), ""},
+		{"missing flag", with(args, map[string]string{"flag": "-"}), "missing required param"},
+		{"flag not int", with(args, map[string]string{"flag": "x"}), "flag must be int"},
+		{"unknown token", with(args, map[string]string{"tokenId": derivedId}), "token not found"},
+		{"not regulator", with(args, map[string]string{"account": "0xalice", "flag": "0"}), "is not a regulator"},
+	})
+
+	stub := setupPublished(t)
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", args))
+	detail, _ := getTokenDetail(stub, copyrightId)
+	if !detail.Frozen || detail.OwnerAccount != "0xalice" {
+		t.Fatalf("freeze lost token data: %+v", detail)
+	}
+}
+
+// setupInstitution 发行后由管理员 0xadmin 登记确权机构 CPCC(账户 0xauth, 0xauth2)
+func setupInstitution(t *testing.T) *mockStub {
+	stub := setupPublished(t)
+	mustOK(t, stub.init(map[string]string{"admins": `["0xadmin"]`, "regulators": `["0xreg"]`}))
+	mustOK(t, stub.invoke("registerInstitution", map[string]string{"account": "0xadmin",
+		"institution": `{"institutionId":"cpcc","name":"CPCC","accounts":["0xauth","0xauth2"]}`}))
+	return stub
+}
+
+func TestBuildModifyAuthenticationInfoTx(t *testing.T) {
+	args := map[string]string{
+		"account":            "0xauth",
+		"tokenId":            copyrightId,
+		"authenticationInfo": `{"institutionId":"cpcc","authenticationId":"A-1","authenticatedDate":"2024-02-01"}`,
+	}
+	runCases(t, setupInstitution, "buildModifyAuthenticationInfoTx", []testCase{
+		{"ok", args, ""},
+		{"missing info", with(args, map[string]string{"authenticationInfo": "-"}), "missing required param"},
+		{"bad json", with(args, map[string]string{"authenticationInfo": "{"}), "fail to parse authenticationInfo"},
+		{"unknown token", with(args, map[string]string{"tokenId": derivedId}), "token not found"},
+		{"missing id", with(args, map[string]string{"authenticationInfo": `{"institutionId":"cpcc"}`}), "'authenticationId'"},
+		{"unregistered", with(args, map[string]string{"authenticationInfo": `{"institutionId":"other","authenticationId":"A-1"}`}), "not registered"},
+		{"free text", with(args, map[string]string{"authenticationInfo": `{"authenticationInstitudeName":"CPCC","authenticationId":"A-1"}`}), "'institutionId'"},
+		{"not institution account", with(args, map[string]string{"account": "0xeve"}), "not an account of institution"},
+		{"bad action", with(args, map[string]string{"action": "replace"}), "action must be"},
+		{"correct unknown", with(args, map[string]string{"action": "correct"}), "no valid authenticationId"},
+	})
+
+	stub := setupInstitution(t)
+	mustOK(t, stub.invoke("buildModifyAuthenticationInfoTx", args))
+	expectResult(t, stub.invoke("buildModifyAuthenticationInfoTx", args), "already exists")
+	corrected := with(args, map[string]string{"action": "correct", "reason": "wrong date",
+		"authenticationInfo": `{"institutionId":"cpcc","authenticationId":"A-1","authenticatedDate":"2024-02-03"}`})
+	expectResult(t, stub.invoke("buildModifyAuthenticationInfoTx", with(corrected, map[string]string{"account": "0xreg"})), "not an account of institution")
+	mustOK(t, stub.invoke("buildModifyAuthenticationInfoTx", with(corrected, map[string]string{"account": "0xauth2"})))
+	revoke := with(args, map[string]string{"account": "0xother", "action": "revoke", "authenticationInfo": `{"institutionId":"cpcc","authenticationId":"A-1"}`})
+	expectResult(t, stub.invoke("buildModifyAuthenticationInfoTx", revoke), "not an account of institution")
+	mustOK(t, stub.invoke("buildModifyAuthenticationInfoTx", with(revoke, map[string]string{"account": "0xauth"})))
+	expectResult(t, stub.invoke("buildModifyAuthenticationInfoTx", with(revoke, map[string]string{"account": "0xauth"})), "no valid authenticationId")
+	// 已撤销的编号不可重新登记, 也不能借重新发行清除历史版本
+	expectResult(t, stub.invoke("buildModifyAuthenticationInfoTx", args), "already exists")
+	expectResult(t, stub.invoke("buildPublishTokenTx", publishArgs()), "already published")
+
+	detail, _ := getTokenDetail(stub, copyrightId)
+	infos := detail.AuthenticationInfos
+	if len(infos) != 2 || infos[0].Status != authSuperseded || infos[0].AuthenticatedDate != "2024-02-01" ||
+		infos[1].Version != 2 || infos[1].Status != authRevoked || infos[1].Issuer != "0xauth" ||
+		infos[1].UpdatedBy != "0xauth2" || infos[1].RevokedBy != "0xauth" || infos[1].AuthenticationInstitudeName != "CPCC" {
+		t.Fatalf("unexpected authentication history: %+v", infos)
+	}
+}
+
+func TestInstitutionRegistry(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	institution := func(status string) string {
+		return toJSON(map[string]interface{}{"institutionId": "ipc", "name": "IP Center", "publicKeys": []string{base64.StdEncoding.EncodeToString(der)}, "status": status})
+	}
+	admin := map[string]string{"account": "0xadmin", "institution": institution("")}
+	runCases(t, setupInstitution, "registerInstitution", []testCase{
+		{"ok", admin, ""},
+		{"not admin", with(admin, map[string]string{"account": "0xauth"}), "only admin"},
+		{"duplicate name", with(admin, map[string]string{"institution": `{"institutionId":"x","name":"CPCC","accounts":["0xa"]}`}), "name already registered"},
+		{"bad key", with(admin, map[string]string{"institution": `{"institutionId":"x","name":"X","publicKeys":["abc"]}`}), "invalid public key"},
+		{"bad status", with(admin, map[string]string{"institution": institution("closed")}), "status must be"},
+	})
+
+	stub := setupInstitution(t)
+	mustOK(t, stub.invoke("registerInstitution", admin))
+	info := &AuthenticationInfo{InstitutionId: "ipc", AuthenticationId: "S-1", AuthenticatedDate: "2024-03-01"}
+	nonce, expiresAt := 0, "2025-01-31"
+	sign := func(action, tokenId string) string {
+		nonce++
+		info.Nonce, info.SignatureExpiresAt = "n-"+strconv.Itoa(nonce), expiresAt
+		info.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, authSigningMessage(action, tokenId, info)))
+		return toJSON(info)
+	}
+	// 机构签名: 任何账户均可代为提交, 签名须覆盖 action 及 tokenId
+	modify := map[string]string{"account": "0xagent", "tokenId": copyrightId}
+	expectResult(t, stub.invoke("buildModifyAuthenticationInfoTx", with(modify, map[string]string{"authenticationInfo": sign("correct", copyrightId)})), "signature does not verify")
+	mustOK(t, stub.invoke("buildModifyAuthenticationInfoTx", with(modify, map[string]string{"authenticationInfo": sign("add", copyrightId)})))
+
+	// 签名不可重放: 先后两次更正后, 重放第一次更正的签名不能回滚确权信息; 过期签名被拒绝
+	correct := with(modify, map[string]string{"action": "correct"})
+	info.AuthenticatedDate = "2024-03-02"
+	first := sign("correct", copyrightId)
+	mustOK(t, stub.invoke("buildModifyAuthenticationInfoTx", with(correct, map[string]string{"authenticationInfo": first})))
+	info.AuthenticatedDate = "2024-03-03"
+	mustOK(t, stub.invoke("buildModifyAuthenticationInfoTx", with(correct, map[string]string{"authenticationInfo": sign("correct", copyrightId)})))
+	if ce := decodeError(t, stub.invoke("buildModifyAuthenticationInfoTx", with(correct, map[string]string{"authenticationInfo": first}))); ce.Code != ErrAlreadyExists {
+		t.Fatalf("expected ALREADY_EXISTS for replayed nonce, got %+v", ce)
+	}
+	expiresAt = "2024-12-31"
+	if ce := decodeError(t, stub.invoke("buildModifyAuthenticationInfoTx", with(correct, map[string]string{"authenticationInfo": sign("correct", copyrightId)}))); ce.Code != ErrNotActive {
+		t.Fatalf("expected NOT_ACTIVE for expired signature, got %+v", ce)
+	}
+	expiresAt = "2025-01-31"
+
+	// 发行时的确权信息同样校验, 机构暂停后不再接受
+	publish := func(infos ...AuthenticationInfo) map[string]string {
+		return with(publishArgs(), map[string]string{"tokenObject": tokenObject(func(m map[string]interface{}) {
+			m["tokenId"] = derivedId
+			m["authenticationInfos"] = infos
+		})})
+	}
+	expectResult(t, stub.invoke("buildPublishTokenTx", publish(AuthenticationInfo{InstitutionId: "cpcc", AuthenticationId: "P-1"})), "not an account of institution")
+	info.AuthenticationId = "P-2"
+	sign("add", derivedId)
+	expectResult(t, stub.invoke("buildPublishTokenTx", publish(*info, *info)), "duplicate authenticationId")
+	mustOK(t, stub.invoke("registerInstitution", with(admin, map[string]string{"institution": institution("suspended")})))
+	if ce := decodeError(t, stub.invoke("buildPublishTokenTx", publish(*info))); ce.Code != ErrNotActive {
+		t.Fatalf("expected NOT_ACTIVE, got %+v", ce)
+	}
+	mustOK(t, stub.invoke("registerInstitution", with(admin, map[string]string{"institution": institution("active")})))
+	mustOK(t, stub.invoke("buildPublishTokenTx", publish(*info)))
+	detail, _ := getTokenDetail(stub, derivedId)
+	if len(detail.AuthenticationInfos) != 1 || detail.AuthenticationInfos[0].AuthenticationInstitudeName != "IP Center" || detail.AuthenticationInfos[0].Issuer != "0xissuer" {
+		t.Fatalf("unexpected authentication infos: %+v", detail.AuthenticationInfos)
+	}
+
+	var institutions []AuthInstitution
+	if err := json.Unmarshal(stub.invoke("requestInstitutions", nil).Payload, &institutions); err != nil || len(institutions) != 2 || institutions[1].RecordVersion != 3 {
+		t.Fatalf("unexpected institutions: %+v, %v", institutions, err)
+	}
+}
+
+// signCredential 按 eddsa-jcs-2022 为凭证附加 Data Integrity 证明
+func signCredential(priv ed25519.PrivateKey, verificationMethod string, vc map[string]interface{}) string {
+	proof := map[string]interface{}{"type": "DataIntegrityProof", "cryptosuite": "eddsa-jcs-2022",
+		"verificationMethod": verificationMethod, "proofPurpose": "assertionMethod", "created": "2024-03-01T00:00:00Z",
+		"@context": vc["@context"]}
+	document, _ := canonicalJSON(vc)
+	config, _ := canonicalJSON(proof)
+	configHash, documentHash := sha256.Sum256(config), sha256.Sum256(document)
+	raw := ed25519.Sign(priv, append(configHash[:], documentHash[:]...))
+	var encoded []byte
+	for mod, n := new(big.Int), new(big.Int).SetBytes(raw); n.Sign() > 0; {
+		n.DivMod(n, big.NewInt(58), mod)
+		encoded = append([]byte{base58Alphabet[mod.Int64()]}, encoded...)
+	}
+	for i := 0; i < len(raw) && raw[i] == 0; i++ {
+		encoded = append([]byte{'1'}, encoded...)
+	}
+	delete(proof, "@context")
+	proof["proofValue"] = "z" + string(encoded)
+	signed := map[string]interface{}{"proof": proof}
+	for k, v := range vc {
+		signed[k] = v
+	}
+	return toJSON(signed)
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	// RFC 8785 第 3.2.2、3.2.3 节及附录 B 的示例
+	for input, want := range map[string]string{
+		`{"b":1.0,"a":"x\u2028y</>","c":1E2}`:                                                                          "{\"a\":\"x\u2028y</>\",\"b\":1,\"c\":100}",
+		`{"s":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/"}`:                                                        `{"s":"€$\u000f\nA'B\"\\\\\"/"}`,
+		`[333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001, -0, 1e21, 1e-7, 123456789012345680000]`: `[333333333.3333333,1e+30,4.5,0.002,1e-27,0,1e+21,1e-7,123456789012345680000]`,
+		`{"\ufb33":1,"\ud83d\ude00":2,"\u20ac":3,"\u00f6":4,"\u0080":5,"1":6,"\r":7}`:                                  "{\"\\r\":7,\"1\":6,\"\u0080\":5,\"ö\":4,\"€\":3,\"😀\":2,\"\ufb33\":1}",
+	} {
+		var v interface{}
+		dec := json.NewDecoder(strings.NewReader(input))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("decode %s: %v", input, err)
+		}
+		if got, err := canonicalJSON(v); err != nil || string(got) != want {
+			t.Errorf("canonicalJSON(%s) = %s, %v, want %s", input, got, err, want)
+		}
+	}
+}
+
+func TestImportCredential(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	const keyId = "did:example:ipc#key-1"
+	setup := func(t *testing.T) *mockStub {
+		stub := setupInstitution(t)
+		mustOK(t, stub.invoke("registerInstitution", map[string]string{"account": "0xadmin", "institution": toJSON(map[string]interface{}{
+			"institutionId": "ipc", "name": "IP Center", "publicKeys": []string{base64.StdEncoding.EncodeToString(der)}, "issuers": []string{"did:example:ipc"},
+			"verificationMethods": map[string]string{keyId: base64.StdEncoding.EncodeToString(der)},
+		})}))
+		return stub
+	}
+	credential := func(mutate func(vc, subject map[string]interface{})) map[string]interface{} {
+		subject := map[string]interface{}{"id": "did:example:alice", "tokenId": copyrightId, "authenticationId": "VC-1",
+			"title": "《春》<初稿>\u2028", "share": 0.5, "pages": 1e21}
+		vc := map[string]interface{}{
+			"@context": []string{"https://www.w3.org/ns/credentials/v2"}, "type": []string{"VerifiableCredential", "CopyrightCredential"},
+			"id": "urn:uuid:vc-1", "issuer": "did:example:ipc", "validFrom": "2024-03-01T00:00:00Z", "credentialSubject": subject,
+		}
+		if mutate != nil {
+			mutate(vc, subject)
+		}
+		return vc
+	}
+	signed := signCredential(priv, keyId, credential(nil))
+	args := map[string]string{"account": "0xalice", "tokenId": copyrightId, "credential": signed}
+	tampered := strings.Replace(signed, "VC-1", "VC-2", 1)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	runCases(t, setup, "buildImportCredentialTx", []testCase{
+		{"ok", args, ""},
+		{"tampered", with(args, map[string]string{"credential": tampered}), "proof does not verify"},
+		{"other key", with(args, map[string]string{"credential": signCredential(otherKey, keyId, credential(nil))}), "proof does not verify"},
+		{"unregistered method", with(args, map[string]string{"credential": signCredential(priv, "did:example:ipc#key-2", credential(nil))}), "is not registered"},
+		{"unsigned", with(args, map[string]string{"credential": toJSON(credential(nil))}), "has no proof"},
+		{"unknown issuer", with(args, map[string]string{"credential": signCredential(priv, keyId, credential(func(vc, _ map[string]interface{}) {
+			vc["issuer"] = map[string]string{"id": "did:example:other"}
+		}))}), "not a registered institution"},
+		{"other token", with(args, map[string]string{"credential": signCredential(priv, keyId, credential(func(_, s map[string]interface{}) {
+			s["tokenId"] = derivedId
+		}))}), "is issued for tokenId"},
+		{"expired", with(args, map[string]string{"credential": signCredential(priv, keyId, credential(func(vc, _ map[string]interface{}) {
+			vc["validUntil"] = "2024-12-31T00:00:00Z"
+		}))}), "credential expired"},
+		{"revoke", with(args, map[string]string{"action": "revoke"}), "'add' or 'correct'"},
+		{"not a vc", with(args, map[string]string{"credential": `{"issuer":"did:example:ipc"}`}), "@context must include"},
+	})
+
+	stub := setup(t)
+	mustOK(t, stub.invoke("buildImportCredentialTx", args))
+	expectResult(t, stub.invoke("buildImportCredentialTx", args), "already exists")
+	corrected := signCredential(priv, keyId, credential(func(vc, s map[string]interface{}) {
+		vc["id"] = "urn:uuid:vc-2"
+		s["authenticatedDate"] = "2024-03-05"
+	}))
+	mustOK(t, stub.invoke("buildImportCredentialTx", with(args, map[string]string{"credential": corrected, "action": "correct"})))
+
+	detail, _ := getTokenDetail(stub, copyrightId)
+	infos := detail.AuthenticationInfos
+	hash := sha256.Sum256([]byte(corrected))
+	if len(infos) != 2 || infos[0].Status != authSuperseded || infos[0].AuthenticatedDate != "2024-03-01" ||
+		infos[1].Version != 2 || infos[1].AuthenticatedDate != "2024-03-05" || infos[1].InstitutionId != "ipc" ||
+		infos[1].AuthenticationInstitudeName != "IP Center" || infos[1].CredentialId != "urn:uuid:vc-2" || infos[1].CredentialHash != hex.EncodeToString(hash[:]) {
+		t.Fatalf("unexpected authentication infos: %+v", infos)
+	}
+}
+
+func TestBuildModifyCopyrightUnitTx(t *testing.T) {
+	args := map[string]string{"account": "0xbob", "tokenId": copyrightId, "address": "0xbob2"}
+	runCases(t, setupPublished, "buildModifyCopyrightUnitTx", []testCase{
+		{"ok", args, ""},
+		{"missing address", with(args, map[string]string{"address": "-"}), "missing required param"},
+		{"unknown token", with(args, map[string]string{"tokenId": derivedId}), "no token found"},
+		{"not a holder", with(args, map[string]string{"account": "0xnobody"}), "no matched old address"},
+	})
+}
+
+func TestBuildModifyConstraintTx(t *testing.T) {
+	constraint := func(signers []string, apprTime string) string {
+		return toJSON(map[string]interface{}{
+			"tokenId": copyrightId,
+			"constraint": map[string]interface{}{
+				"copyrightLimit":    1,
+				"apprConstraint":    map[string]interface{}{"channel": "web", "time": apprTime},
+				"licenseConstraint": map[string]interface{}{"type": "stream"},
+			},
+			"signers": signers,
+		})
+	}
+	all := []string{"0xalice", "0xbob"}
+	runCases(t, setupPublished, "buildModifyConstraintTx", []testCase{
+		{"ok", map[string]string{"tokenId": copyrightId, "constraint": constraint(all, "2025-01-01/2025-12-31")}, ""},
+		{"missing constraint", map[string]string{"tokenId": copyrightId}, "missing required param"},
+		{"bad json", map[string]string{"tokenId": copyrightId, "constraint": "{"}, "fail to parse constraint"},
+		{"bad window", map[string]string{"tokenId": copyrightId, "constraint": constraint(all, "2025")}, "apprConstraint.time"},
+		{"unknown token", map[string]string{"tokenId": derivedId, "constraint": constraint(all, "")}, "no token found"},
+		{"missing signer", map[string]string{"tokenId": copyrightId, "constraint": constraint([]string{"0xalice"}, "")}, "missing address: 0xbob"},
+	})
+}
+
+func TestBuildTokenChangeTx(t *testing.T) {
+	args := map[string]string{"account": "0xalice", "tokenId": copyrightId, "flags": "1", "tokenInfos": `[{"type":"k","data":"v"}]`}
+	runCases(t, setupPublished, "buildTokenChangeTx", []testCase{
+		{"ok", args, ""},
+		{"missing tokenId", with(args, map[string]string{"tokenId": "-"}), "missing required param"},
+		{"unknown token", with(args, map[string]string{"tokenId": derivedId}), "no token found"},
+		{"bad flags", with(args, map[string]string{"flags": "x"}), "invalid flags param"},
+		{"bad tokenInfos", with(args, map[string]string{"tokenInfos": "{"}), "fail to parse tokenInfos"},
+		{"not owner", with(args, map[string]string{"account": "0xdave"}), "only the token owner"},
+		{"initiate", with(args, map[string]string{"flags": "-", "to": "0xdave"}), ""},
+		{"flags with to", with(args, map[string]string{"to": "0xdave"}), "flags and to can not be given in the same call"},
+		{"to co-owner", with(args, map[string]string{"flags": "-", "to": "0xbob"}), "already holds a copyright unit"},
+		{"past expiry", with(args, map[string]string{"flags": "-", "to": "0xdave", "expiresAt": "2024-12-01"}), "in the past"},
+	})
+
+	// 两步变更: 发起后持有者不变, 受让方接受后才变更持有者及持有索引
+	stub := setupPublished(t)
+	initiate := map[string]string{"account": "0xalice", "tokenId": copyrightId, "to": "0xdave"}
+	mustOK(t, stub.invoke("buildTokenChangeTx", initiate))
+	detail, _ := getTokenDetail(stub, copyrightId)
+	if detail.OwnerAccount != "0xalice" {
+		t.Fatalf("owner changed before acceptance: %s", detail.OwnerAccount)
+	}
+	expectResult(t, stub.invoke("buildTokenChangeTx", with(initiate, map[string]string{"to": "0xeve"})), "has a pending change to 0xdave")
+	expectResult(t, stub.invoke("acceptTokenChange", map[string]string{"account": "0xeve", "tokenId": copyrightId}), "only the recipient")
+	mustOK(t, stub.invoke("acceptTokenChange", map[string]string{"account": "0xdave", "tokenId": copyrightId}))
+	detail, _ = getTokenDetail(stub, copyrightId)
+	if detail.OwnerAccount != "0xdave" {
+		t.Fatalf("ownership not transferred: %+v", detail)
+	}
+	for owner, want := range map[string]string{"0xalice": "0", "0xdave": "1"} {
+		if resp := stub.invoke("balanceOf", map[string]string{"owner": owner}); string(resp.Payload) != want {
+			t.Fatalf("balanceOf %s = %s, want %s", owner, resp.Payload, want)
+		}
+	}
+	expectResult(t, stub.invoke("acceptTokenChange", map[string]string{"account": "0xdave", "tokenId": copyrightId}), "no pending token change")
+
+	// 超期未接受: 不可接受, 可重新发起; 受让方可拒绝
+	stub = setupPublished(t)
+	mustOK(t, stub.invoke("buildTokenChangeTx", with(initiate, map[string]string{"expiresAt": "2025-01-05"})))
+	stub.timestamp += 10 * 86400
+	expectResult(t, stub.invoke("acceptTokenChange", map[string]string{"account": "0xdave", "tokenId": copyrightId}), "no pending token change")
+	resp := stub.invoke("requestTokenChange", map[string]string{"tokenId": copyrightId})
+	var change TokenChange
+	if err := json.Unmarshal(resp.Payload, &change); err != nil || change.Status != "expired" {
+		t.Fatalf("unexpected token change: %s", resp.Payload)
+	}
+	mustOK(t, stub.invoke("buildTokenChangeTx", with(initiate, map[string]string{"to": "0xeve"})))
+	mustOK(t, stub.invoke("cancelTokenChange", map[string]string{"account": "0xeve", "tokenId": copyrightId}))
+	expectResult(t, stub.invoke("acceptTokenChange", map[string]string{"account": "0xeve", "tokenId": copyrightId}), "no pending token change")
+
+	// 发起后被冻结: 接受时拒绝
+	stub = setupPublished(t)
+	mustOK(t, stub.invoke("buildTokenChangeTx", initiate))
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1"}))
+	if ce := decodeError(t, stub.invoke("acceptTokenChange", map[string]string{"account": "0xdave", "tokenId": copyrightId})); ce.Code != ErrFrozen {
+		t.Fatalf("expected FROZEN, got %+v", ce)
+	}
+
+	// 冻结的通证不能在同一调用中先解冻再发起变更, 持有者也不能单独解冻后再发起
+	stub = setupPublished(t)
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1"}))
+	if ce := decodeError(t, stub.invoke("buildTokenChangeTx", with(initiate, map[string]string{"flags": "0"}))); ce.Code != ErrInvalidParam {
+		t.Fatalf("expected INVALID_PARAM, got %+v", ce)
+	}
+	unfreeze := map[string]string{"account": "0xalice", "tokenId": copyrightId, "flags": "0"}
+	if ce := decodeError(t, stub.invoke("buildTokenChangeTx", unfreeze)); ce.Code != ErrFrozen || !strings.Contains(ce.Message, "only a regulator can unfreeze") {
+		t.Fatalf("expected FROZEN, got %+v", ce)
+	}
+	if ce := decodeError(t, stub.invoke("buildTokenChangeTx", initiate)); ce.Code != ErrFrozen {
+		t.Fatalf("expected FROZEN, got %+v", ce)
+	}
+	if detail, _ = getTokenDetail(stub, copyrightId); !detail.Frozen {
+		t.Fatalf("token unfrozen by rejected change: %+v", detail)
+	}
+}
+
+func TestBuildTransferProportionTx(t *testing.T) {
+	args := map[string]string{
+		"account":        "0xbob",
+		"tokenId":        copyrightId,
+		"copyrightUnits": `[{"address":"0xeve","proportion":"0.3"},{"address":"0xfrank","proportion":"0.1"}]`,
+	}
+	runCases(t, setupPublished, "buildTransferProportionTx", []testCase{
+		{"ok", args, ""},
+		{"missing units", with(args, map[string]string{"copyrightUnits": "-"}), "missing required param"},
+		{"bad json", with(args, map[string]string{"copyrightUnits": "{"}), "fail to parse 'copyrightUnits'"},
+		{"unknown token", with(args, map[string]string{"tokenId": derivedId}), "no token found"},
+		{"not a holder", with(args, map[string]string{"account": "0xnobody"}), "does not hold any proportion"},
+		{"bad proportion", with(args, map[string]string{"copyrightUnits": `[{"address":"0xeve","proportion":"abc"}]`}), "new proportion invalid"},
+		{"sum mismatch", with(args, map[string]string{"copyrightUnits": `[{"address":"0xeve","proportion":"0.3"}]`}), "sum of new proportions"},
+	})
+}
+
+func TestERC721(t *testing.T) {
+	args := map[string]string{"account": "0xalice", "from": "0xalice", "to": "0xcarol", "tokenId": copyrightId}
+	runCases(t, setupPublished, "transferFrom", []testCase{
+		{"ok", args, ""},
+		{"missing to", with(args, map[string]string{"to": "-"}), "missing required param"},
+		{"unknown token", with(args, map[string]string{"tokenId": derivedId}), "no token found"},
+		{"wrong from", with(args, map[string]string{"from": "0xbob"}), "not the token owner"},
+		{"not approved", with(args, map[string]string{"account": "0xeve"}), "not owner nor approved"},
+		{"to co-owner", with(args, map[string]string{"to": "0xbob"}), "already holds a copyright unit"},
+		{"stale version", with(args, map[string]string{"expectedVersion": "9"}), "expectedVersion=9"},
+	})
+	runCases(t, setupPublished, "approve", []testCase{
+		{"ok", map[string]string{"account": "0xalice", "to": "0xcarol", "tokenId": copyrightId}, ""},
+		{"not owner", map[string]string{"account": "0xbob", "to": "0xcarol", "tokenId": copyrightId}, "not owner nor approved for all"},
+		{"to owner", map[string]string{"account": "0xalice", "to": "0xalice", "tokenId": copyrightId}, "approval to current owner"},
+	})
+
+	stub := setupPublished(t)
+	expectQuery := func(method string, args map[string]string, want string) {
+		t.Helper()
+		resp := stub.invoke(method, args)
+		mustOK(t, resp)
+		if string(resp.Payload) != want {
+			t.Fatalf("%s: expected %q, got %q", method, want, resp.Payload)
+		}
+	}
+	expectQuery("ownerOf", map[string]string{"tokenId": copyrightId}, "0xalice")
+	expectQuery("balanceOf", map[string]string{"owner": "0xalice"}, "1")
+	resp := stub.invoke("tokenURI", map[string]string{"tokenId": copyrightId})
+	mustOK(t, resp)
+	if !strings.HasPrefix(string(resp.Payload), "data:application/json;base64,") {
+		t.Fatalf("unexpected tokenURI: %s", resp.Payload)
+	}
+
+	// 单个通证授权: 被授权账户可转移, 转移后授权清除
+	mustOK(t, stub.invoke("approve", map[string]string{"account": "0xalice", "to": "0xcarol", "tokenId": copyrightId}))
+	expectQuery("getApproved", map[string]string{"tokenId": copyrightId}, "0xcarol")
+	mustOK(t, stub.invoke("transferFrom", with(args, map[string]string{"account": "0xcarol", "to": "0xdave"})))
+	expectQuery("ownerOf", map[string]string{"tokenId": copyrightId}, "0xdave")
+	expectQuery("getApproved", map[string]string{"tokenId": copyrightId}, "")
+	expectQuery("balanceOf", map[string]string{"owner": "0xalice"}, "0")
+	expectQuery("balanceOf", map[string]string{"owner": "0xdave"}, "1")
+	detail, _ := getTokenDetail(stub, copyrightId)
+	if detail.CopyrightUnits[0].Address != "0xdave" || detail.CopyrightUnits[1].Address != "0xbob" {
+		t.Fatalf("owner's copyright unit not transferred: %+v", detail.CopyrightUnits)
+	}
+	// transferFrom 是 ERC-721 接口, 持有者或其授权账户发起即立即转移, 不经两步变更
+	expectResult(t, stub.invoke("requestTokenChange", map[string]string{"tokenId": copyrightId}), "no token change found")
+
+	// 操作员可转移持有者的全部通证; 冻结及不可流通的通证拒绝转移
+	mustOK(t, stub.invoke("setApprovalForAll", map[string]string{"account": "0xdave", "operator": "0xop", "approved": "true"}))
+	expectQuery("isApprovedForAll", map[string]string{"owner": "0xdave", "operator": "0xop"}, "true")
+	byOperator := map[string]string{"account": "0xop", "from": "0xdave", "to": "0xalice", "tokenId": copyrightId}
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1"}))
+	if ce := decodeError(t, stub.invoke("transferFrom", byOperator)); ce.Code != ErrFrozen {
+		t.Fatalf("expected FROZEN, got %+v", ce)
+	}
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "0"}))
+	detail, _ = getTokenDetail(stub, copyrightId)
+	detail.CirculationFlag = 1
+	putTokenDetail(stub, detail)
+	if ce := decodeError(t, stub.invoke("transferFrom", byOperator)); ce.Code != ErrNotCirculating {
+		t.Fatalf("expected NOT_CIRCULATING, got %+v", ce)
+	}
+	detail.CirculationFlag = 0
+	putTokenDetail(stub, detail)
+
+	// safeTransferFrom: 接收合约未确认时失败
+	stub.contracts = map[string]func(args map[string][]byte) protogo.Response{
+		"reject": func(args map[string][]byte) protogo.Response { return shim.Success([]byte("no")) },
+	}
+	expectResult(t, stub.invoke("safeTransferFrom", with(byOperator, map[string]string{"receiverContract": "reject"})), "did not accept")
+	stub = setupPublished(t)
+	stub.contracts = map[string]func(args map[string][]byte) protogo.Response{
+		"vault": func(args map[string][]byte) protogo.Response {
+			if string(args["tokenId"]) != copyrightId || string(args["data"]) != "memo" {
+				return shim.Error("unexpected args")
+			}
+			return shim.Success([]byte("onTokenReceived"))
+		},
+	}
+	mustOK(t, stub.invoke("safeTransferFrom", with(args, map[string]string{"receiverContract": "vault", "data": "memo"})))
+	expectQuery("ownerOf", map[string]string{"tokenId": copyrightId}, "0xcarol")
+
+	// 配置 tokenBaseURI 后 tokenURI 为 前缀+tokenId
+	mustOK(t, stub.init(map[string]string{"config": `{"tokenBaseURI":"https://meta.example/"}`}))
+	expectQuery("tokenURI", map[string]string{"tokenId": copyrightId}, "https://meta.example/"+copyrightId)
+}
+
+func TestSaleOffer(t *testing.T) {
+	setupMarket := func(t *testing.T) *mockStub {
+		stub := setupPublished(t)
+		mustOK(t, stub.init(map[string]string{"config": `{"paymentAttestors":["0xpay"]}`}))
+		return stub
+	}
+	shareAsk := map[string]string{"account": "0xbob", "side": "ask", "kind": "share", "tokenId": copyrightId,
+		"proportion": "0.1", "price": "100", "expiresAt": "2025-03-01"}
+	runCases(t, setupMarket, "createOffer", []testCase{
+		{"share ask", shareAsk, ""},
+		{"token ask", map[string]string{"account": "0xalice", "side": "ask", "kind": "token", "tokenId": copyrightId, "price": "500", "expiresAt": "2025-03-01"}, ""},
+		{"bad side", with(shareAsk, map[string]string{"side": "swap"}), "side must be"},
+		{"bad price", with(shareAsk, map[string]string{"price": "-1"}), "positive number"},
+		{"too much", with(shareAsk, map[string]string{"proportion": "0.5"}), "proportion must be in (0, 0.4]"},
+		{"owner whole share", with(shareAsk, map[string]string{"account": "0xalice", "proportion": "0.6"}), "use kind=token"},
+		{"not owner", with(shareAsk, map[string]string{"kind": "token"}), "not the token owner"},
+		{"past expiry", with(shareAsk, map[string]string{"expiresAt": "2024-12-31"}), "in the past"},
+		{"no contract", with(shareAsk, map[string]string{"payment": "contract"}), "paymentContract is not configured"},
+	})
+
+	// 份额挂单: 托管期间不可另行转让, 买方接受后由付款见证账户登记付款成交
+	stub := setupMarket(t)
+	offerId := decodeTxResult(t, stub.invoke("createOffer", shareAsk)).Id
+	transferArgs := map[string]string{"account": "0xbob", "tokenId": copyrightId, "copyrightUnits": `[{"address":"0xeve","proportion":"0.4"}]`}
+	if ce := decodeError(t, stub.invoke("buildTransferProportionTx", transferArgs)); ce.Code != ErrInEscrow {
+		t.Fatalf("expected IN_ESCROW, got %+v", ce)
+	}
+	expectResult(t, stub.invoke("acceptOffer", map[string]string{"account": "0xbob", "offerId": offerId}), "seller can not accept")
+	mustOK(t, stub.invoke("acceptOffer", map[string]string{"account": "0xcarol", "offerId": offerId}))
+	expectResult(t, stub.invoke("cancelOffer", map[string]string{"account": "0xbob", "offerId": offerId}), "can not cancel the offer now")
+	expectResult(t, stub.invoke("attestPayment", map[string]string{"account": "0xeve", "offerId": offerId, "paymentRef": "bank-1"}), "not a payment attestor")
+	mustOK(t, stub.invoke("attestPayment", map[string]string{"account": "0xpay", "offerId": offerId, "paymentRef": "bank-1"}))
+	detail, _ := getTokenDetail(stub, copyrightId)
+	if toJSON(detail.CopyrightUnits) != `[{"address":"0xalice","proportion":"0.6","copyrightExplain":""},{"address":"0xbob","proportion":"0.3","copyrightExplain":""},{"address":"0xcarol","proportion":"0.1","copyrightExplain":""}]` {
+		t.Fatalf("unexpected units after settlement: %s", toJSON(detail.CopyrightUnits))
+	}
+	mustOK(t, stub.invoke("buildTransferProportionTx", with(transferArgs, map[string]string{"copyrightUnits": `[{"address":"0xeve","proportion":"0.3"}]`})))
+
+	// 出价: 创建者撤销; 过期后不可接受
+	bid := map[string]string{"account": "0xdave", "side": "bid", "kind": "token", "tokenId": copyrightId, "price": "800", "expiresAt": "2025-01-10"}
+	bidId := decodeTxResult(t, stub.invoke("createOffer", bid)).Id
+	expectResult(t, stub.invoke("cancelOffer", map[string]string{"account": "0xalice", "offerId": bidId}), "can not cancel")
+	mustOK(t, stub.invoke("cancelOffer", map[string]string{"account": "0xdave", "offerId": bidId}))
+	bidId = decodeTxResult(t, stub.invoke("createOffer", bid)).Id
+	stub.timestamp += 30 * 86400
+	expectResult(t, stub.invoke("acceptOffer", map[string]string{"account": "0xalice", "offerId": bidId}), "offer is not open")
+	resp := stub.invoke("requestOffers", map[string]string{"tokenId": copyrightId, "status": "expired"})
+	mustOK(t, resp)
+	var offers []SaleOffer
+	if err := json.Unmarshal(resp.Payload, &offers); err != nil || len(offers) != 1 || offers[0].OfferId != bidId {
+		t.Fatalf("unexpected expired offers: %s", resp.Payload)
+	}
+
+	// 资产合约付款: 接受即成交, 付款失败则整笔交易失败
+	stub = setupPublished(t)
+	mustOK(t, stub.init(map[string]string{"config": `{"paymentContract":"coin"}`}))
+	stub.contracts = map[string]func(args map[string][]byte) protogo.Response{
+		"coin": func(args map[string][]byte) protogo.Response {
+			if string(args["from"]) == "0xpoor" {
+				return shim.Error("insufficient balance")
+			}
+			return shim.Success(nil)
+		},
+	}
+	ask := map[string]string{"account": "0xalice", "side": "ask", "kind": "token", "tokenId": copyrightId, "price": "500", "expiresAt": "2025-03-01"}
+	offerId = decodeTxResult(t, stub.invoke("createOffer", ask)).Id
+	if ce := decodeError(t, stub.invoke("acceptOffer", map[string]string{"account": "0xpoor", "offerId": offerId})); ce.Code != ErrPaymentFailed {
+		t.Fatalf("expected PAYMENT_FAILED, got %+v", ce)
+	}
+	stub = setupPublished(t)
+	mustOK(t, stub.init(map[string]string{"config": `{"paymentContract":"coin"}`}))
+	stub.contracts = map[string]func(args map[string][]byte) protogo.Response{
+		"coin": func(args map[string][]byte) protogo.Response { return shim.Success(nil) },
+	}
+	// 双方须以本人账户签名: 第三方不能代卖方接受出价而划走买方资金, 也不能代买方出价
+	bid = map[string]string{"account": "0xdave", "side": "bid", "kind": "token", "tokenId": copyrightId, "price": "800", "expiresAt": "2025-03-01"}
+	expectResult(t, stub.invokeAs("0xeve", "createOffer", bid), "0xeve has no operator grant from 0xdave")
+	bidId = decodeTxResult(t, stub.invoke("createOffer", bid)).Id
+	expectResult(t, stub.invokeAs("0xeve", "acceptOffer", map[string]string{"account": "0xalice", "offerId": bidId}), "0xeve has no operator grant from 0xalice")
+	mustOK(t, stub.invoke("cancelOffer", map[string]string{"account": "0xdave", "offerId": bidId}))
+	offerId = decodeTxResult(t, stub.invoke("createOffer", ask)).Id
+	result := decodeTxResult(t, stub.invoke("acceptOffer", map[string]string{"account": "0xdave", "offerId": offerId}))
+	var offer SaleOffer
+	if err := json.Unmarshal(result.Record, &offer); err != nil || offer.Status != "settled" || offer.Buyer != "0xdave" {
+		t.Fatalf("unexpected offer: %s", result.Record)
+	}
+	detail, _ = getTokenDetail(stub, copyrightId)
+	if detail.OwnerAccount != "0xdave" {
+		t.Fatalf("ownership not transferred: %+v", detail)
+	}
+}
+
+func TestPreemption(t *testing.T) {
+	setup := func(t *testing.T) *mockStub {
+		stub := setupPublished(t)
+		mustOK(t, stub.invoke("buildTransferProportionTx", map[string]string{"account": "0xbob", "tokenId": copyrightId,
+			"copyrightUnits": `[{"address":"0xcarol","proportion":"0.1"},{"address":"0xdave","proportion":"0.3"}]`}))
+		mustOK(t, stub.invoke("setPreemptionRule", map[string]string{"account": "0xalice", "tokenId": copyrightId, "noticeDays": "30"}))
+		mustOK(t, stub.init(map[string]string{"config": `{"paymentAttestors":["0xpay"]}`}))
+		return stub
+	}
+	held := func(t *testing.T, stub *mockStub, account string) string {
+		detail, _ := getTokenDetail(stub, copyrightId)
+		p, err := unitProportion(detail, account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return formatProportion(p)
+	}
+	runCases(t, setupPublished, "setPreemptionRule", []testCase{
+		{"owner", map[string]string{"account": "0xalice", "tokenId": copyrightId, "noticeDays": "30"}, ""},
+		{"not owner", map[string]string{"account": "0xbob", "tokenId": copyrightId, "noticeDays": "30"}, "only the token owner"},
+		{"bad days", map[string]string{"account": "0xalice", "tokenId": copyrightId, "noticeDays": "400"}, "noticeDays must be"},
+	})
+
+	// 转给现有权利主体不触发通知
+	stub := setup(t)
+	result := decodeTxResult(t, stub.invoke("buildTransferProportionTx", map[string]string{"account": "0xcarol", "tokenId": copyrightId,
+		"copyrightUnits": `[{"address":"0xdave","proportion":"0.1"}]`}))
+	if result.Id != copyrightId || held(t, stub, "0xcarol") != "-1" {
+		t.Fatalf("transfer between holders should complete at once: %s", toJSON(result))
+	}
+
+	// 两位权利主体主张: 按份额比例(0.6 : 0.1)生成挂单, 付款后受让
+	stub = setup(t)
+	transfer := map[string]string{"account": "0xdave", "tokenId": copyrightId, "terms": "delivery in 30 days", "price": "300",
+		"copyrightUnits": `[{"address":"0xeve","proportion":"0.3"}]`}
+	expectResult(t, stub.invoke("buildTransferProportionTx", with(transfer, map[string]string{"price": "-"})), "price is required")
+	expectResult(t, stub.invoke("buildTransferProportionTx", with(transfer, map[string]string{"price": "0"})), "price must be a positive number")
+	noticeId := decodeTxResult(t, stub.invoke("buildTransferProportionTx", transfer)).Id
+	if held(t, stub, "0xdave") != "0.3" || held(t, stub, "0xeve") != "-1" {
+		t.Fatal("transfer to outsider should wait for the notice period")
+	}
+	if ce := decodeError(t, stub.invoke("buildTransferProportionTx", transfer)); ce.Code != ErrInEscrow {
+		t.Fatalf("expected IN_ESCROW, got %+v", ce)
+	}
+	respond := func(account, decision string) map[string]string {
+		return map[string]string{"account": account, "noticeId": noticeId, "decision": decision}
+	}
+	expectResult(t, stub.invoke("respondPreemption", respond("0xeve", "claim")), "no pre-emption right")
+	expectResult(t, stub.invoke("respondPreemption", respond("0xalice", "buy")), "decision must be")
+	mustOK(t, stub.invoke("respondPreemption", respond("0xalice", "claim")))
+	expectResult(t, stub.invoke("respondPreemption", respond("0xalice", "waive")), "already responded")
+	expectResult(t, stub.invoke("settlePreemption", map[string]string{"noticeId": noticeId}), "notice period ends at")
+	mustOK(t, stub.invoke("respondPreemption", respond("0xcarol", "claim")))
+	result = decodeTxResult(t, stub.invoke("settlePreemption", map[string]string{"noticeId": noticeId}))
+	var notice PreemptionNotice
+	if err := json.Unmarshal(result.Record, &notice); err != nil || notice.Status != "preempted" || notice.Price != "300" || len(notice.Offers) != 2 {
+		t.Fatalf("unexpected notice: %s", result.Record)
+	}
+	expectResult(t, stub.invoke("settlePreemption", map[string]string{"noticeId": noticeId}), "already settled")
+	// 主张者付款前份额不转移, 转让方份额仍锁定且不能撤销挂单
+	if held(t, stub, "0xalice") != "0.6" || held(t, stub, "0xdave") != "0.3" || held(t, stub, "0xeve") != "-1" {
+		t.Fatal("claimed shares should move only after payment")
+	}
+	aliceOffer, _ := getSaleOffer(stub, notice.Offers[0])
+	carolOffer, _ := getSaleOffer(stub, notice.Offers[1])
+	if aliceOffer.Buyer != "0xalice" || aliceOffer.Proportion != "0.257142857" || aliceOffer.Price != "257.142857143" ||
+		carolOffer.Buyer != "0xcarol" || carolOffer.Proportion != "0.042857143" || carolOffer.Price != "42.857142857" || carolOffer.NoticeId != noticeId {
+		t.Fatalf("unexpected pre-emption offers: %+v %+v", aliceOffer, carolOffer)
+	}
+	if ce := decodeError(t, stub.invoke("buildTransferProportionTx", transfer)); ce.Code != ErrInEscrow {
+		t.Fatalf("expected IN_ESCROW while pre-emption offers are open, got %+v", ce)
+	}
+	expectResult(t, stub.invoke("cancelOffer", map[string]string{"account": "0xdave", "offerId": aliceOffer.OfferId}), "can not cancel")
+	expectResult(t, stub.invoke("acceptOffer", map[string]string{"account": "0xeve", "offerId": aliceOffer.OfferId}), "reserved for buyer")
+	mustOK(t, stub.invoke("acceptOffer", map[string]string{"account": "0xalice", "offerId": aliceOffer.OfferId}))
+	mustOK(t, stub.invoke("attestPayment", map[string]string{"account": "0xpay", "offerId": aliceOffer.OfferId, "paymentRef": "bank-1"}))
+	if held(t, stub, "0xalice") != "0.857142857" || held(t, stub, "0xdave") != "0.042857143" {
+		t.Fatal("claimant should receive the share after payment")
+	}
+	// 主张者放弃后解除锁定, 未付款部分留在转让方
+	mustOK(t, stub.invoke("cancelOffer", map[string]string{"account": "0xcarol", "offerId": carolOffer.OfferId}))
+	mustOK(t, stub.invoke("buildTransferProportionTx", map[string]string{"account": "0xdave", "tokenId": copyrightId,
+		"copyrightUnits": `[{"address":"0xcarol","proportion":"0.042857143"}]`}))
+
+	// 冻结的通证不能结算
+	stub = setup(t)
+	noticeId = decodeTxResult(t, stub.invoke("buildTransferProportionTx", transfer)).Id
+	stub.timestamp += 31 * 86400
+	mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1"}))
+	if ce := decodeError(t, stub.invoke("settlePreemption", map[string]string{"noticeId": noticeId})); ce.Code != ErrFrozen {
+		t.Fatalf("expected FROZEN, got %+v", ce)
+	}
+
+	// 通知期满无人主张: 完成原转让
+	stub = setup(t)
+	noticeId = decodeTxResult(t, stub.invoke("buildTransferProportionTx", transfer)).Id
+	stub.timestamp += 31 * 86400
+	expectResult(t, stub.invoke("respondPreemption", respond("0xalice", "claim")), "period has ended")
+	mustOK(t, stub.invoke("settlePreemption", map[string]string{"noticeId": noticeId}))
+	if held(t, stub, "0xeve") != "0.3" || held(t, stub, "0xdave") != "-1" {
+		t.Fatal("unclaimed transfer should complete after the notice period")
+	}
+	resp := stub.invoke("requestPreemptionNotices", map[string]string{"tokenId": copyrightId})
+	mustOK(t, resp)
+	var notices []PreemptionNotice
+	if err := json.Unmarshal(resp.Payload, &notices); err != nil || len(notices) != 1 || notices[0].Status != "completed" {
+		t.Fatalf("unexpected notices: %s", resp.Payload)
+	}
+
+	// 挂单出售份额只能面向现有权利主体
+	expectResult(t, stub.invoke("createOffer", map[string]string{"account": "0xcarol", "side": "ask", "kind": "share", "tokenId": copyrightId,
+		"proportion": "0.1", "price": "100", "buyer": "0xfrank", "payment": "attested", "expiresAt": "2025-06-01"}), "has a pre-emption rule")
+
+	// 持有权及份额不能绕过通知直接转给非权利主体
+	stub = setup(t)
+	const bypass = "transfer to non-holder 0xeve with buildTransferProportionTx"
+	expectResult(t, stub.invoke("transferFrom", map[string]string{"account": "0xalice", "from": "0xalice", "to": "0xeve", "tokenId": copyrightId}), bypass)
+	expectResult(t, stub.invoke("buildTokenChangeTx", map[string]string{"account": "0xalice", "tokenId": copyrightId, "to": "0xeve"}), bypass)
+	expectResult(t, stub.invoke("createOffer", map[string]string{"account": "0xeve", "side": "bid", "kind": "token", "tokenId": copyrightId,
+		"price": "800", "payment": "attested", "expiresAt": "2025-03-01"}), bypass)
+	askId := decodeTxResult(t, stub.invoke("createOffer", map[string]string{"account": "0xalice", "side": "ask", "kind": "token", "tokenId": copyrightId,
+		"price": "500", "payment": "attested", "expiresAt": "2025-03-01"})).Id
+	expectResult(t, stub.invoke("acceptOffer", map[string]string{"account": "0xeve", "offerId": askId}), bypass)
+	mustOK(t, stub.invoke("cancelOffer", map[string]string{"account": "0xalice", "offerId": askId}))
+	expectResult(t, stub.invoke("buildModifyCopyrightUnitTx", map[string]string{"account": "0xcarol", "tokenId": copyrightId, "address": "0xeve"}), bypass)
+
+	// 持有者不能单独取消规则: 全部权利主体同意后才生效
+	relax := func(account string) map[string]string {
+		return map[string]string{"account": account, "tokenId": copyrightId, "noticeDays": "0"}
+	}
+	expectResult(t, stub.invoke("setPreemptionRule", with(relax("0xcarol"), map[string]string{"noticeDays": "60"})), "only the token owner")
+	expectResult(t, stub.invoke("setPreemptionRule", relax("0xeve")), "only the token owner or a copyright unit holder")
+	mustOK(t, stub.invoke("setPreemptionRule", relax("0xalice")))
+	expectResult(t, stub.invoke("setPreemptionRule", relax("0xalice")), "already consented")
+	mustOK(t, stub.invoke("setPreemptionRule", relax("0xcarol")))
+	if days, _ := noticeDays(stub, copyrightId); days != 30 {
+		t.Fatalf("rule relaxed before every holder consented: %d", days)
+	}
+	expectResult(t, stub.invoke("transferFrom", map[string]string{"account": "0xalice", "from": "0xalice", "to": "0xeve", "tokenId": copyrightId}), bypass)
+	mustOK(t, stub.invoke("setPreemptionRule", relax("0xdave")))
+	if rule, _ := getPreemptionRule(stub, copyrightId); rule.NoticeDays != 0 || rule.Pending != nil {
+		t.Fatalf("rule not relaxed after every holder consented: %+v", rule)
+	}
+	mustOK(t, stub.invoke("transferFrom", map[string]string{"account": "0xalice", "from": "0xalice", "to": "0xeve", "tokenId": copyrightId}))
+}
+
+func TestDispute(t *testing.T) {
+	evidenceA, evidenceB := strings.Repeat("e", 64), strings.Repeat("f", 64)
+	setup := func(t *testing.T) *mockStub {
+		stub := setupPublished(t)
+		mustOK(t, stub.init(map[string]string{"regulators": `["0xreg"]`, "config": `{"arbitrators":["0xarb"]}`}))
+		for _, hash := range []string{evidenceA, evidenceB} {
+			mustOK(t, stub.invoke("saveFact", map[string]string{"fileHash": hash, "fileName": "contract.pdf", "time": "1700000000"}))
+		}
+		return stub
+	}
+	file := map[string]string{"account": "0xcarol", "tokenId": copyrightId, "claim": "co-author of the lyrics", "evidence": toJSON([]string{evidenceA})}
+	runCases(t, setup, "fileDispute", []testCase{
+		{"ok", file, ""},
+		{"unknown evidence", with(file, map[string]string{"evidence": toJSON([]string{derivedId})}), "no fact found"},
+		{"bad evidence", with(file, map[string]string{"evidence": `["x"]`}), "must be hash256"},
+		{"unknown token", with(file, map[string]string{"tokenId": derivedId}), "no token found"},
+	})
+
+	stub := setup(t)
+	disputeId := decodeTxResult(t, stub.invoke("fileDispute", file)).Id
+	evidence := map[string]string{"disputeId": disputeId, "evidence": toJSON([]string{evidenceB})}
+	expectResult(t, stub.invoke("addDisputeEvidence", with(evidence, map[string]string{"account": "0xeve"})), "only the claimant or a rights holder")
+	mustOK(t, stub.invoke("addDisputeEvidence", with(evidence, map[string]string{"account": "0xalice", "note": "signed contract"})))
+	expectResult(t, stub.invoke("acceptDispute", map[string]string{"account": "0xcarol", "disputeId": disputeId}), "neither a regulator nor an arbitrator")
+	expectResult(t, stub.invoke("resolveDispute", map[string]string{"account": "0xarb", "disputeId": disputeId, "ruling": "r"}), "must be accepted")
+	expectResult(t, stub.invokeAs("0xcarol", "acceptDispute", map[string]string{"account": "0xarb", "disputeId": disputeId}), "transaction sender 0xcarol is not 0xarb")
+	mustOK(t, stub.invoke("acceptDispute", map[string]string{"account": "0xarb", "disputeId": disputeId}))
+
+	// 争议状态: 不可转移、修改份额、挂单, 查询不受影响
+	blocked := []struct {
+		method string
+		args   map[string]string
+	}{
+		{"transferFrom", map[string]string{"account": "0xalice", "from": "0xalice", "to": "0xdave", "tokenId": copyrightId}},
+		{"buildTransferProportionTx", map[string]string{"account": "0xbob", "tokenId": copyrightId, "copyrightUnits": `[{"address":"0xeve","proportion":"0.4"}]`}},
+		{"buildModifyCopyrightUnitTx", map[string]string{"account": "0xbob", "tokenId": copyrightId, "address": "0xbob2"}},
+		{"buildTokenChangeTx", map[string]string{"account": "0xalice", "tokenId": copyrightId, "to": "0xdave"}},
+		{"createOffer", map[string]string{"account": "0xalice", "side": "ask", "kind": "token", "tokenId": copyrightId, "price": "1", "expiresAt": "2025-03-01"}},
+	}
+	for _, b := range blocked {
+		if ce := decodeError(t, stub.invoke(b.method, b.args)); ce.Code != ErrDisputed {
+			t.Fatalf("%s: expected DISPUTED, got %+v", b.method, ce)
+		}
+	}
+	if resp := stub.invoke("ownerOf", map[string]string{"tokenId": copyrightId}); string(resp.Payload) != "0xalice" {
+		t.Fatalf("queries should still work under dispute: %s", resp.Message)
+	}
+	// 重新发行不能清除争议状态或改写持有者
+	expectResult(t, stub.invoke("buildPublishTokenTx", with(publishArgs(), map[string]string{"receiver": "0xmallory"})), "already published")
+	if detail, _ := getTokenDetail(stub, copyrightId); detail.DisputeId != disputeId || detail.OwnerAccount != "0xalice" {
+		t.Fatalf("dispute cleared by re-publish: %+v", detail)
+	}
+
+	// 裁决重新分配份额及持有者, 解除争议状态
+	resolve := map[string]string{"account": "0xarb", "disputeId": disputeId, "ruling": "carol is a co-author",
+		"copyrightUnits": `[{"address":"0xalice","proportion":"0.5"},{"address":"0xbob","proportion":"0.3"},{"address":"0xcarol","proportion":"0.2"}]`}
+	expectResult(t, stub.invoke("resolveDispute", with(resolve, map[string]string{"copyrightUnits": `[{"address":"0xalice","proportion":"0.5"}]`})), "sum of ruled proportions")
+	expectResult(t, stub.invoke("resolveDispute", with(resolve, map[string]string{"owner": "0xdave"})), "must hold a copyright unit")
+	// 裁决份额移除当前持有者时须指定新的持有者
+	dropOwner := with(resolve, map[string]string{"copyrightUnits": `[{"address":"0xbob","proportion":"0.6"},{"address":"0xcarol","proportion":"0.4"}]`})
+	expectResult(t, stub.invoke("resolveDispute", dropOwner), "drop the current owner 0xalice")
+	expectResult(t, stub.invoke("resolveDispute", with(dropOwner, map[string]string{"owner": "0xalice"})), "drop the current owner 0xalice")
+	expectResult(t, stub.invoke("resolveDispute", with(resolve, map[string]string{"dismiss": "true"})), "can not reassign")
+	expectResult(t, stub.invokeAs("0xcarol", "resolveDispute", with(resolve, map[string]string{"owner": "0xcarol"})), "transaction sender")
+	result := decodeTxResult(t, stub.invoke("resolveDispute", with(resolve, map[string]string{"owner": "0xcarol"})))
+	var dispute Dispute
+	if err := json.Unmarshal(result.Record, &dispute); err != nil || dispute.Status != "resolved" || len(dispute.Steps) != 4 || len(dispute.Evidence) != 2 {
+		t.Fatalf("unexpected dispute: %s", result.Record)
+	}
+	detail, _ := getTokenDetail(stub, copyrightId)
+	if detail.DisputeId != "" || detail.OwnerAccount != "0xcarol" || len(detail.CopyrightUnits) != 3 {
+		t.Fatalf("ruling not applied: %+v", detail)
+	}
+	if resp := stub.invoke("ownerOf", map[string]string{"tokenId": copyrightId}); string(resp.Payload) != "0xcarol" {
+		t.Fatalf("unexpected owner: %s", resp.Payload)
+	}
+	for owner, want := range map[string]string{"0xalice": "0", "0xcarol": "1"} {
+		if resp := stub.invoke("balanceOf", map[string]string{"owner": owner}); string(resp.Payload) != want {
+			t.Fatalf("balanceOf %s = %s, want %s", owner, resp.Payload, want)
+		}
+	}
+	expectResult(t, stub.invoke("addDisputeEvidence", with(evidence, map[string]string{"account": "0xcarol"})), "already closed")
+	mustOK(t, stub.invoke("buildTransferProportionTx", map[string]string{"account": "0xbob", "tokenId": copyrightId, "copyrightUnits": `[{"address":"0xeve","proportion":"0.3"}]`}))
+
+	// 未受理的争议可直接驳回, 通证状态不变
+	stub = setup(t)
+	disputeId = decodeTxResult(t, stub.invoke("fileDispute", file)).Id
+	mustOK(t, stub.invoke("resolveDispute", map[string]string{"account": "0xreg", "disputeId": disputeId, "ruling": "no standing", "dismiss": "true"}))
+	resp := stub.invoke("requestDisputes", map[string]string{"tokenId": copyrightId})
+	var disputes []Dispute
+	if err := json.Unmarshal(resp.Payload, &disputes); err != nil || len(disputes) != 1 || disputes[0].Status != "dismissed" {
+		t.Fatalf("unexpected disputes: %s", resp.Payload)
+	}
+}
+
+func TestOperatorGrant(t *testing.T) {
+	grant := map[string]string{
+		"account":   "0xalice",
+		"operator":  "0xlabel",
+		"scope":     `{"methods":["buildPublishApproveTokenTx"],"tokenIds":["` + copyrightId + `"],"approveChannels":[1],"approveAreas":[0]}`,
+		"expiresAt": "2025-06-30",
+	}
+	runCases(t, setupPublished, "grantOperator", []testCase{
+		{"ok", grant, ""},
+		{"missing expiry", with(grant, map[string]string{"expiresAt": "-"}), "missing required param"},
+		{"past expiry", with(grant, map[string]string{"expiresAt": "2024-12-31"}), "in the past"},
+		{"self", with(grant, map[string]string{"operator": "0xalice"}), "grant to self"},
+		{"admin method", with(grant, map[string]string{"scope": `{"methods":["setConfig"]}`}), "can not be delegated"},
+		{"bad channel", with(grant, map[string]string{"scope": `{"approveChannels":[99]}`}), "approveChannel must be"},
+	})
+
+	stub := setupPublished(t)
+	mustOK(t, stub.invoke("grantOperator", grant))
+	asLabel := with(approveArgs(), map[string]string{"operatorAccount": "0xlabel"})
+	cases := []struct {
+		name    string
+		method  string
+		args    map[string]string
+		wantErr string
+	}{
+		{"other channel", "buildPublishApproveTokenTx", with(asLabel, map[string]string{
+			"approveConstraints": `[{"approveChannel":2,"approveArea":0,"approveTime":1,"approveStatus":1,"reapproveType":0}]`}), "approveChannel 2"},
+		{"other token", "buildPublishApproveTokenTx", with(asLabel, map[string]string{"referenceID": derivedId}), "does not cover the token"},
+		{"other method", "transferFrom", map[string]string{"account": "0xalice", "from": "0xalice", "to": "0xlabel", "tokenId": copyrightId, "operatorAccount": "0xlabel"}, "does not cover method"},
+		{"no grant", "buildPublishApproveTokenTx", with(asLabel, map[string]string{"operatorAccount": "0xeve"}), "no operator grant"},
+		{"not delegable", "saveFact", map[string]string{"fileHash": copyrightId, "fileName": "a", "time": "1", "operatorAccount": "0xlabel"}, "can not be called by operator"},
+		{"in scope", "buildPublishApproveTokenTx", asLabel, ""},
+		// 操作员以自己为发行者时不持有权利, 代理范围之外的渠道无从绕过
+		{"as own publisher", "buildPublishApproveTokenTx", with(approveArgs(), map[string]string{"publisher": "0xlabel", "tokenId": derivedId,
+			"approveConstraints": `[{"approveChannel":2,"approveArea":0,"approveTime":1,"approveStatus":1,"reapproveType":0}]`}), "publisher 0xlabel is neither the owner nor a copyright unit holder"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expectResult(t, stub.invoke(c.method, c.args), c.wantErr)
+		})
+	}
+	// 交易发送者不是委托人时, 未传入 operatorAccount 同样须持有代理权; 传入时须为发送者
+	expectResult(t, stub.invokeAs("0xeve", "buildPublishApproveTokenTx", with(approveArgs(), map[string]string{"tokenId": derivedId})), "0xeve has no operator grant from 0xalice")
+	expectResult(t, stub.invokeAs("0xeve", "buildPublishApproveTokenTx", with(asLabel, map[string]string{"tokenId": derivedId})), "operatorAccount 0xlabel is not the transaction sender 0xeve")
+	expectResult(t, stub.invokeAs("0xeve", "grantOperator", with(grant, map[string]string{"operator": "0xeve"})), "transaction sender 0xeve is not 0xalice")
+
+	// 审计记录同时记录委托人及操作员
+	resp := stub.invoke("requestAuditLog", nil)
+	mustOK(t, resp)
+	var page AuditPage
+	if err := json.Unmarshal(resp.Payload, &page); err != nil {
+		t.Fatal(err)
+	}
+	last := page.Entries[len(page.Entries)-1]
+	if last.Action != "buildPublishApproveTokenTx" || last.Account != "0xalice" || last.Operator != "0xlabel" || last.Target != approveId {
+		t.Fatalf("unexpected audit entry: %+v", last)
+	}
+	mustOK(t, stub.invokeAs("0xlabel", "buildPublishApproveTokenTx", with(approveArgs(), map[string]string{"tokenId": derivedId})))
+
+	// 过期及撤销后拒绝
+	stub.timestamp = 1751328000 // 2025-07-01
+	expectResult(t, stub.invoke("buildPublishApproveTokenTx", with(asLabel, map[string]string{"tokenId": derivedId})), "expired at 2025-06-30")
+	mustOK(t, stub.invoke("revokeOperator", map[string]string{"account": "0xalice", "operator": "0xlabel"}))
+	expectResult(t, stub.invoke("revokeOperator", map[string]string{"account": "0xalice", "operator": "0xlabel"}), "has no operator grant")
+	resp = stub.invoke("requestOperatorGrants", map[string]string{"account": "0xalice"})
+	mustOK(t, resp)
+	if string(resp.Payload) != "[]" {
+		t.Fatalf("expected no grants, got %s", resp.Payload)
+	}
+}
+
+func TestRequestWorkTokens(t *testing.T) {
+	stub := setupLicensed(t)
+	expectResult(t, stub.invoke("requestWorkTokens", nil), "missing required param")
+	expectResult(t, stub.invoke("requestWorkTokens", map[string]string{"workId": "nope"}), "no work found")
+
+	resp := stub.invoke("requestWorkTokens", map[string]string{"workId": "work-1"})
+	mustOK(t, resp)
+	var tree WorkTokenTree
+	if err := json.Unmarshal(resp.Payload, &tree); err != nil {
+		t.Fatalf("unmarshal tree: %v", err)
+	}
+	if len(tree.CopyrightTokens) != 1 || len(tree.CopyrightTokens[0].Approvals) != 1 {
+		t.Fatalf("unexpected tree: %s", resp.Payload)
+	}
+	approval := tree.CopyrightTokens[0].Approvals[0]
+	if !approval.Active || len(approval.Licenses) != 1 || approval.Licenses[0].License.TokenId != licenseId {
+		t.Fatalf("unexpected approval node: %+v", approval)
+	}
+}
+
+func TestDerivativeLineage(t *testing.T) {
+	derivedArgs := func(approveTokenId string, relation int) map[string]string {
+		return with(publishArgs(), map[string]string{
+			"receiver": "0xcarol",
+			"tokenObject": tokenObject(func(m map[string]interface{}) {
+				m["tokenId"] = derivedId
+				m["parentLinks"] = []map[string]interface{}{
+					{"tokenId": copyrightId, "relationType": relation, "approveTokenId": approveTokenId},
+				}
+			}),
+		})
+	}
+	setup := func(t *testing.T) *mockStub {
+		stub := setupPublished(t)
+		mustOK(t, stub.invoke("buildPublishApproveTokenTx", with(approveArgs(), map[string]string{"derivativeUses": "[1]"})))
+		return stub
+	}
+	runCases(t, setup, "buildPublishTokenTx", []testCase{
+		{"ok", derivedArgs(approveId, RelationTranslation), ""},
+		{"use not covered", derivedArgs(approveId, RelationAdaptation), "does not cover relationType 0"},
+		{"unknown approval", derivedArgs(licenseId, RelationTranslation), "approve token not found"},
+		{"relation out of range", derivedArgs(approveId, 9), "relationType out of range"},
+		{"wrong grantee", with(derivedArgs(approveId, RelationTranslation), map[string]string{"receiver": "0xeve"}), "is not granted to 0xeve"},
+	})
+
+	// 第三方以自己为发行者、自己为接收者的授权通证不能证明上级的许可
+	stub := setup(t)
+	forged, _ := getApproveToken(stub, approveId)
+	forged.TokenId, forged.Publisher = "forged-approval", "0xcarol"
+	stub.state["approve_token_forged-approval"] = []byte(toJSON(forged))
+	expectResult(t, stub.invoke("buildPublishTokenTx", derivedArgs("forged-approval", RelationTranslation)),
+		"is not issued by the owner or a copyright unit holder of parent token")
+
+	mustOK(t, stub.invoke("buildPublishTokenTx", derivedArgs(approveId, RelationTranslation)))
+	resp := stub.invoke("requestTokenLineage", map[string]string{"tokenId": copyrightId})
+	mustOK(t, resp)
+	var lineage TokenLineage
+	if err := json.Unmarshal(resp.Payload, &lineage); err != nil {
+		t.Fatalf("unmarshal lineage: %v", err)
+	}
+	if len(lineage.Descendants) != 1 || lineage.Descendants[0].ChildId != derivedId || len(lineage.Ancestors) != 0 {
+		t.Fatalf("unexpected lineage: %s", resp.Payload)
+	}
+	resp = stub.invoke("requestTokenLineage", map[string]string{"tokenId": derivedId})
+	mustOK(t, resp)
+	if err := json.Unmarshal(resp.Payload, &lineage); err != nil || len(lineage.Ancestors) != 1 {
+		t.Fatalf("unexpected lineage: %s", resp.Payload)
+	}
+	expectResult(t, stub.invoke("requestTokenLineage", map[string]string{"tokenId": copyrightId, "depth": "0"}), "depth must be integer")
+	expectResult(t, stub.invoke("requestTokenLineage", map[string]string{"tokenId": licenseId}), "no token found")
+}
+
+func TestBuildBurnTokenTx(t *testing.T) {
+	burnArgs := map[string]string{
+		"account":   "0xalice",
+		"tokenId":   copyrightId,
+		"tokenType": "1",
+		"signers":   `["0xbob"]`,
+		"cascade":   "true",
+	}
+	setup := func(t *testing.T) *mockStub {
+		stub := setupLicensed(t)
+		mustOK(t, stub.init(map[string]string{"regulators": `["0xreg"]`}))
+		return stub
+	}
+	runCases(t, setup, "buildBurnTokenTx", []testCase{
+		{"owner with co-owner consent", burnArgs, ""},
+		{"regulator", with(burnArgs, map[string]string{"account": "0xreg", "signers": "-"}), ""},
+		{"license by holder", map[string]string{"account": "0xdave", "tokenId": licenseId, "tokenType": "3"}, ""},
+		{"missing tokenType", with(burnArgs, map[string]string{"tokenType": "-"}), "missing required param"},
+		{"bad tokenType", with(burnArgs, map[string]string{"tokenType": "4"}), "tokenType must be"},
+		{"bad cascade", with(burnArgs, map[string]string{"cascade": "x"}), "cascade must be bool"},
+		{"bad signers", with(burnArgs, map[string]string{"signers": "x"}), "fail to parse 'signers'"},
+		{"unknown token", with(burnArgs, map[string]string{"tokenId": derivedId}), "no token found"},
+		{"not owner", with(burnArgs, map[string]string{"account": "0xbob"}), "neither the owner nor a regulator"},
+		{"missing co-owner", with(burnArgs, map[string]string{"signers": "-"}), "missing co-owner: 0xbob"},
+		{"dependents without cascade", with(burnArgs, map[string]string{"cascade": "-"}), "active dependent tokens"},
+	})
+
+	t.Run("cascade writes tombstones and counters", func(t *testing.T) {
+		stub := setup(t)
+		mustOK(t, stub.invoke("buildBurnTokenTx", burnArgs))
+		for _, id := range []string{copyrightId, approveId, licenseId} {
+			resp := stub.invoke("requestTombstone", map[string]string{"tokenId": id})
+			mustOK(t, resp)
+		}
+		if d, _ := getTokenDetail(stub, copyrightId); d != nil {
+			t.Fatalf("burned token still live")
+		}
+		issue, _ := getTokenIssue(stub, "MediaToken")
+		if issue.Burned != 3 {
+			t.Fatalf("burned counter = %d, want 3", issue.Burned)
+		}
+		expectResult(t, stub.invoke("buildPublishTokenTx", publishArgs()), "was burned")
+		expectResult(t, stub.invoke("requestTombstone", map[string]string{"tokenId": derivedId}), "no tombstone found")
+	})
+
+	t.Run("frozen token needs regulator", func(t *testing.T) {
+		stub := setup(t)
+		mustOK(t, stub.invoke("BuildModifyCopyrightTokenFlagTx", map[string]string{"account": "0xreg", "tokenId": copyrightId, "flag": "1"}))
+		expectResult(t, stub.invoke("buildBurnTokenTx", burnArgs), "token is frozen")
+	})
+}
+
+func TestRequestExpiringTokens(t *testing.T) {
+	stub := setupLicensed(t)
+	expectResult(t, stub.invoke("requestExpiringTokens", nil), "missing required param")
+	expectResult(t, stub.invoke("requestExpiringTokens", map[string]string{"days": "400"}), "days must be integer")
+	expectResult(t, stub.invoke("requestExpiringTokens", map[string]string{"days": "1", "tokenType": "9"}), "tokenType must be")
+
+	stub.timestamp = 1764547200 // 2025-12-01
+	resp := stub.invoke("requestExpiringTokens", map[string]string{"days": "30"})
+	mustOK(t, resp)
+	var expiring []ExpiringToken
+	if err := json.Unmarshal(resp.Payload, &expiring); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(expiring) != 1 || expiring[0].TokenId != licenseId || expiring[0].EndDate != "2025-12-31" {
+		t.Fatalf("unexpected expiring tokens: %s", resp.Payload)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	stub := newMockStub()
+
+	resp := stub.invoke("describe", nil)
+	mustOK(t, resp)
+	var doc struct {
+		Methods    map[string]json.RawMessage `json:"methods"`
+		ErrorCodes map[string]string          `json:"errorCodes"`
+	}
+	if err := json.Unmarshal(resp.Payload, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(doc.Methods) != len(contractMethods) || doc.ErrorCodes[ErrNotFound] == "" {
+		t.Fatalf("unexpected describe document: %d methods", len(doc.Methods))
+	}
+	// 目录中的方法均可分发
+	for _, spec := range contractMethods {
+		if resp := stub.invoke(spec.Name, nil); strings.Contains(resp.Message, "invalid method") {
+			t.Fatalf("method %s is listed but not dispatched", spec.Name)
+		}
+		for _, code := range spec.Errors {
+			if errorCodeMeanings[code] == "" {
+				t.Fatalf("method %s lists unknown error code %s", spec.Name, code)
+			}
+		}
+	}
+
+	resp = stub.invoke("describe", map[string]string{"methodName": "buildPublishApproveTokenTx"})
+	mustOK(t, resp)
+	if !strings.Contains(string(resp.Payload), `"referenceID"`) || !strings.Contains(string(resp.Payload), `"approveConstraints"`) {
+		t.Fatalf("unexpected method schema: %s", resp.Payload)
+	}
+	expectResult(t, stub.invoke("describe", map[string]string{"methodName": "noSuchMethod"}), "unknown method")
+}
+
+// TestSchemaArtifact 校验 schema/token_contract.schema.json 与 describe 输出一致
+// 修改方法目录后以 UPDATE_SCHEMA=1 go test -run TestSchemaArtifact 重新生成
+func TestSchemaArtifact(t *testing.T) {
+	const path = "schema/token_contract.schema.json"
+	want, err := json.MarshalIndent(contractSchema(), "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want = append(want, '\n')
+	if os.Getenv("UPDATE_SCHEMA") != "" {
+		if err := os.MkdirAll("schema", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, want, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("%s is out of date, regenerate with UPDATE_SCHEMA=1", path)
+	}
+}
+
+// TestLifecycleFlow 通证初始化 -> 发行 -> 授权 -> 许可 -> 签名 的完整流程
+func TestLifecycleFlow(t *testing.T) {
+	stub := newMockStub()
+	mustOK(t, stub.invoke("buildTokenIssueTx", issueArgs()))
+	mustOK(t, stub.invoke("buildRegisterWorkTx", workArgs()))
+	mustOK(t, stub.invoke("buildPublishTokenTx", publishArgs()))
+	mustOK(t, stub.invoke("buildPublishApproveTokenTx", approveArgs()))
+	mustOK(t, stub.invoke("buildPubTokenTx", licenseArgs()))
+	mustOK(t, stub.invoke("ownerSign", map[string]string{"account": "0xalice", "secret": "s3cret", "tokenId": licenseId}))
+
+	pubTx, err := getPubTokenTx(stub, licenseId)
+	if err != nil || pubTx == nil {
+		t.Fatalf("license not found: %v", err)
+	}
+	if !pubTx.OwnerSigned || pubTx.OwnerAccount != "0xalice" || pubTx.ReferenceId != approveId {
+		t.Fatalf("unexpected license: %+v", pubTx)
+	}
+
+	resp := stub.invoke("requestTokenInfo", map[string]string{"tokenId": copyrightId, "version": "v2"})
+	mustOK(t, resp)
+	var detail TokenDetail
+	if err := json.Unmarshal(resp.Payload, &detail); err != nil || detail.WorkId != "work-1" {
+		t.Fatalf("unexpected token info: %s", resp.Payload)
+	}
+
+	issue, _ := getTokenIssue(stub, "MediaToken")
+	if issue.Issued != 3 {
+		t.Fatalf("issued counter = %d, want 3", issue.Issued)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// 衍生作品关联类型
+const (
+	RelationAdaptation  = 0 // 改编
+	RelationTranslation = 1 // 翻译
+	RelationCompilation = 2 // 汇编
+	RelationExcerpt     = 3 // 节选
+)
+
+// maxLineageDepth 溯源查询默认的最大层数
+const maxLineageDepth = 32
+
+// ParentLink 版权通证对上级(原作品)版权通证的关联
+type ParentLink struct {
+	TokenId        string `json:"tokenId"`        // 上级版权通证ID
+	RelationType   int    `json:"relationType"`   // 关联类型: 0=改编,1=翻译,2=汇编,3=节选
+	ApproveTokenId string `json:"approveTokenId"` // 上级版权通证出具的、覆盖该用途的授权通证ID
+}
+
+// LineageEdge 溯源图中的一条边(子 -> 父)
+type LineageEdge struct {
+	ParentId     string `json:"parentId"`
+	ChildId      string `json:"childId"`
+	RelationType int    `json:"relationType"`
+	Depth        int    `json:"depth"` // 距查询通证的层数, 从1开始
+}
+
+// TokenLineage 版权通证的溯源结果
+type TokenLineage struct {
+	TokenId     string        `json:"tokenId"`
+	Ancestors   []LineageEdge `json:"ancestors"`   // 向上: 原作品
+	Descendants []LineageEdge `json:"descendants"` // 向下: 衍生作品
+}
+
+// RequestTokenLineage 查询版权通证的衍生关系(向上及向下)
+// 文档: requestTokenLineage({tokenId, depth?})
+func (tc *TokenContract) RequestTokenLineage(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestTokenLineage"
+	args := stub.GetArgs()
+
+	tokenId := string(args["tokenId"])
+	depthStr := string(args["depth"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+
+	depth := maxLineageDepth
+	if depthStr != "" {
+		d, err := strconv.Atoi(depthStr)
+		if err != nil || d <= 0 || d > maxLineageDepth {
+			return errorResponse(stub, method, ErrInvalidParam, "depth", fmt.Sprintf("depth must be integer in 1-%d, got: %s", maxLineageDepth, depthStr))
+		}
+		depth = d
+	}
+
+	tokenBytes, err := stub.GetStateFromKeyByte("publish_token_" + tokenId)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to GetState for tokenId %s: %s", tokenId, err.Error()))
+	}
+	if len(tokenBytes) == 0 {
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no token found for tokenId: "+tokenId)
+	}
+
+	ancestors, err := walkAncestors(stub, tokenId, depth)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	descendants, err := walkDescendants(stub, tokenId, depth)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	retBytes, err := json.Marshal(&TokenLineage{
+		TokenId:     tokenId,
+		Ancestors:   ancestors,
+		Descendants: descendants,
+	})
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to marshal result: %s", err.Error()))
+	}
+	return shim.Success(retBytes)
+}
+
+// checkParentLinks 校验衍生版权通证的上级关联:
+// 上级版权通证存在, 且每个上级的持有者或权利主体都出具了授予 receiver、覆盖该关联类型的授权通证
+func checkParentLinks(stub shim.CMStubInterface, tokenObj *TokenObject, receiver string) error {
+	seen := make(map[string]bool)
+	for i, link := range tokenObj.ParentLinks {
+		field := fmt.Sprintf("parentLinks[%d]", i)
+		if link.TokenId == "" || link.ApproveTokenId == "" {
+			return newError(ErrInvalidLink, field, "%s missing 'tokenId' or 'approveTokenId'", field)
+		}
+		if !validEnum(stub, "relationType", link.RelationType) {
+			return newError(ErrInvalidEnum, field, "%s.relationType out of range (0-3), got: %d", field, link.RelationType)
+		}
+		if link.TokenId == tokenObj.TokenId {
+			return newError(ErrInvalidLink, field, "%s token can not derive from itself", field)
+		}
+		if seen[link.TokenId] {
+			return newError(ErrInvalidLink, field, "%s duplicated parent token: %s", field, link.TokenId)
+		}
+		seen[link.TokenId] = true
+
+		parent, err := getTokenDetail(stub, link.TokenId)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			return newError(ErrNotFound, field, "%s parent token not found: %s", field, link.TokenId)
+		}
+
+		approveBytes, err := stub.GetStateFromKeyByte("approve_token_" + link.ApproveTokenId)
+		if err != nil {
+			return fmt.Errorf("fail to GetState for approve token %s: %s", link.ApproveTokenId, err.Error())
+		}
+		if len(approveBytes) == 0 {
+			return newError(ErrNotFound, field, "%s approve token not found: %s", field, link.ApproveTokenId)
+		}
+		var approveToken ApproveToken
+		if err := json.Unmarshal(approveBytes, &approveToken); err != nil {
+			return fmt.Errorf("unmarshal approve token %s error: %s", link.ApproveTokenId, err.Error())
+		}
+		if approveToken.ReferenceID != link.TokenId {
+			return newError(ErrInvalidLink, field, "%s approve token %s is not issued under parent token %s",
+				field, link.ApproveTokenId, link.TokenId)
+		}
+		if !holdsRights(parent, approveToken.Publisher) {
+			return newError(ErrInvalidLink, field, "%s approve token %s is not issued by the owner or a copyright unit holder of parent token %s",
+				field, link.ApproveTokenId, link.TokenId)
+		}
+		if approveToken.Receiver != receiver {
+			return newError(ErrInvalidLink, field, "%s approve token %s is not granted to %s", field, link.ApproveTokenId, receiver)
+		}
+		if !containsInt(approveToken.DerivativeUses, link.RelationType) {
+			return newError(ErrInvalidLink, field, "%s approve token %s does not cover relationType %d",
+				field, link.ApproveTokenId, link.RelationType)
+		}
+		active, err := windowActive(stub, approveToken.Time)
+		if err != nil {
+			return err
+		}
+		if !active {
+			return newError(ErrNotActive, field, "%s approve token %s is not active at block time", field, link.ApproveTokenId)
+		}
+
+		// 重新发行已存在的通证时, 防止形成环
+		ancestors, err := walkAncestors(stub, link.TokenId, maxLineageDepth)
+		if err != nil {
+			return err
+		}
+		for _, edge := range ancestors {
+			if edge.ParentId == tokenObj.TokenId {
+				return newError(ErrInvalidLink, field, "%s would create a cycle through token %s", field, link.TokenId)
+			}
+		}
+	}
+	return nil
+}
+
+// saveParentLinks 保存版权通证的上级关联, 并维护上级 -> 衍生通证索引
+func saveParentLinks(stub shim.CMStubInterface, tokenId string, links []ParentLink) error {
+	if len(links) == 0 {
+		return nil
+	}
+	linkBytes, err := json.Marshal(links)
+	if err != nil {
+		return fmt.Errorf("marshal parentLinks error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte("parent_links_"+tokenId, linkBytes); err != nil {
+		return fmt.Errorf("fail to PutState parentLinks: %s", err.Error())
+	}
+	for _, link := range links {
+		if err := appendIndex(stub, "derivative_index_"+link.TokenId, tokenId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getParentLinks 读取版权通证的上级关联
+func getParentLinks(stub shim.CMStubInterface, tokenId string) ([]ParentLink, error) {
+	linkBytes, err := stub.GetStateFromKeyByte("parent_links_" + tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState parentLinks for %s: %s", tokenId, err.Error())
+	}
+	if len(linkBytes) == 0 {
+		return nil, nil
+	}
+	var links []ParentLink
+	if err := json.Unmarshal(linkBytes, &links); err != nil {
+		return nil, fmt.Errorf("unmarshal parentLinks for %s error: %s", tokenId, err.Error())
+	}
+	return links, nil
+}
+
+// walkAncestors 广度优先向上遍历原作品
+func walkAncestors(stub shim.CMStubInterface, tokenId string, depth int) ([]LineageEdge, error) {
+	edges := []LineageEdge{}
+	visited := map[string]bool{tokenId: true}
+	current := []string{tokenId}
+	for level := 1; level <= depth && len(current) > 0; level++ {
+		var next []string
+		for _, child := range current {
+			links, err := getParentLinks(stub, child)
+			if err != nil {
+				return nil, err
+			}
+			for _, link := range links {
+				edges = append(edges, LineageEdge{
+					ParentId:     link.TokenId,
+					ChildId:      child,
+					RelationType: link.RelationType,
+					Depth:        level,
+				})
+				if !visited[link.TokenId] {
+					visited[link.TokenId] = true
+					next = append(next, link.TokenId)
+				}
+			}
+		}
+		current = next
+	}
+	return edges, nil
+}
+
+// walkDescendants 广度优先向下遍历衍生作品
+func walkDescendants(stub shim.CMStubInterface, tokenId string, depth int) ([]LineageEdge, error) {
+	edges := []LineageEdge{}
+	visited := map[string]bool{tokenId: true}
+	current := []string{tokenId}
+	for level := 1; level <= depth && len(current) > 0; level++ {
+		var next []string
+		for _, parent := range current {
+			children, err := readIndex(stub, "derivative_index_"+parent)
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				links, err := getParentLinks(stub, child)
+				if err != nil {
+					return nil, err
+				}
+				for _, link := range links {
+					if link.TokenId != parent {
+						continue
+					}
+					edges = append(edges, LineageEdge{
+						ParentId:     parent,
+						ChildId:      child,
+						RelationType: link.RelationType,
+						Depth:        level,
+					})
+				}
+				if !visited[child] {
+					visited[child] = true
+					next = append(next, child)
+				}
+			}
+		}
+		current = next
+	}
+	return edges, nil
+}
+
+// containsInt 判断整数切片是否包含 v
+func containsInt(list []int, v int) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,1089 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// schemaDialect 发布的 JSON Schema 版本
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// enumValue 枚举取值及含义
+type enumValue struct {
+	Value   int
+	Meaning string
+}
+
+// enumSpec 整数枚举
+type enumSpec struct {
+	Description string
+	Values      []enumValue
+}
+
+// contractEnums 合约使用的全部整数枚举, 参数校验与 describe 共用
+// 部分枚举的具体含义由接口规范定义, 此处只登记取值范围, 不给出含义
+var contractEnums = map[string]enumSpec{
+	"referenceFlag": {"通证标识", []enumValue{
+		{1, "版权通证"}, {2, "授权通证"}, {3, "操作许可通证"},
+	}},
+	"circulationFlag": {"流通标识", []enumValue{
+		{0, "可流通"}, {1, "不可流通"},
+	}},
+	"freezeFlag": {"冻结标识", []enumValue{
+		{0, "解冻"}, {1, "冻结"},
+	}},
+	"tokenType": {"通证类型", []enumValue{
+		{TokenTypeCopyright, "版权通证"}, {TokenTypeApprove, "授权通证"}, {TokenTypeLicense, "许可"},
+	}},
+	"relationType": {"衍生作品关联类型", []enumValue{
+		{RelationAdaptation, "改编"}, {RelationTranslation, "翻译"}, {RelationCompilation, "汇编"}, {RelationExcerpt, "节选"},
+	}},
+	"copyrightType":      {"作品类型(含义见接口规范)", numberedEnum(0, 16)},
+	"copyrightGetType":   {"版权取得方式(含义见接口规范)", numberedEnum(0, 5)},
+	"approveChannel":     {"授权渠道(含义见接口规范)", numberedEnum(0, 4)},
+	"approveArea":        {"授权范围(含义见接口规范)", numberedEnum(0, 2)},
+	"approveTime":        {"授权时间(含义见接口规范)", numberedEnum(0, 3)},
+	"approveStatus":      {"授权状态(含义见接口规范)", numberedEnum(0, 1)},
+	"distributionMethod": {"计酬方式(含义见接口规范)", numberedEnum(0, 3)},
+}
+
+// numberedEnum 生成连续取值、未登记含义的枚举
+func numberedEnum(min, max int) []enumValue {
+	values := make([]enumValue, 0, max-min+1)
+	for v := min; v <= max; v++ {
+		values = append(values, enumValue{Value: v})
+	}
+	return values
+}
+
+// enumOption 枚举取值的 JSON Schema 选项, 未登记含义时不输出 title
+func enumOption(value interface{}, ev enumValue) map[string]interface{} {
+	option := map[string]interface{}{"const": value}
+	if ev.Meaning != "" {
+		option["title"] = ev.Meaning
+	}
+	return option
+}
+
+// validEnum 判断取值是否属于枚举, 管理员调整过取值范围的枚举以调整后的范围为准
+func validEnum(stub shim.CMStubInterface, name string, v int) bool {
+	bounds, err := getEnumBounds(stub, name)
+	if err != nil {
+		stub.Log("[validEnum] " + err.Error() + ", fallback to default values")
+	}
+	if bounds != nil {
+		return v >= bounds.Min && v <= bounds.Max
+	}
+	for _, ev := range contractEnums[name].Values {
+		if ev.Value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// 参数类型: 合约参数均以字符串传入, 以下为其逻辑类型
+const (
+	argString  = "string"
+	argInteger = "integer"
+	argBoolean = "boolean"
+	argJSON    = "json"
+)
+
+// 字符串格式
+const (
+	formatHash256  = "hash256"
+	formatDate     = "iso8601-date"
+	formatInterval = "iso8601-interval"
+)
+
+// paramSpec 方法参数说明
+type paramSpec struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+	Enum        string // 整数枚举名
+	Format      string // 字符串格式
+	Schema      string // JSON 参数的结构, "[]" 前缀表示数组
+}
+
+// methodSpec 方法说明
+type methodSpec struct {
+	Name        string
+	Query       bool
+	Description string
+	Params      []paramSpec
+	Result      string // 查询方法的返回结构, 写方法均返回 TxResult
+	Principal   string // 可由操作员代理时, 委托人(调用者)参数名
+	Errors      []string
+}
+
+// initParams InitContract 参数
+var initParams = []paramSpec{
+	{Name: "strictContent", Type: argBoolean, Description: "开启内容哈希严格校验模式"},
+	{Name: "regulators", Type: argJSON, Schema: "[]string", Description: "监管机构账户"},
+	{Name: "admins", Type: argJSON, Schema: "[]string", Description: "管理员账户, 默认为部署交易的发送者"},
+	{Name: "config", Type: argJSON, Schema: "object", Description: "配置项, 同 setConfig 的 name/value"},
+}
+
+// configNameDescription setConfig 支持的配置项
+const configNameDescription = "strictContent(bool) / regulators([]string) / tokenBaseURI(string) / paymentContract(string) / paymentAttestors([]string) / arbitrators([]string) / enum.<枚举名>({min,max}, 仅限 copyrightType、copyrightGetType、approve*、distributionMethod)"
+
+// operatorAccountSpec 可代理写方法的操作员参数, 由 methodSchema 统一添加
+var operatorAccountSpec = paramSpec{Name: operatorAccountParam, Type: argString,
+	Description: "代理调用的操作员账户, 可选, 须为交易发送者; 发送者不是委托人(principal 参数)时须持有其授予的有效代理权"}
+
+// expectedVersionParam 写方法的乐观并发控制参数
+var expectedVersionParam = paramSpec{Name: "expectedVersion", Type: argInteger,
+	Description: "期望的记录版本号, 与当前版本不一致时返回 VERSION_CONFLICT; 0 表示记录尚不存在"}
+
+// contractMethods InvokeContract 支持的全部方法
+var contractMethods = []methodSpec{
+	{
+		Name: "buildTokenIssueTx", Principal: "account", Description: "通证初始化(创建或更新通证类别)",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "动态发币账户"},
+			{Name: "publisher", Type: argString, Required: true, Description: "通证发行账户"},
+			{Name: "token", Type: argString, Required: true, Description: "通证名称(类别)"},
+			{Name: "number", Type: argInteger, Required: true, Description: "发行数量, 不得小于已发行数量"},
+			{Name: "flag", Type: argInteger, Required: true, Enum: "circulationFlag"},
+			{Name: "version", Type: argString, Required: true, Description: "版本号 v1 / v2"},
+			{Name: "roles", Type: argJSON, Schema: "[]TokenRole", Description: "权限列表"},
+			{Name: "reference_flag", Type: argInteger, Required: true, Enum: "referenceFlag"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidEnum, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "buildPublishTokenTx", Principal: "publisher", Description: "一般通证(版权通证)发行, tokenId 已发行时返回 ALREADY_EXISTS",
+		Params: []paramSpec{
+			{Name: "publisher", Type: argString, Required: true, Description: "发行账户"},
+			{Name: "receiver", Type: argString, Required: true, Description: "接收(持有)账户"},
+			{Name: "token", Type: argString, Required: true, Description: "通证名称(类别), 需已初始化"},
+			{Name: "referenceFlag", Type: argInteger, Required: true, Enum: "referenceFlag"},
+			{Name: "tokenObject", Type: argJSON, Required: true, Schema: "TokenObject"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidEnum, ErrInvalidDate, ErrNotFound, ErrBurned,
+			ErrContentClaimed, ErrInvalidLink, ErrNotActive, ErrSupplyExhausted, ErrAlreadyExists, ErrForbidden, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "buildPublishApproveTokenTx", Principal: "publisher", Description: "授权通证发行",
+		Params: []paramSpec{
+			{Name: "publisher", Type: argString, Required: true, Description: "发行账户, 须为关联版权通证的持有者或权利主体, 操作员须经其代理"},
+			{Name: "receiver", Type: argString, Required: true, Description: "被授权账户"},
+			{Name: "token", Type: argString, Required: true, Description: "通证名称(类别), 需已初始化"},
+			{Name: "tokenId", Type: argString, Required: true, Format: formatHash256, Description: "授权通证ID"},
+			{Name: "referenceID", Type: argString, Required: true, Format: formatHash256, Description: "关联的版权通证ID"},
+			{Name: "approveType", Type: argInteger, Required: true, Description: "授权类型"},
+			{Name: "approveConstraints", Type: argJSON, Schema: "[]ApproveConstraint", Description: "授权约束"},
+			{Name: "duty", Type: argJSON, Schema: "[]DutyInfo", Description: "计酬信息"},
+			{Name: "derivativeUses", Type: argJSON, Schema: "[]relationType", Description: "允许的衍生用途"},
+			{Name: "time", Type: argString, Format: formatInterval, Description: "授权期限, 须在版权保护期内"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidEnum, ErrInvalidDate, ErrNotFound, ErrForbidden, ErrAlreadyExists, ErrBurned,
+			ErrSupplyExhausted, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "buildPubTokenTx", Principal: "publisher", Description: "通证许可(第一步, 组织许可交易)",
+		Params: []paramSpec{
+			{Name: "publisher", Type: argString, Required: true, Description: "发行账户"},
+			{Name: "receiver", Type: argString, Required: true, Description: "被许可账户"},
+			{Name: "token", Type: argString, Required: true, Description: "通证名称(类别), 需已初始化"},
+			{Name: "tokenId", Type: argString, Required: true, Format: formatHash256, Description: "许可ID"},
+			{Name: "referenceId", Type: argString, Required: true, Format: formatHash256, Description: "关联的授权通证ID"},
+			{Name: "tokenInfos", Type: argJSON, Schema: "[]TokenInfo", Description: "许可属性"},
+			{Name: "time", Type: argString, Format: formatInterval, Description: "许可期限, 须在授权期限内"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidDate, ErrNotFound, ErrBurned, ErrNotActive,
+			ErrSupplyExhausted, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "ownerSign", Principal: "account", Description: "通证许可(第二步, 版权所有者签名)",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "版权所有者账户"},
+			{Name: "secret", Type: argString, Required: true, Description: "签名"},
+			{Name: "tokenId", Type: argString, Required: true, Description: "许可ID"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrNotActive, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "requestAccountToken", Query: true, Description: "分页查询账户所持通证, 按首次发行时间排序",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true},
+			{Name: "version", Type: argString, Required: true, Description: "v1 / v2"},
+			{Name: "flag", Type: argInteger, Enum: "referenceFlag", Description: "仅 v2 有效, 默认 1"},
+			{Name: "frozen", Type: argBoolean, Description: "按冻结状态过滤"},
+			{Name: "token", Type: argString, Description: "按通证类别过滤"},
+			{Name: "workId", Type: argString, Description: "按作品ID过滤"},
+			{Name: "order", Type: argString, Description: "asc(默认, 先发行在前) / desc"},
+			{Name: "cursor", Type: argString, Description: "上一页返回的 nextCursor"},
+			{Name: "pageSize", Type: argInteger, Description: "每页条数 1-200, 默认 50"},
+		},
+		Result: "AccountTokenPage",
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrStateError},
+	},
+	{
+		Name: "requestTokenInfo", Query: true, Description: "查询单个通证详情",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "version", Type: argString, Required: true, Description: "v1 / v2"},
+		},
+		Result: "TokenDetail",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrVersionMismatch, ErrStateError},
+	},
+	{
+		Name: "saveFact", Description: "文件存证",
+		Params: []paramSpec{
+			{Name: "fileHash", Type: argString, Required: true, Format: formatHash256},
+			{Name: "fileName", Type: argString, Required: true},
+			{Name: "time", Type: argInteger, Required: true, Description: "存证时间(unix 秒)"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrAlreadyExists, ErrStateError},
+	},
+	{
+		Name: "findByFileHash", Query: true, Description: "按文件哈希查询存证",
+		Params: []paramSpec{
+			{Name: "fileHash", Type: argString, Required: true, Format: formatHash256},
+		},
+		Result: "Fact",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrStateError},
+	},
+	{
+		Name: "buildRegisterWorkTx", Principal: "account", Description: "作品登记",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "登记账户"},
+			{Name: "work", Type: argJSON, Required: true, Schema: "Work"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidEnum, ErrInvalidDate, ErrAlreadyExists, ErrStateError},
+	},
+	{
+		Name: "requestWorkInfo", Query: true, Description: "查询作品登记信息",
+		Params: []paramSpec{
+			{Name: "workId", Type: argString, Required: true},
+		},
+		Result: "Work",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrStateError},
+	},
+	{
+		Name: "requestWorkTokens", Query: true, Description: "查询作品下的版权通证、授权通证及许可",
+		Params: []paramSpec{
+			{Name: "workId", Type: argString, Required: true},
+		},
+		Result: "WorkTokenTree",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrStateError},
+	},
+	{
+		Name: "requestTokenLineage", Query: true, Description: "衍生作品溯源",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "depth", Type: argInteger, Description: "溯源层数, 1-" + strconv.Itoa(maxLineageDepth)},
+		},
+		Result: "TokenLineage",
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrStateError},
+	},
+	{
+		Name: "buildBurnTokenTx", Principal: "account", Description: "通证销毁",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "持有者或监管机构账户"},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "tokenType", Type: argInteger, Required: true, Enum: "tokenType"},
+			{Name: "reason", Type: argString, Description: "销毁原因"},
+			{Name: "cascade", Type: argBoolean, Description: "级联销毁下级通证"},
+			{Name: "signers", Type: argJSON, Schema: "[]string", Description: "共有人签名账户"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidEnum, ErrNotFound, ErrForbidden, ErrFrozen,
+			ErrHasDependents, ErrVersionConflict, ErrInEscrow, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "requestTombstone", Query: true, Description: "查询通证销毁记录",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Result: "Tombstone",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrStateError},
+	},
+	{
+		Name: "requestExpiringTokens", Query: true, Description: "查询指定天数内到期的通证",
+		Params: []paramSpec{
+			{Name: "days", Type: argInteger, Required: true, Description: "0-" + strconv.Itoa(maxExpiringDays)},
+			{Name: "tokenType", Type: argInteger, Enum: "tokenType"},
+		},
+		Result: "[]ExpiringToken",
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidEnum, ErrStateError},
+	},
+	{
+		Name: "ownerOf", Query: true, Description: "ERC-721: 查询版权通证持有者",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Result: "string",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrBurned, ErrStateError},
+	},
+	{
+		Name: "balanceOf", Query: true, Description: "ERC-721: 查询账户持有的版权通证数量",
+		Params: []paramSpec{
+			{Name: "owner", Type: argString, Required: true},
+		},
+		Result: "integer",
+		Errors: []string{ErrMissingParam, ErrStateError},
+	},
+	{
+		Name: "tokenURI", Query: true, Description: "ERC-721: 查询通证元数据 URI, 未配置 tokenBaseURI 时为内嵌 TokenMetadata 的 data URI",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Result: "string",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrBurned, ErrStateError},
+	},
+	{
+		Name: "approve", Principal: "account", Description: "ERC-721: 授权账户转移单个通证",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "持有者或其操作员"},
+			{Name: "to", Type: argString, Description: "被授权账户, 为空时取消授权"},
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrBurned, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "getApproved", Query: true, Description: "ERC-721: 查询单个通证的被授权账户, 未授权时为空串",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Result: "string",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrBurned, ErrStateError},
+	},
+	{
+		Name: "setApprovalForAll", Principal: "account", Description: "ERC-721: 授权或取消操作员转移调用者的全部通证",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "持有者"},
+			{Name: "operator", Type: argString, Required: true},
+			{Name: "approved", Type: argBoolean, Required: true},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrStateError},
+	},
+	{
+		Name: "isApprovedForAll", Query: true, Description: "ERC-721: 查询操作员是否可转移持有者的全部通证",
+		Params: []paramSpec{
+			{Name: "owner", Type: argString, Required: true},
+			{Name: "operator", Type: argString, Required: true},
+		},
+		Result: "boolean",
+		Errors: []string{ErrMissingParam, ErrStateError},
+	},
+	{
+		Name: "transferFrom", Principal: "account", Description: "ERC-721: 转移版权通证, 持有者的权利主体份额随之转移",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "持有者、被授权账户或操作员"},
+			{Name: "from", Type: argString, Required: true, Description: "当前持有者"},
+			{Name: "to", Type: argString, Required: true},
+			{Name: "tokenId", Type: argString, Required: true},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrBurned, ErrForbidden, ErrFrozen, ErrNotCirculating, ErrVersionConflict, ErrInEscrow, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "safeTransferFrom", Principal: "account", Description: "ERC-721: 转移版权通证, 指定接收合约时须其 onTokenReceived 确认",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "持有者、被授权账户或操作员"},
+			{Name: "from", Type: argString, Required: true, Description: "当前持有者"},
+			{Name: "to", Type: argString, Required: true},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "receiverContract", Type: argString, Description: "接收合约名, 须返回 \"onTokenReceived\""},
+			{Name: "data", Type: argString, Description: "原样传给接收合约"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrBurned, ErrForbidden, ErrFrozen, ErrNotCirculating, ErrVersionConflict, ErrInEscrow, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "createOffer", Principal: "account", Description: "创建挂单(卖方)或出价(买方), 挂单即托管锁定卖方的持有权/份额",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "ask 为卖方, bid 为买方"},
+			{Name: "side", Type: argString, Required: true, Description: "ask / bid"},
+			{Name: "kind", Type: argString, Required: true, Description: "token(持有权) / share(部分份额)"},
+			{Name: "tokenId", Type: argString, Required: true, Description: "版权通证ID"},
+			{Name: "proportion", Type: argString, Description: "出售的份额, kind=share 时必填"},
+			{Name: "price", Type: argString, Required: true, Description: "价格(正数)"},
+			{Name: "asset", Type: argString, Description: "计价资产"},
+			{Name: "seller", Type: argString, Description: "bid 的卖方, kind=token 时默认为持有者"},
+			{Name: "buyer", Type: argString, Description: "ask 限定的买方, 为空时任何账户可接受"},
+			{Name: "payment", Type: argString, Description: "contract / attested, 默认配置了 paymentContract 时为 contract"},
+			{Name: "expiresAt", Type: argString, Required: true, Format: formatDate, Description: "到期日, 当天结束失效"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidDate, ErrNotFound, ErrBurned, ErrForbidden, ErrFrozen, ErrNotCirculating, ErrInEscrow, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "acceptOffer", Principal: "account", Description: "接受挂单(买方)或出价(卖方); contract 付款方式下随即付款成交",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true},
+			{Name: "offerId", Type: argString, Required: true},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrNotActive, ErrForbidden, ErrFrozen, ErrNotCirculating,
+			ErrInEscrow, ErrSumMismatch, ErrPaymentFailed, ErrVersionConflict, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "attestPayment", Description: "付款见证账户登记链外付款记录, 随即成交",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "paymentAttestors 中的账户"},
+			{Name: "offerId", Type: argString, Required: true},
+			{Name: "paymentRef", Type: argString, Required: true, Description: "付款记录(流水号、凭证哈希等)"},
+		},
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrNotActive, ErrForbidden, ErrFrozen, ErrNotCirculating, ErrSumMismatch, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "cancelOffer", Principal: "account", Description: "撤销挂单/出价: 创建者撤销未接受的, 或买卖双方撤销已过期的; 优先购买结算生成的挂单由买方放弃",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true},
+			{Name: "offerId", Type: argString, Required: true},
+		},
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrNotActive, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "requestOffers", Query: true, Description: "查询通证下的挂单/出价",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "status", Type: argString, Description: "open / accepted / settled / cancelled / expired"},
+		},
+		Result: "[]SaleOffer",
+		Errors: []string{ErrMissingParam, ErrStateError},
+	},
+	{
+		Name: "setPreemptionRule", Principal: "account", Description: "持有者设置通证的优先购买规则: 向非权利主体转让份额须先经通知期; 缩短或取消须持有者及全部权利主体分别同意",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "持有者; 缩短或取消时也可为表示同意的权利主体"},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "noticeDays", Type: argInteger, Required: true, Description: "通知期天数(0-365), 0 为取消"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrBurned, ErrForbidden, ErrAlreadyExists, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "respondPreemption", Principal: "account", Description: "权利主体在通知期内主张或放弃优先购买",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "发出通知时的其他权利主体"},
+			{Name: "noticeId", Type: argString, Required: true},
+			{Name: "decision", Type: argString, Required: true, Description: "claim / waive"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrNotActive, ErrForbidden, ErrAlreadyExists, ErrStateError},
+	},
+	{
+		Name: "settlePreemption", Description: "通知期满或全部权利主体已答复后结算: 无人主张时完成原转让, 否则按份额比例向主张者生成待付款的挂单",
+		Params: []paramSpec{
+			{Name: "noticeId", Type: argString, Required: true},
+		},
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrNotActive, ErrForbidden, ErrBurned, ErrSumMismatch, ErrFrozen, ErrNotCirculating, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "requestPreemptionNotices", Query: true, Description: "查询通证下的优先购买通知",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Result: "[]PreemptionNotice",
+		Errors: []string{ErrMissingParam, ErrStateError},
+	},
+	{
+		Name: "fileDispute", Principal: "account", Description: "提出权属争议, 证据须已存证",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "争议提出者"},
+			{Name: "tokenId", Type: argString, Required: true, Description: "版权通证ID"},
+			{Name: "claim", Type: argString, Required: true, Description: "争议主张"},
+			{Name: "evidence", Type: argJSON, Required: true, Schema: "[]string", Description: "证据的存证哈希(saveFact)"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrBurned, ErrStateError},
+	},
+	{
+		Name: "addDisputeEvidence", Principal: "account", Description: "争议提出者或通证的权利主体补充证据",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true},
+			{Name: "disputeId", Type: argString, Required: true},
+			{Name: "evidence", Type: argJSON, Required: true, Schema: "[]string", Description: "证据的存证哈希(saveFact)"},
+			{Name: "note", Type: argString},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrBurned, ErrNotActive, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "acceptDispute", Description: "监管机构或仲裁账户受理争议, 通证进入争议状态",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "监管机构或 arbitrators 中的账户"},
+			{Name: "disputeId", Type: argString, Required: true},
+			{Name: "note", Type: argString},
+		},
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrBurned, ErrNotActive, ErrForbidden, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "resolveDispute", Description: "监管机构或仲裁账户裁决(可重新分配权利主体份额及持有者)或驳回争议, 解除争议状态",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "监管机构或 arbitrators 中的账户"},
+			{Name: "disputeId", Type: argString, Required: true},
+			{Name: "ruling", Type: argString, Required: true, Description: "裁决内容"},
+			{Name: "copyrightUnits", Type: argJSON, Schema: "[]CopyrightUnit", Description: "裁决的权利主体份额, 份额之和须与原份额之和相同"},
+			{Name: "owner", Type: argString, Description: "裁决的持有者, 须为裁决后的权利主体; 裁决份额移除当前持有者时必填"},
+			{Name: "dismiss", Type: argBoolean, Description: "驳回争议, 不可同时指定 copyrightUnits / owner"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrBurned, ErrNotActive, ErrForbidden, ErrSumMismatch, ErrStateError},
+	},
+	{
+		Name: "requestDisputes", Query: true, Description: "查询通证下的争议及处理记录",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Result: "[]Dispute",
+		Errors: []string{ErrMissingParam, ErrStateError},
+	},
+	{
+		Name: "grantOperator", Description: "授予或更新操作员代理权(限定方法、通证、授权渠道及范围, 限期)",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "委托人"},
+			{Name: "operator", Type: argString, Required: true, Description: "操作员"},
+			{Name: "scope", Type: argJSON, Schema: "OperatorScope", Description: "代理范围, 为空时不限"},
+			{Name: "expiresAt", Type: argString, Required: true, Format: formatDate, Description: "到期日, 当天结束失效"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidEnum, ErrInvalidDate, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "revokeOperator", Description: "撤销操作员代理权",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "委托人"},
+			{Name: "operator", Type: argString, Required: true, Description: "操作员"},
+		},
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrStateError},
+	},
+	{
+		Name: "requestOperatorGrants", Query: true, Description: "查询委托人授予的操作员代理权",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "委托人"},
+		},
+		Result: "[]OperatorGrant",
+		Errors: []string{ErrMissingParam, ErrStateError},
+	},
+	{
+		Name: "pauseContract", Description: "暂停全部或指定写方法",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "管理员账户"},
+			{Name: "methodName", Type: argString, Description: "为空时暂停全部写方法"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "unpauseContract", Description: "恢复全部或指定写方法",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "管理员账户"},
+			{Name: "methodName", Type: argString, Description: "为空时解除全局暂停"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "setConfig", Description: "修改配置项",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "管理员账户"},
+			{Name: "name", Type: argString, Required: true, Description: configNameDescription},
+			{Name: "value", Type: argJSON, Required: true, Description: "配置值(JSON)"},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "rotateAdmin", Description: "增加和/或移除管理员, 至少保留一名",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "管理员账户"},
+			{Name: "add", Type: argString, Description: "新增的管理员"},
+			{Name: "remove", Type: argString, Description: "移除的管理员"},
+		},
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrAlreadyExists, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "requestAdminInfo", Query: true, Description: "查询管理员、暂停开关及配置",
+		Result: "AdminInfo",
+		Errors: []string{ErrStateError},
+	},
+	{
+		Name: "requestAuditLog", Query: true, Description: "分页查询管理操作审计记录",
+		Params: []paramSpec{
+			{Name: "cursor", Type: argString, Description: "上一页返回的 nextCursor"},
+			{Name: "pageSize", Type: argInteger, Description: "每页条数 1-200, 默认 50"},
+		},
+		Result: "AuditPage",
+		Errors: []string{ErrInvalidParam, ErrStateError},
+	},
+	{
+		Name: "registerInstitution", Description: "登记或更新确权机构(名称、可提交账户、签名公钥、状态)",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "管理员账户"},
+			{Name: "institution", Type: argJSON, Required: true, Schema: "AuthInstitution", Description: "status 默认 active, 暂停时为 suspended; 更新时整体替换"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrAlreadyExists, ErrForbidden, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "requestInstitutions", Query: true, Description: "查询已登记的确权机构",
+		Result: "[]AuthInstitution",
+		Errors: []string{ErrStateError},
+	},
+	{
+		Name: "runMigration", Description: "分批执行未完成的数据迁移",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "管理员账户"},
+			{Name: "batchSize", Type: argInteger, Description: "本批处理的记录数 1-" + strconv.Itoa(maxMigrationBatch) + ", 默认 " + strconv.Itoa(defaultMigrationBatch)},
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "requestMigrationStatus", Query: true, Description: "查询数据迁移进度",
+		Result: "MigrationStatus",
+		Errors: []string{ErrStateError},
+	},
+	{
+		Name: "describe", Query: true, Description: "查询合约方法目录及 JSON Schema",
+		Params: []paramSpec{
+			{Name: "methodName", Type: argString, Description: "只返回指定方法"},
+		},
+		Result: "object",
+		Errors: []string{ErrNotFound, ErrStateError},
+	},
+	{
+		Name: "BuildModifyCopyrightTokenFlagTx", Principal: "account", Description: "冻结/解冻通证",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "监管机构账户, 须在监管机构白名单内"},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "flag", Type: argInteger, Required: true, Enum: "freezeFlag"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrForbidden, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "buildModifyAuthenticationInfoTx", Principal: "account", Description: "登记、更正或撤销通证确权信息, 更正生成新版本并保留旧版本",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "确权机构的登记账户, 或 authenticationInfo 附带机构签名"},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "authenticationInfo", Type: argJSON, Required: true, Schema: "AuthenticationInfo", Description: "撤销时只需 institutionId 及 authenticationId"},
+			{Name: "action", Type: argString, Description: "add(默认) / correct / revoke"},
+			{Name: "reason", Type: argString, Description: "更正或撤销原因"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidDate, ErrNotFound, ErrNotActive, ErrAlreadyExists, ErrForbidden, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "buildImportCredentialTx", Principal: "account", Description: "导入确权机构签发的 W3C 可验证凭证作为确权信息, 验证 issuer 及 proof 后记录凭证哈希",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "提交账户, 凭证已由机构签名, 不要求为机构账户"},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "credential", Type: argJSON, Required: true, Description: "可验证凭证(JSON-LD), proof 为 DataIntegrityProof(eddsa-jcs-2022 / ecdsa-jcs-2019); credentialSubject 须含 tokenId 或 contentHash"},
+			{Name: "action", Type: argString, Description: "add(默认) / correct"},
+			{Name: "reason", Type: argString, Description: "更正原因"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidDate, ErrNotFound, ErrNotActive, ErrAlreadyExists, ErrForbidden, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "buildModifyCopyrightUnitTx", Principal: "account", Description: "替换版权单元地址",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "原版权单元地址"},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "address", Type: argString, Required: true, Description: "新的版权单元地址"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrForbidden, ErrVersionConflict, ErrInEscrow, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "buildModifyConstraintTx", Description: "修改通证约束(版权单元全部签名)",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "constraint", Type: argJSON, Required: true, Schema: "ConstraintUpdate"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidDate, ErrNotFound, ErrForbidden, ErrVersionConflict, ErrStateError},
+	},
+	{
+		Name: "buildTokenChangeTx", Principal: "account", Description: "通证变更(冻结标识、属性), 指定 to 时发起持有者变更, 受让方接受后生效",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "当前持有者"},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "flags", Type: argInteger, Enum: "freezeFlag", Description: "持有者只能冻结, 解冻须由监管机构进行"},
+			{Name: "tokenInfos", Type: argJSON, Schema: "[]TokenInfo"},
+			{Name: "to", Type: argString, Description: "新的持有者, 不可与 flags 同时传入"},
+			{Name: "expiresAt", Type: argString, Format: formatDate, Description: "受让方接受期限, 当天结束失效, 默认 7 天"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrInvalidDate, ErrNotFound, ErrForbidden, ErrFrozen, ErrNotCirculating,
+			ErrInEscrow, ErrAlreadyExists, ErrVersionConflict, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "acceptTokenChange", Principal: "account", Description: "受让方接受持有者变更, 随即变更持有者、权利主体份额及持有索引",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "受让方"},
+			{Name: "tokenId", Type: argString, Required: true},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrBurned, ErrNotActive, ErrForbidden, ErrFrozen, ErrNotCirculating,
+			ErrInEscrow, ErrVersionConflict, ErrDisputed, ErrStateError},
+	},
+	{
+		Name: "cancelTokenChange", Principal: "account", Description: "持有者撤回或受让方拒绝进行中的持有者变更",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "持有者或受让方"},
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Errors: []string{ErrMissingParam, ErrNotActive, ErrForbidden, ErrStateError},
+	},
+	{
+		Name: "requestTokenChange", Query: true, Description: "查询通证最近一笔持有者变更",
+		Params: []paramSpec{
+			{Name: "tokenId", Type: argString, Required: true},
+		},
+		Result: "TokenChange",
+		Errors: []string{ErrMissingParam, ErrNotFound, ErrStateError},
+	},
+	{
+		Name: "buildTransferProportionTx", Principal: "account", Description: "版权份额转让",
+		Params: []paramSpec{
+			{Name: "account", Type: argString, Required: true, Description: "转出份额的版权单元地址"},
+			{Name: "tokenId", Type: argString, Required: true},
+			{Name: "copyrightUnits", Type: argJSON, Required: true, Schema: "[]CopyrightUnit", Description: "受让方及份额, 份额之和须等于转出份额"},
+			{Name: "terms", Type: argString, Description: "转让条件(价格等), 触发优先购买通知时记入通知"},
+			{Name: "price", Type: argString, Description: "转给非权利主体部分的总价, 触发优先购买通知时必填, 主张者按受让份额比例支付"},
+			{Name: "asset", Type: argString, Description: "计价资产"},
+			expectedVersionParam,
+		},
+		Errors: []string{ErrMissingParam, ErrInvalidParam, ErrNotFound, ErrForbidden, ErrSumMismatch, ErrVersionConflict, ErrInEscrow, ErrDisputed, ErrStateError},
+	},
+}
+
+// schemaTypes 发布到 $defs 的结构
+var schemaTypes = map[string]reflect.Type{
+	"TokenRole":           reflect.TypeOf(TokenRole{}),
+	"TokenObject":         reflect.TypeOf(TokenObject{}),
+	"AuthenticationInfo":  reflect.TypeOf(AuthenticationInfo{}),
+	"CopyrightUnit":       reflect.TypeOf(CopyrightUnit{}),
+	"CopyrightConstraint": reflect.TypeOf(CopyrightConstraint{}),
+	"ApprConstraint":      reflect.TypeOf(ApprConstraint{}),
+	"LicenseConstraint":   reflect.TypeOf(LicenseConstraint{}),
+	"CopyrightStatus":     reflect.TypeOf(CopyrightStatus{}),
+	"ParentLink":          reflect.TypeOf(ParentLink{}),
+	"ApproveConstraint":   reflect.TypeOf(ApproveConstraint{}),
+	"DutyInfo":            reflect.TypeOf(DutyInfo{}),
+	"TokenInfo":           reflect.TypeOf(TokenInfo{}),
+	"ConstraintUpdate":    reflect.TypeOf(ConstraintUpdate{}),
+	"Work":                reflect.TypeOf(Work{}),
+	"Fact":                reflect.TypeOf(Fact{}),
+	"TokenDetail":         reflect.TypeOf(TokenDetail{}),
+	"ApproveToken":        reflect.TypeOf(ApproveToken{}),
+	"PubTokenTx":          reflect.TypeOf(PubTokenTx{}),
+	"WorkTokenTree":       reflect.TypeOf(WorkTokenTree{}),
+	"CopyrightTokenNode":  reflect.TypeOf(CopyrightTokenNode{}),
+	"ApprovalNode":        reflect.TypeOf(ApprovalNode{}),
+	"LicenseNode":         reflect.TypeOf(LicenseNode{}),
+	"TokenLineage":        reflect.TypeOf(TokenLineage{}),
+	"LineageEdge":         reflect.TypeOf(LineageEdge{}),
+	"Tombstone":           reflect.TypeOf(Tombstone{}),
+	"ExpiringToken":       reflect.TypeOf(ExpiringToken{}),
+	"TokenMetadata":       reflect.TypeOf(TokenMetadata{}),
+	"OperatorScope":       reflect.TypeOf(OperatorScope{}),
+	"SaleOffer":           reflect.TypeOf(SaleOffer{}),
+	"PreemptionRule":      reflect.TypeOf(PreemptionRule{}),
+	"PreemptionNotice":    reflect.TypeOf(PreemptionNotice{}),
+	"TokenChange":         reflect.TypeOf(TokenChange{}),
+	"Dispute":             reflect.TypeOf(Dispute{}),
+	"DisputeStep":         reflect.TypeOf(DisputeStep{}),
+	"AuthInstitution":     reflect.TypeOf(AuthInstitution{}),
+	"OperatorGrant":       reflect.TypeOf(OperatorGrant{}),
+	"TxResult":            reflect.TypeOf(TxResult{}),
+	"EventRef":            reflect.TypeOf(EventRef{}),
+	"AccountTokenPage":    reflect.TypeOf(AccountTokenPage{}),
+	"MigrationStatus":     reflect.TypeOf(MigrationStatus{}),
+	"AuditEntry":          reflect.TypeOf(AuditEntry{}),
+	"AuditPage":           reflect.TypeOf(AuditPage{}),
+	"AdminInfo":           reflect.TypeOf(AdminInfo{}),
+}
+
+// fieldNotes 结构字段的枚举、格式及必填标注, 键为 "结构名.json字段名"
+var fieldNotes = map[string]paramSpec{
+	"TokenObject.flag":                     {Enum: "circulationFlag"},
+	"TokenObject.tokenId":                  {Required: true, Format: formatHash256},
+	"TokenObject.contentHash":              {Format: formatHash256},
+	"TokenObject.copyrightType":            {Enum: "copyrightType"},
+	"TokenObject.copyrightGetType":         {Enum: "copyrightGetType"},
+	"TokenObject.term":                     {Format: formatInterval},
+	"AuthenticationInfo.authenticatedDate": {Format: formatDate},
+	"CopyrightStatus.publishDate":          {Format: formatDate},
+	"CopyrightStatus.comeoutDate":          {Format: formatDate},
+	"CopyrightStatus.issueDate":            {Format: formatDate},
+	"ApprConstraint.time":                  {Format: formatInterval},
+	"LicenseConstraint.time":               {Format: formatInterval},
+	"ParentLink.tokenId":                   {Required: true, Format: formatHash256},
+	"ParentLink.relationType":              {Required: true, Enum: "relationType"},
+	"ParentLink.approveTokenId":            {Required: true, Format: formatHash256},
+	"ApproveConstraint.approveChannel":     {Enum: "approveChannel"},
+	"ApproveConstraint.approveArea":        {Enum: "approveArea"},
+	"ApproveConstraint.approveTime":        {Enum: "approveTime"},
+	"ApproveConstraint.approveStatus":      {Enum: "approveStatus"},
+	"DutyInfo.distributionMethod":          {Enum: "distributionMethod"},
+	"DutyInfo.balanceDate":                 {Format: formatDate},
+	"Work.workId":                          {Required: true},
+	"Work.copyrightType":                   {Enum: "copyrightType"},
+	"Work.creationDate":                    {Format: formatDate},
+	"TokenDetail.flag":                     {Enum: "referenceFlag"},
+	"TokenDetail.circulationFlag":          {Enum: "circulationFlag"},
+	"TokenDetail.copyrightType":            {Enum: "copyrightType"},
+	"TokenDetail.copyrightGetType":         {Enum: "copyrightGetType"},
+	"TokenDetail.term":                     {Format: formatInterval},
+	"ApproveToken.time":                    {Format: formatInterval},
+	"PubTokenTx.time":                      {Format: formatInterval},
+	"LineageEdge.relationType":             {Enum: "relationType"},
+	"Tombstone.tokenType":                  {Enum: "tokenType"},
+	"ExpiringToken.tokenType":              {Enum: "tokenType"},
+	"ExpiringToken.window":                 {Format: formatInterval},
+	"ExpiringToken.endDate":                {Format: formatDate},
+	"TxResult.digest":                      {Format: formatHash256},
+}
+
+// Describe 查询合约方法目录及 JSON Schema
+// 文档: describe({methodName?})
+// 参数 method 已用于方法分发, 指定方法时使用 methodName
+func (tc *TokenContract) Describe(stub shim.CMStubInterface) protogo.Response {
+	const method = "describe"
+	name := string(stub.GetArgs()["methodName"])
+
+	var doc interface{} = contractSchema()
+	if name != "" {
+		spec, ok := findMethodSpec(name)
+		if !ok {
+			return errorResponse(stub, method, ErrNotFound, "methodName", "unknown method: "+name)
+		}
+		doc = methodSchema(spec)
+	}
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to marshal schema: %s", err.Error()))
+	}
+	return shim.Success(docBytes)
+}
+
+// findMethodSpec 按方法名查找方法说明
+func findMethodSpec(method string) (methodSpec, bool) {
+	for _, spec := range contractMethods {
+		if spec.Name == method {
+			return spec, true
+		}
+	}
+	return methodSpec{}, false
+}
+
+// contractSchema 生成完整的合约说明文档
+func contractSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+	for name, t := range schemaTypes {
+		defs[name] = structSchema(name, t)
+	}
+
+	enums := map[string]interface{}{}
+	for name, spec := range contractEnums {
+		enums[name] = enumSchema(name)
+		enums[name].(map[string]interface{})["description"] = spec.Description
+	}
+
+	methods := map[string]interface{}{}
+	for _, spec := range contractMethods {
+		methods[spec.Name] = methodSchema(spec)
+	}
+
+	return map[string]interface{}{
+		"$schema":     schemaDialect,
+		"title":       "TokenContract",
+		"description": "合约方法目录: 参数均以字符串传入, JSON 参数为序列化后的字符串",
+		"$defs":       defs,
+		"enums":       enums,
+		"errorCodes":  errorCodeMeanings,
+		"init":        map[string]interface{}{"params": paramsSchema(initParams)},
+		"methods":     methods,
+	}
+}
+
+// methodSchema 生成单个方法的说明
+func methodSchema(spec methodSpec) map[string]interface{} {
+	kind := "invoke"
+	if spec.Query {
+		kind = "query"
+	}
+	result := refSchema("TxResult")
+	if spec.Result != "" {
+		result = refSchema(spec.Result)
+	}
+	errs := spec.Errors
+	if errs == nil {
+		errs = []string{}
+	}
+	params := spec.Params
+	doc := map[string]interface{}{
+		"method":      spec.Name,
+		"kind":        kind,
+		"description": spec.Description,
+		"result":      result,
+		"errors":      errs,
+	}
+	if delegable(spec) {
+		params = append(append([]paramSpec{}, params...), operatorAccountSpec)
+		doc["principal"] = spec.Principal
+	}
+	doc["params"] = paramsSchema(params)
+	return doc
+}
+
+// paramsSchema 生成参数表的 JSON Schema
+func paramsSchema(params []paramSpec) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, p := range params {
+		properties[p.Name] = paramSchema(p)
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	sort.Strings(required)
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// paramSchema 生成单个参数的 JSON Schema, 参数值均为字符串
+func paramSchema(p paramSpec) map[string]interface{} {
+	s := map[string]interface{}{"type": "string", "x-valueType": p.Type}
+	switch p.Type {
+	case argInteger:
+		s["pattern"] = "^-?[0-9]+$"
+		if p.Enum != "" {
+			var options []interface{}
+			for _, ev := range contractEnums[p.Enum].Values {
+				options = append(options, enumOption(strconv.Itoa(ev.Value), ev))
+			}
+			s["oneOf"] = options
+			s["x-enum"] = p.Enum
+		}
+	case argBoolean:
+		s["enum"] = []string{"true", "false"}
+	case argJSON:
+		s["contentMediaType"] = "application/json"
+		s["contentSchema"] = refSchema(p.Schema)
+	default:
+		addFormat(s, p.Format)
+	}
+	if p.Description != "" {
+		s["description"] = p.Description
+	}
+	return s
+}
+
+// refSchema 引用 $defs 中的结构或枚举, "[]" 前缀表示数组
+func refSchema(name string) map[string]interface{} {
+	if strings.HasPrefix(name, "[]") {
+		return map[string]interface{}{"type": "array", "items": refSchema(name[2:])}
+	}
+	if _, ok := contractEnums[name]; ok {
+		return enumSchema(name)
+	}
+	if _, ok := schemaTypes[name]; ok {
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	return map[string]interface{}{"type": name}
+}
+
+// enumSchema 生成整数枚举的 JSON Schema
+func enumSchema(name string) map[string]interface{} {
+	var options []interface{}
+	for _, ev := range contractEnums[name].Values {
+		options = append(options, enumOption(ev.Value, ev))
+	}
+	return map[string]interface{}{"type": "integer", "oneOf": options, "x-enum": name}
+}
+
+// addFormat 为字符串字段添加格式约束
+func addFormat(s map[string]interface{}, format string) {
+	switch format {
+	case formatHash256:
+		s["pattern"] = "^[0-9a-fA-F]{64}$"
+		s["format"] = format
+	case formatDate, formatInterval:
+		s["format"] = format
+	}
+}
+
+// structSchema 由结构体定义生成 JSON Schema
+func structSchema(name string, t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		jsonName := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				jsonName = n
+			}
+		}
+		note := fieldNotes[name+"."+jsonName]
+		var s map[string]interface{}
+		if note.Enum != "" {
+			s = enumSchema(note.Enum)
+		} else {
+			s = typeSchema(field.Type)
+			addFormat(s, note.Format)
+		}
+		properties[jsonName] = s
+		if note.Required {
+			required = append(required, jsonName)
+		}
+	}
+	sort.Strings(required)
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// typeSchema 由字段类型生成 JSON Schema
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		return map[string]interface{}{}
+	}
+	for name, st := range schemaTypes {
+		if st == t {
+			return map[string]interface{}{"$ref": "#/$defs/" + name}
+		}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t.Name(), t)
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 权属争议: 任何账户可就版权通证的持有权或份额提出争议并提交证据(存证哈希)
+// 监管机构或仲裁账户受理后通证进入争议状态, 期间不可转移、修改份额或由持有者销毁, 查询不受影响
+// 裁决可重新分配权利主体份额及持有者, 各环节均记入争议记录
+//
+//	dispute_<disputeId>                 -> Dispute, disputeId 为提出争议交易的 txId
+//	dispute_token_<tokenId>#<disputeId> -> disputeId   通证下的争议
+//
+// 通证处于争议状态时 TokenDetail.disputeId 为受理中的争议
+const (
+	disputeKeyPrefix      = "dispute_"
+	disputeTokenKeyPrefix = "dispute_token_"
+	arbitratorsKey        = "config_arbitrators"
+
+	disputeFiled     = "filed"     // 已提出, 待受理
+	disputeAccepted  = "accepted"  // 已受理, 通证处于争议状态
+	disputeResolved  = "resolved"  // 已裁决
+	disputeDismissed = "dismissed" // 已驳回
+)
+
+// DisputeStep 争议处理环节
+type DisputeStep struct {
+	Action   string   `json:"action"` // file / evidence / accept / resolve / dismiss
+	Account  string   `json:"account"`
+	Note     string   `json:"note,omitempty"`
+	Evidence []string `json:"evidence,omitempty"` // 本环节提交的存证哈希
+	At       string   `json:"at"`                 // 区块时间(RFC3339)
+	TxId     string   `json:"txId"`
+}
+
+// Dispute 权属争议
+type Dispute struct {
+	DisputeId  string          `json:"disputeId"`
+	TokenId    string          `json:"tokenId"`
+	Claimant   string          `json:"claimant"`
+	Claim      string          `json:"claim"`                // 争议主张
+	Evidence   []string        `json:"evidence"`             // 全部证据的存证哈希
+	Status     string          `json:"status"`               // filed / accepted / resolved / dismissed
+	Arbitrator string          `json:"arbitrator,omitempty"` // 受理的监管机构或仲裁账户
+	Ruling     string          `json:"ruling,omitempty"`
+	Units      []CopyrightUnit `json:"units,omitempty"` // 裁决重新分配的权利主体份额
+	Owner      string          `json:"owner,omitempty"` // 裁决变更的持有者
+	Steps      []DisputeStep   `json:"steps"`
+	// RecordVersion 记录版本号, 每次写入递增
+	RecordVersion int `json:"recordVersion"`
+}
+
+func getDispute(stub shim.CMStubInterface, disputeId string) (*Dispute, error) {
+	disputeBytes, err := stub.GetStateFromKeyByte(disputeKeyPrefix + disputeId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState dispute %s: %s", disputeId, err.Error())
+	}
+	if len(disputeBytes) == 0 {
+		return nil, newError(ErrNotFound, "disputeId", "no dispute found for disputeId: %s", disputeId)
+	}
+	var dispute Dispute
+	if err := json.Unmarshal(disputeBytes, &dispute); err != nil {
+		return nil, fmt.Errorf("unmarshal dispute %s error: %s", disputeId, err.Error())
+	}
+	return &dispute, nil
+}
+
+func putDispute(stub shim.CMStubInterface, dispute *Dispute) error {
+	dispute.RecordVersion++
+	disputeBytes, err := json.Marshal(dispute)
+	if err != nil {
+		return fmt.Errorf("marshal dispute error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(disputeKeyPrefix+dispute.DisputeId, disputeBytes); err != nil {
+		return fmt.Errorf("fail to PutState dispute %s: %s", dispute.DisputeId, err.Error())
+	}
+	return nil
+}
+
+// addStep 追加争议处理环节
+func (d *Dispute) addStep(stub shim.CMStubInterface, action, account, note string, evidence []string) error {
+	now, err := txTime(stub)
+	if err != nil {
+		return err
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	d.Steps = append(d.Steps, DisputeStep{Action: action, Account: account, Note: note, Evidence: evidence, At: now.Format(time.RFC3339), TxId: txId})
+	return nil
+}
+
+// checkDisputed 通证处于争议状态时返回 DISPUTED
+func checkDisputed(detail *TokenDetail) error {
+	if detail.DisputeId != "" {
+		return newError(ErrDisputed, "tokenId", "token %s is under dispute %s", detail.TokenId, detail.DisputeId)
+	}
+	return nil
+}
+
+// checkArbitrator 校验账户为监管机构或仲裁账户, 且为交易发送者
+func checkArbitrator(stub shim.CMStubInterface, account string) error {
+	if err := checkSender(stub, account); err != nil {
+		return err
+	}
+	regulator, err := isRegulator(stub, account)
+	if err != nil {
+		return err
+	}
+	if regulator {
+		return nil
+	}
+	arbitrators, err := readIndex(stub, arbitratorsKey)
+	if err != nil {
+		return err
+	}
+	if !containsId(arbitrators, account) {
+		return newError(ErrForbidden, "account", "account is neither a regulator nor an arbitrator: %s", account)
+	}
+	return nil
+}
+
+// parseEvidence 解析证据存证哈希数组, 各哈希须已通过 saveFact 存证
+func parseEvidence(stub shim.CMStubInterface, evidenceStr string) ([]string, error) {
+	var evidence []string
+	if err := json.Unmarshal([]byte(evidenceStr), &evidence); err != nil {
+		return nil, newError(ErrInvalidParam, "evidence", "evidence must be json array of fact hashes: %s", err.Error())
+	}
+	if len(evidence) == 0 {
+		return nil, newError(ErrMissingParam, "evidence", "evidence is empty")
+	}
+	for i, hash := range evidence {
+		hash = strings.ToLower(hash)
+		if !isHash256(hash) {
+			return nil, newError(ErrInvalidParam, "evidence", "evidence must be hash256, got: %s", hash)
+		}
+		factBytes, err := stub.GetStateFromKeyByte("fact_" + hash)
+		if err != nil {
+			return nil, fmt.Errorf("fail to GetState fact %s: %s", hash, err.Error())
+		}
+		if len(factBytes) == 0 {
+			return nil, newError(ErrNotFound, "evidence", "no fact found for evidence: %s", hash)
+		}
+		evidence[i] = hash
+	}
+	return evidence, nil
+}
+
+// FileDispute 提出权属争议, 任何账户均可提出
+// 文档: fileDispute({account, tokenId, claim, evidence})
+func (tc *TokenContract) FileDispute(stub shim.CMStubInterface) protogo.Response {
+	const method = "fileDispute"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	tokenId := string(args["tokenId"])
+	claim := string(args["claim"])
+	if name := missingArg(args, "account", "tokenId", "claim", "evidence"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if _, err := loadCopyrightToken(stub, tokenId); err != nil {
+		return failResponse(stub, method, err)
+	}
+	evidence, err := parseEvidence(stub, string(args["evidence"]))
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	disputeId, err := stub.GetTxId()
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	dispute := &Dispute{DisputeId: disputeId, TokenId: tokenId, Claimant: account, Claim: claim, Evidence: evidence, Status: disputeFiled}
+	if err := dispute.addStep(stub, "file", account, claim, evidence); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := putDispute(stub, dispute); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := stub.PutStateByte(disputeTokenKeyPrefix+tokenId, disputeId, []byte(disputeId)); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to PutState dispute index of %s: %s", tokenId, err.Error()))
+	}
+
+	stub.EmitEvent("event_dispute_filed", []string{disputeId, tokenId, account})
+	return rec.success(method, disputeId, disputeKeyPrefix+disputeId, dispute.RecordVersion, dispute)
+}
+
+// AddDisputeEvidence 争议提出者或通证的权利主体补充证据, 裁决前均可提交
+// 文档: addDisputeEvidence({account, disputeId, evidence, note?})
+func (tc *TokenContract) AddDisputeEvidence(stub shim.CMStubInterface) protogo.Response {
+	const method = "addDisputeEvidence"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	disputeId := string(args["disputeId"])
+	if name := missingArg(args, "account", "disputeId", "evidence"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	dispute, err := getDispute(stub, disputeId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if dispute.Status != disputeFiled && dispute.Status != disputeAccepted {
+		return errorResponse(stub, method, ErrNotActive, "disputeId", "dispute is already closed: "+disputeId)
+	}
+	if account != dispute.Claimant {
+		detail, err := loadCopyrightToken(stub, dispute.TokenId)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if !holdsUnit(detail.CopyrightUnits, account) && detail.OwnerAccount != account {
+			return errorResponse(stub, method, ErrForbidden, "account", "only the claimant or a rights holder can submit evidence: "+account)
+		}
+	}
+	evidence, err := parseEvidence(stub, string(args["evidence"]))
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	for _, hash := range evidence {
+		if !containsId(dispute.Evidence, hash) {
+			dispute.Evidence = append(dispute.Evidence, hash)
+		}
+	}
+	if err := dispute.addStep(stub, "evidence", account, string(args["note"]), evidence); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := putDispute(stub, dispute); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_dispute_evidence", []string{disputeId, dispute.TokenId, account})
+	return rec.success(method, disputeId, disputeKeyPrefix+disputeId, dispute.RecordVersion, dispute)
+}
+
+// AcceptDispute 监管机构或仲裁账户受理争议, 通证进入争议状态; 同一通证同时只能受理一个争议
+// 文档: acceptDispute({account, disputeId, note?})
+func (tc *TokenContract) AcceptDispute(stub shim.CMStubInterface) protogo.Response {
+	const method = "acceptDispute"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	disputeId := string(args["disputeId"])
+	if name := missingArg(args, "account", "disputeId"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkArbitrator(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	dispute, err := getDispute(stub, disputeId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if dispute.Status != disputeFiled {
+		return errorResponse(stub, method, ErrNotActive, "disputeId", "dispute is not waiting for acceptance: "+disputeId)
+	}
+	detail, err := loadCopyrightToken(stub, dispute.TokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkDisputed(detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	detail.DisputeId = disputeId
+	if err := putTokenDetail(stub, detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	dispute.Status = disputeAccepted
+	dispute.Arbitrator = account
+	if err := dispute.addStep(stub, "accept", account, string(args["note"]), nil); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := putDispute(stub, dispute); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if _, _, err := audit(stub, method, account, dispute.TokenId, disputeId); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_dispute_accepted", []string{disputeId, dispute.TokenId, account})
+	return rec.success(method, disputeId, disputeKeyPrefix+disputeId, dispute.RecordVersion, dispute)
+}
+
+// ResolveDispute 监管机构或仲裁账户裁决争议并解除争议状态
+// 指定 copyrightUnits 时按裁决替换权利主体份额(份额之和须与原份额之和相同), 指定 owner 时变更持有者; 均未指定且 dismiss=true 时为驳回
+// 文档: resolveDispute({account, disputeId, ruling, copyrightUnits?, owner?, dismiss?})
+func (tc *TokenContract) ResolveDispute(stub shim.CMStubInterface) protogo.Response {
+	const method = "resolveDispute"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	disputeId := string(args["disputeId"])
+	ruling := string(args["ruling"])
+	owner := string(args["owner"])
+	dismiss := string(args["dismiss"]) == "true"
+	if name := missingArg(args, "account", "disputeId", "ruling"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkArbitrator(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	dispute, err := getDispute(stub, disputeId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if dispute.Status != disputeFiled && dispute.Status != disputeAccepted {
+		return errorResponse(stub, method, ErrNotActive, "disputeId", "dispute is already closed: "+disputeId)
+	}
+	var units []CopyrightUnit
+	if unitsStr := string(args["copyrightUnits"]); unitsStr != "" {
+		if err := json.Unmarshal([]byte(unitsStr), &units); err != nil {
+			return errorResponse(stub, method, ErrInvalidParam, "copyrightUnits", "fail to parse 'copyrightUnits': "+err.Error())
+		}
+	}
+	if dismiss && (len(units) > 0 || owner != "") {
+		return errorResponse(stub, method, ErrInvalidParam, "dismiss", "a dismissal can not reassign units or owner")
+	}
+	if !dismiss && dispute.Status != disputeAccepted {
+		return errorResponse(stub, method, ErrNotActive, "disputeId", "dispute must be accepted before a ruling: "+disputeId)
+	}
+
+	detail, err := loadCopyrightToken(stub, dispute.TokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if len(units) > 0 {
+		if err := checkRulingUnits(detail.CopyrightUnits, units); err != nil {
+			return failResponse(stub, method, err)
+		}
+		detail.CopyrightUnits = units
+		dispute.Units = units
+		// 裁决份额不再包含当前持有者时, 须同时指定新的持有者
+		if (owner == "" || owner == detail.OwnerAccount) && !holdsUnit(units, detail.OwnerAccount) {
+			return errorResponse(stub, method, ErrInvalidParam, "owner", "ruled copyright units drop the current owner "+detail.OwnerAccount+", specify a new owner")
+		}
+	}
+	if owner != "" && owner != detail.OwnerAccount {
+		if !holdsUnit(detail.CopyrightUnits, owner) {
+			return errorResponse(stub, method, ErrInvalidParam, "owner", "owner must hold a copyright unit after the ruling: "+owner)
+		}
+		if err := removeHolding(stub, detail.OwnerAccount, detail.TokenId); err != nil {
+			return failResponse(stub, method, err)
+		}
+		if err := addHolding(stub, owner, detail); err != nil {
+			return failResponse(stub, method, err)
+		}
+		if err := stub.DelState(erc721ApprovalKey, detail.TokenId); err != nil {
+			return failResponse(stub, method, fmt.Errorf("fail to DelState approval for %s: %s", detail.TokenId, err.Error()))
+		}
+		detail.OwnerAccount = owner
+		dispute.Owner = owner
+	}
+	changed := len(units) > 0 || dispute.Owner != ""
+	if detail.DisputeId == disputeId {
+		detail.DisputeId = ""
+		changed = true
+	}
+	if changed {
+		if err := putTokenDetail(stub, detail); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+
+	action := "resolve"
+	dispute.Status = disputeResolved
+	if dismiss {
+		action, dispute.Status = "dismiss", disputeDismissed
+	}
+	dispute.Ruling = ruling
+	if dispute.Arbitrator == "" {
+		dispute.Arbitrator = account
+	}
+	if err := dispute.addStep(stub, action, account, ruling, nil); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := putDispute(stub, dispute); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if _, _, err := audit(stub, method, account, dispute.TokenId, disputeId+" "+dispute.Status); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_dispute_"+dispute.Status, []string{disputeId, dispute.TokenId, account})
+	return rec.success(method, disputeId, disputeKeyPrefix+disputeId, dispute.RecordVersion, dispute)
+}
+
+// checkRulingUnits 裁决份额: 地址不重复, 份额为正数, 之和与原份额之和相同
+func checkRulingUnits(old, units []CopyrightUnit) error {
+	var oldSum, newSum float64
+	for _, unit := range old {
+		p, err := strconv.ParseFloat(unit.Proportion, 64)
+		if err != nil {
+			return fmt.Errorf("proportion of %s is not numeric: %s", unit.Address, unit.Proportion)
+		}
+		oldSum += p
+	}
+	seen := map[string]bool{}
+	for _, unit := range units {
+		if unit.Address == "" || seen[unit.Address] {
+			return newError(ErrInvalidParam, "copyrightUnits", "copyright unit address is empty or duplicated: %s", unit.Address)
+		}
+		seen[unit.Address] = true
+		p, err := strconv.ParseFloat(unit.Proportion, 64)
+		if err != nil || p <= 0 {
+			return newError(ErrInvalidParam, "copyrightUnits", "proportion must be a positive number, got: %s", unit.Proportion)
+		}
+		newSum += p
+	}
+	if math.Abs(newSum-oldSum) > 1e-9 {
+		return newError(ErrSumMismatch, "copyrightUnits", "sum of ruled proportions %s != current sum %s", formatProportion(newSum), formatProportion(oldSum))
+	}
+	return nil
+}
+
+// RequestDisputes 查询通证下的争议及处理记录
+// 文档: requestDisputes({tokenId})
+func (tc *TokenContract) RequestDisputes(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestDisputes"
+	tokenId := string(stub.GetArgs()["tokenId"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+	iter, err := stub.NewIteratorPrefixWithKeyField(disputeTokenKeyPrefix+tokenId, "")
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to create iterator for disputes of %s: %s", tokenId, err.Error()))
+	}
+	var disputeIds []string
+	for iter.HasNext() {
+		_, _, value, err := iter.Next()
+		if err != nil {
+			iter.Close()
+			return failResponse(stub, method, fmt.Errorf("fail to iterate disputes of %s: %s", tokenId, err.Error()))
+		}
+		disputeIds = append(disputeIds, string(value))
+	}
+	iter.Close()
+
+	disputes := []Dispute{}
+	for _, disputeId := range disputeIds {
+		dispute, err := getDispute(stub, disputeId)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		disputes = append(disputes, *dispute)
+	}
+	disputesBytes, err := json.Marshal(disputes)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal disputes error: %s", err.Error()))
+	}
+	return shim.Success(disputesBytes)
+}
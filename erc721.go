@@ -0,0 +1,381 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ERC-721 标准接口, 映射到版权通证(publish_token_)的持有者、冻结状态及流通标识
+// 查询方法直接返回字符串值(账户、数量、URI、"true"/"false"), 便于钱包及 NFT 工具对接
+const (
+	erc721ApprovalKey       = "erc721_approval"  // field 为 tokenId, 值为被授权转移该通证的账户
+	erc721OperatorKeyPrefix = "erc721_operator_" // erc721_operator_<owner>#<operator>, 值为 "true"
+	tokenReceivedMethod     = "onTokenReceived"  // safeTransferFrom 回调接收合约的方法
+	tokenReceivedAck        = "onTokenReceived"  // 接收合约须原样返回, 表示已接收
+)
+
+// TokenMetadata tokenURI 未配置 tokenBaseURI 时返回的内嵌元数据
+type TokenMetadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TokenId     string `json:"tokenId"`
+	WorkId      string `json:"workId,omitempty"`
+	ContentHash string `json:"contentHash,omitempty"`
+	Owner       string `json:"owner"`
+	PublishedAt string `json:"publishedAt,omitempty"`
+}
+
+// loadCopyrightToken 读取版权通证, 不存在或已销毁时返回结构化错误
+func loadCopyrightToken(stub shim.CMStubInterface, tokenId string) (*TokenDetail, error) {
+	detail, err := getTokenDetail(stub, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if detail != nil {
+		return detail, nil
+	}
+	burned, err := isBurned(stub, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if burned {
+		return nil, newError(ErrBurned, "tokenId", "token was burned: %s", tokenId)
+	}
+	return nil, newError(ErrNotFound, "tokenId", "no token found for tokenId: %s", tokenId)
+}
+
+// getApproved 读取单个通证的转移授权账户
+func getApproved(stub shim.CMStubInterface, tokenId string) (string, error) {
+	approved, err := stub.GetStateByte(erc721ApprovalKey, tokenId)
+	if err != nil {
+		return "", fmt.Errorf("fail to GetState approval for %s: %s", tokenId, err.Error())
+	}
+	return string(approved), nil
+}
+
+// isApprovedForAll 判断 operator 是否可转移 owner 的全部通证
+func isApprovedForAll(stub shim.CMStubInterface, owner, operator string) (bool, error) {
+	value, err := stub.GetStateByte(erc721OperatorKeyPrefix+owner, operator)
+	if err != nil {
+		return false, fmt.Errorf("fail to GetState operator %s of %s: %s", operator, owner, err.Error())
+	}
+	return string(value) == "true", nil
+}
+
+// canTransfer 持有者本人、单个通证的被授权账户或全部通证的操作员可转移通证
+func canTransfer(stub shim.CMStubInterface, detail *TokenDetail, account string) (bool, error) {
+	if account == detail.OwnerAccount {
+		return true, nil
+	}
+	approved, err := getApproved(stub, detail.TokenId)
+	if err != nil {
+		return false, err
+	}
+	if approved != "" && approved == account {
+		return true, nil
+	}
+	return isApprovedForAll(stub, detail.OwnerAccount, account)
+}
+
+// checkTransferable 冻结及不可流通的通证不可转移
+func checkTransferable(detail *TokenDetail) error {
+	if detail.Frozen {
+		return newError(ErrFrozen, "tokenId", "token is frozen: %s", detail.TokenId)
+	}
+	if detail.CirculationFlag == 1 {
+		return newError(ErrNotCirculating, "tokenId", "token is not circulating: %s", detail.TokenId)
+	}
+	return checkDisputed(detail)
+}
+
+// transferOwnership 将通证的持有者由 from 改为 to: 持有者的权利主体份额随之转移, 共有人份额不变
+// 同时更新账户持有索引并清除单个通证的转移授权
+func transferOwnership(stub shim.CMStubInterface, detail *TokenDetail, to string) error {
+	from := detail.OwnerAccount
+	for _, unit := range detail.CopyrightUnits {
+		if unit.Address == to {
+			return newError(ErrInvalidParam, "to", "%s already holds a copyright unit, use buildTransferProportionTx", to)
+		}
+	}
+	if err := checkPreemptionOutsider(stub, detail, from, to); err != nil {
+		return err
+	}
+	for i := range detail.CopyrightUnits {
+		if detail.CopyrightUnits[i].Address == from {
+			detail.CopyrightUnits[i].Address = to
+		}
+	}
+	detail.OwnerAccount = to
+	if err := putTokenDetail(stub, detail); err != nil {
+		return err
+	}
+	if err := removeHolding(stub, from, detail.TokenId); err != nil {
+		return err
+	}
+	if err := addHolding(stub, to, detail); err != nil {
+		return err
+	}
+	if err := stub.DelState(erc721ApprovalKey, detail.TokenId); err != nil {
+		return fmt.Errorf("fail to DelState approval for %s: %s", detail.TokenId, err.Error())
+	}
+	return nil
+}
+
+// OwnerOf 查询通证持有者
+// 文档: ownerOf({tokenId})
+func (tc *TokenContract) OwnerOf(stub shim.CMStubInterface) protogo.Response {
+	const method = "ownerOf"
+	tokenId := string(stub.GetArgs()["tokenId"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+	detail, err := loadCopyrightToken(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	return shim.Success([]byte(detail.OwnerAccount))
+}
+
+// BalanceOf 查询账户持有的版权通证数量
+// 文档: balanceOf({owner})
+func (tc *TokenContract) BalanceOf(stub shim.CMStubInterface) protogo.Response {
+	const method = "balanceOf"
+	owner := string(stub.GetArgs()["owner"])
+	if owner == "" {
+		return missingParam(stub, method, "owner")
+	}
+	count, err := holdingCount(stub, owner)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	return shim.Success([]byte(strconv.Itoa(count)))
+}
+
+// TokenURI 查询通证元数据 URI: 配置了 tokenBaseURI 时为 <tokenBaseURI><tokenId>, 否则为内嵌 JSON 元数据的 data URI
+// 文档: tokenURI({tokenId})
+func (tc *TokenContract) TokenURI(stub shim.CMStubInterface) protogo.Response {
+	const method = "tokenURI"
+	tokenId := string(stub.GetArgs()["tokenId"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+	detail, err := loadCopyrightToken(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	baseURI, err := getConfigString(stub, tokenBaseURIKey)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if baseURI != "" {
+		return shim.Success([]byte(baseURI + tokenId))
+	}
+	metadata := TokenMetadata{
+		Name:        detail.Token,
+		Description: detail.ConstraintExplain,
+		TokenId:     detail.TokenId,
+		WorkId:      detail.WorkId,
+		ContentHash: detail.ContentHash,
+		Owner:       detail.OwnerAccount,
+		PublishedAt: detail.PublishedAt,
+	}
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal metadata error: %s", err.Error()))
+	}
+	return shim.Success([]byte("data:application/json;base64," + base64.StdEncoding.EncodeToString(metadataBytes)))
+}
+
+// Approve 授权 to 转移单个通证, to 为空时取消授权; 调用者须为持有者或其全部通证的操作员
+// 文档: approve({account, to?, tokenId})
+func (tc *TokenContract) Approve(stub shim.CMStubInterface) protogo.Response {
+	const method = "approve"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	to := string(args["to"])
+	tokenId := string(args["tokenId"])
+	if name := missingArg(args, "account", "tokenId"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	detail, err := loadCopyrightToken(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if to == detail.OwnerAccount {
+		return errorResponse(stub, method, ErrInvalidParam, "to", "approval to current owner: "+to)
+	}
+	if account != detail.OwnerAccount {
+		operator, err := isApprovedForAll(stub, detail.OwnerAccount, account)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if !operator {
+			return errorResponse(stub, method, ErrForbidden, "account", "approve caller is not owner nor approved for all: "+account)
+		}
+	}
+
+	if to == "" {
+		err = stub.DelState(erc721ApprovalKey, tokenId)
+	} else {
+		err = stub.PutStateByte(erc721ApprovalKey, tokenId, []byte(to))
+	}
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to save approval for %s: %s", tokenId, err.Error()))
+	}
+
+	stub.EmitEvent("event_approval", []string{detail.OwnerAccount, to, tokenId})
+	return rec.success(method, tokenId, erc721ApprovalKey+"#"+tokenId, 1, map[string]string{"tokenId": tokenId, "approved": to})
+}
+
+// GetApproved 查询单个通证的转移授权账户, 未授权时返回空串
+// 文档: getApproved({tokenId})
+func (tc *TokenContract) GetApproved(stub shim.CMStubInterface) protogo.Response {
+	const method = "getApproved"
+	tokenId := string(stub.GetArgs()["tokenId"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+	if _, err := loadCopyrightToken(stub, tokenId); err != nil {
+		return failResponse(stub, method, err)
+	}
+	approved, err := getApproved(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	return shim.Success([]byte(approved))
+}
+
+// SetApprovalForAll 授权或取消 operator 转移调用者的全部通证
+// 文档: setApprovalForAll({account, operator, approved})
+func (tc *TokenContract) SetApprovalForAll(stub shim.CMStubInterface) protogo.Response {
+	const method = "setApprovalForAll"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	operator := string(args["operator"])
+	approvedStr := string(args["approved"])
+	if name := missingArg(args, "account", "operator", "approved"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	approved, err := strconv.ParseBool(approvedStr)
+	if err != nil {
+		return errorResponse(stub, method, ErrInvalidParam, "approved", "approved must be bool, got: "+approvedStr)
+	}
+	if operator == account {
+		return errorResponse(stub, method, ErrInvalidParam, "operator", "approve to caller: "+operator)
+	}
+
+	key := erc721OperatorKeyPrefix + account
+	if approved {
+		err = stub.PutStateByte(key, operator, []byte("true"))
+	} else {
+		err = stub.DelState(key, operator)
+	}
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to save operator %s: %s", operator, err.Error()))
+	}
+
+	stub.EmitEvent("event_approval_for_all", []string{account, operator, strconv.FormatBool(approved)})
+	return rec.success(method, operator, key+"#"+operator, 1, map[string]interface{}{"owner": account, "operator": operator, "approved": approved})
+}
+
+// IsApprovedForAll 查询 operator 是否可转移 owner 的全部通证
+// 文档: isApprovedForAll({owner, operator})
+func (tc *TokenContract) IsApprovedForAll(stub shim.CMStubInterface) protogo.Response {
+	const method = "isApprovedForAll"
+	args := stub.GetArgs()
+	if name := missingArg(args, "owner", "operator"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	approved, err := isApprovedForAll(stub, string(args["owner"]), string(args["operator"]))
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	return shim.Success([]byte(strconv.FormatBool(approved)))
+}
+
+// TransferFrom 转移通证
+// 文档: transferFrom({account, from, to, tokenId})
+func (tc *TokenContract) TransferFrom(stub shim.CMStubInterface) protogo.Response {
+	return transferFrom(stub, "transferFrom", false)
+}
+
+// SafeTransferFrom 转移通证, 指定 receiverContract 时回调其 onTokenReceived 方法, 未确认接收则整笔交易失败
+// 文档: safeTransferFrom({account, from, to, tokenId, receiverContract?, data?})
+func (tc *TokenContract) SafeTransferFrom(stub shim.CMStubInterface) protogo.Response {
+	return transferFrom(stub, "safeTransferFrom", true)
+}
+
+// transferFrom 转移通证: from 须为当前持有者, 调用者须为持有者、被授权账户或操作员
+// 作为 ERC-721 接口, 钱包及交易市场按一步转移调用, 不经 buildTokenChangeTx 的两步变更: 调用本身即持有者(或其授权账户)的同意,
+// 受让方的确认由 safeTransferFrom 的接收合约回调提供; 冻结、不可流通、托管及优先购买的限制与两步变更相同
+func transferFrom(stub shim.CMStubInterface, method string, safe bool) protogo.Response {
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	from := string(args["from"])
+	to := string(args["to"])
+	tokenId := string(args["tokenId"])
+	if name := missingArg(args, "account", "from", "to", "tokenId"); name != "" {
+		return missingParam(stub, method, name)
+	}
+
+	detail, err := loadCopyrightToken(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkExpectedVersion(args, detail.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if detail.OwnerAccount != from {
+		return errorResponse(stub, method, ErrForbidden, "from", "from is not the token owner: "+from)
+	}
+	if to == from {
+		return errorResponse(stub, method, ErrInvalidParam, "to", "transfer to current owner: "+to)
+	}
+	allowed, err := canTransfer(stub, detail, account)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if !allowed {
+		return errorResponse(stub, method, ErrForbidden, "account", "caller is not owner nor approved: "+account)
+	}
+	if err := checkTransferable(detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkEscrow(stub, tokenId, from, ""); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := transferOwnership(stub, detail, to); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 接收合约确认接收, 否则整笔交易失败
+	if receiver := string(args["receiverContract"]); safe && receiver != "" {
+		resp := stub.CallContract(receiver, "", map[string][]byte{
+			"method":   []byte(tokenReceivedMethod),
+			"operator": []byte(account),
+			"from":     []byte(from),
+			"to":       []byte(to),
+			"tokenId":  []byte(tokenId),
+			"data":     args["data"],
+		})
+		if resp.Status != shim.OK || string(resp.Payload) != tokenReceivedAck {
+			return errorResponse(stub, method, ErrForbidden, "receiverContract", "receiver contract did not accept token: "+receiver+" "+resp.Message)
+		}
+	}
+
+	stub.EmitEvent("event_transfer", []string{from, to, tokenId})
+	return rec.success(method, tokenId, "publish_token_"+tokenId, detail.RecordVersion, detail)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+)
+
+// 错误码: 合约对外公布的稳定错误分类, 由 describe 方法发布
+const (
+	ErrMissingParam    = "MISSING_PARAM"
+	ErrInvalidParam    = "INVALID_PARAM"
+	ErrInvalidEnum     = "INVALID_ENUM"
+	ErrInvalidDate     = "INVALID_DATE"
+	ErrNotFound        = "NOT_FOUND"
+	ErrAlreadyExists   = "ALREADY_EXISTS"
+	ErrForbidden       = "FORBIDDEN"
+	ErrFrozen          = "FROZEN"
+	ErrNotCirculating  = "NOT_CIRCULATING"
+	ErrBurned          = "BURNED"
+	ErrNotActive       = "NOT_ACTIVE"
+	ErrSumMismatch     = "SUM_MISMATCH"
+	ErrSupplyExhausted = "SUPPLY_EXHAUSTED"
+	ErrContentClaimed  = "CONTENT_CLAIMED"
+	ErrInvalidLink     = "INVALID_LINK"
+	ErrHasDependents   = "HAS_DEPENDENTS"
+	ErrVersionMismatch = "VERSION_MISMATCH"
+	ErrVersionConflict = "VERSION_CONFLICT"
+	ErrPaused          = "PAUSED"
+	ErrInEscrow        = "IN_ESCROW"
+	ErrPaymentFailed   = "PAYMENT_FAILED"
+	ErrDisputed        = "DISPUTED"
+	ErrStateError      = "STATE_ERROR"
+)
+
+// errorCodeMeanings 错误码含义
+var errorCodeMeanings = map[string]string{
+	ErrMissingParam:    "缺少必填参数",
+	ErrInvalidParam:    "参数格式错误(非整数、JSON 无法解析、非 hash256 等)",
+	ErrInvalidEnum:     "枚举值超出取值范围",
+	ErrInvalidDate:     "日期或期限格式错误, 或期限超出上级期限",
+	ErrNotFound:        "记录不存在",
+	ErrAlreadyExists:   "记录已存在, 不可覆盖",
+	ErrForbidden:       "调用账户无权执行该操作, 或多签不完整",
+	ErrFrozen:          "通证已冻结",
+	ErrNotCirculating:  "通证流通标识为不可流通, 不可转移",
+	ErrBurned:          "通证已销毁",
+	ErrNotActive:       "授权或许可不在有效期内",
+	ErrSumMismatch:     "份额之和与原份额不符",
+	ErrSupplyExhausted: "通证类别发行数量已用尽",
+	ErrContentClaimed:  "内容哈希已被其他通证登记",
+	ErrInvalidLink:     "衍生作品关联不合法",
+	ErrHasDependents:   "存在下级通证, 需级联处理",
+	ErrVersionMismatch: "通证版本与请求版本不一致",
+	ErrVersionConflict: "记录版本号与 expectedVersion 不一致, 记录已被其他交易修改",
+	ErrPaused:          "写方法已被管理员暂停(全局或该方法)",
+	ErrInEscrow:        "持有权或份额已被挂单/出价托管锁定, 或处于优先购买通知期",
+	ErrPaymentFailed:   "资产合约付款失败",
+	ErrDisputed:        "通证处于权属争议状态, 裁决前不可转移或修改份额",
+	ErrStateError:      "链上状态读写或序列化失败",
+}
+
+// ContractError 结构化错误, 以 JSON 形式写入 Response.Message
+// 例: {"code":"NOT_FOUND","message":"no token found for tokenId: ...","field":"tokenId","method":"requestTokenInfo"}
+type ContractError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+	Method  string `json:"method,omitempty"`
+}
+
+func (e *ContractError) Error() string {
+	return e.Message
+}
+
+// newError 创建结构化错误, 供校验函数返回, method 由 failResponse 填写
+func newError(code, field, format string, a ...interface{}) *ContractError {
+	return &ContractError{Code: code, Field: field, Message: fmt.Sprintf(format, a...)}
+}
+
+// errorResponse 返回结构化错误响应
+func errorResponse(stub shim.CMStubInterface, method, code, field, message string) protogo.Response {
+	return failResponse(stub, method, &ContractError{Code: code, Field: field, Message: message})
+}
+
+// failResponse 将 error 转为结构化错误响应并记录日志
+// 非 ContractError 的错误来自状态读写或序列化, 归为 STATE_ERROR
+func failResponse(stub shim.CMStubInterface, method string, err error) protogo.Response {
+	ce := ContractError{Code: ErrStateError, Message: err.Error()}
+	if coded, ok := err.(*ContractError); ok {
+		ce = *coded
+	}
+	ce.Method = method
+	stub.Log("[" + method + "] " + ce.Code + ": " + ce.Message)
+	msgBytes, marshalErr := json.Marshal(ce)
+	if marshalErr != nil {
+		return shim.Error("[" + method + "] " + ce.Message)
+	}
+	return shim.Error(string(msgBytes))
+}
+
+// missingArg 返回第一个为空的必填参数名, 均不为空时返回空串
+func missingArg(args map[string][]byte, names ...string) string {
+	for _, name := range names {
+		if len(args[name]) == 0 {
+			return name
+		}
+	}
+	return ""
+}
+
+// missingParam 缺少必填参数的错误响应
+func missingParam(stub shim.CMStubInterface, method, field string) protogo.Response {
+	return errorResponse(stub, method, ErrMissingParam, field, "missing required param: '"+field+"'")
+}
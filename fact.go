@@ -0,0 +1,213 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ContentBinding 内容哈希与版权通证的绑定记录
+type ContentBinding struct {
+	ContentHash string `json:"contentHash"` // 作品内容哈希
+	TokenId     string `json:"tokenId"`     // 登记该内容的版权通证ID
+}
+
+// SaveFact 文件存证
+// 文档: saveFact({fileHash, fileName, time})
+func (tc *TokenContract) SaveFact(stub shim.CMStubInterface) protogo.Response {
+	const method = "saveFact"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	fileHash := strings.ToLower(string(args["fileHash"]))
+	fileName := string(args["fileName"])
+	timeStr := string(args["time"])
+
+	if field := missingArg(args, "fileHash", "fileName", "time"); field != "" {
+		return missingParam(stub, method, field)
+	}
+	if !isHash256(fileHash) {
+		return errorResponse(stub, method, ErrInvalidParam, "fileHash", "fileHash must be hash256 (64 hex chars), got: "+fileHash)
+	}
+	factTime, err := strconv.Atoi(timeStr)
+	if err != nil {
+		return errorResponse(stub, method, ErrInvalidParam, "time", "time must be integer, got: "+timeStr)
+	}
+
+	// 存证不可覆盖
+	storeKey := "fact_" + fileHash
+	oldData, err := stub.GetStateFromKeyByte(storeKey)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("GetState failed: %s", err.Error()))
+	}
+	if len(oldData) > 0 {
+		return errorResponse(stub, method, ErrAlreadyExists, "fileHash", "fact already exists for fileHash: "+fileHash)
+	}
+
+	fact := NewFact(fileHash, fileName, factTime)
+	factBytes, err := json.Marshal(fact)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal fact failed: %s", err.Error()))
+	}
+	if err := stub.PutStateFromKeyByte(storeKey, factBytes); err != nil {
+		return failResponse(stub, method, fmt.Errorf("PutState failed: %s", err.Error()))
+	}
+
+	stub.EmitEvent("event_save_fact", []string{fileHash, fileName})
+	// 存证不可覆盖, 版本号恒为 1
+	return rec.success(method, fileHash, storeKey, 1, fact)
+}
+
+// FindByFileHash 按文件哈希查询存证
+// 文档: findByFileHash({fileHash})
+func (tc *TokenContract) FindByFileHash(stub shim.CMStubInterface) protogo.Response {
+	const method = "findByFileHash"
+	fileHash := strings.ToLower(string(stub.GetArgs()["fileHash"]))
+	if fileHash == "" {
+		return missingParam(stub, method, "fileHash")
+	}
+
+	factBytes, err := stub.GetStateFromKeyByte("fact_" + fileHash)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("GetState failed: %s", err.Error()))
+	}
+	if len(factBytes) == 0 {
+		return errorResponse(stub, method, ErrNotFound, "fileHash", "no fact found for fileHash: "+fileHash)
+	}
+	return shim.Success(factBytes)
+}
+
+// isHash256 判断字符串是否为 hash256 格式(64位十六进制)
+func isHash256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// saveStrictContentMode 根据初始化参数 strictContent 保存严格模式开关
+func saveStrictContentMode(stub shim.CMStubInterface) error {
+	strictStr := string(stub.GetArgs()["strictContent"])
+	if strictStr == "" {
+		return nil
+	}
+	strict, err := strconv.ParseBool(strictStr)
+	if err != nil {
+		return newError(ErrInvalidParam, "strictContent", "strictContent must be bool, got: %s", strictStr)
+	}
+	return putStrictContentMode(stub, strict)
+}
+
+// putStrictContentMode 保存严格模式开关
+func putStrictContentMode(stub shim.CMStubInterface, strict bool) error {
+	value := "0"
+	if strict {
+		value = "1"
+	}
+	if err := stub.PutStateFromKeyByte("config_strict_content", []byte(value)); err != nil {
+		return fmt.Errorf("fail to save strictContent: %s", err.Error())
+	}
+	return nil
+}
+
+// isStrictContentMode 是否开启内容哈希严格校验
+func isStrictContentMode(stub shim.CMStubInterface) (bool, error) {
+	value, err := stub.GetStateFromKeyByte("config_strict_content")
+	if err != nil {
+		return false, fmt.Errorf("fail to GetState for config_strict_content: %s", err.Error())
+	}
+	return string(value) == "1", nil
+}
+
+// checkContentBinding 校验版权通证的内容哈希, 返回归一化后的内容哈希
+// 严格模式下: 内容哈希必须为 hash256, 必须已存证, 且未被其他版权通证登记
+func checkContentBinding(stub shim.CMStubInterface, tokenObj *TokenObject) (string, error) {
+	contentHash := tokenObj.ContentHash
+	if contentHash == "" {
+		contentHash = tokenObj.TokenId
+	}
+	contentHash = strings.ToLower(contentHash)
+
+	strict, err := isStrictContentMode(stub)
+	if err != nil {
+		return "", err
+	}
+	if !strict {
+		return contentHash, nil
+	}
+
+	if !isHash256(contentHash) {
+		return "", newError(ErrInvalidParam, "contentHash", "content hash must be hash256 (64 hex chars), got: %s", contentHash)
+	}
+
+	factBytes, err := stub.GetStateFromKeyByte("fact_" + contentHash)
+	if err != nil {
+		return "", fmt.Errorf("fail to GetState for fact %s: %s", contentHash, err.Error())
+	}
+	if len(factBytes) == 0 {
+		return "", newError(ErrNotFound, "contentHash", "no registered fact matches content hash: %s", contentHash)
+	}
+
+	binding, err := getContentBinding(stub, contentHash)
+	if err != nil {
+		return "", err
+	}
+	if binding != nil && binding.TokenId != tokenObj.TokenId {
+		// 持有者随转让变化, 以通证当前记录为准
+		claimed, err := getTokenDetail(stub, binding.TokenId)
+		if err != nil {
+			return "", err
+		}
+		if claimed == nil {
+			return "", newError(ErrContentClaimed, "contentHash", "content hash %s already claimed by token %s", contentHash, binding.TokenId)
+		}
+		return "", newError(ErrContentClaimed, "contentHash", "content hash %s already claimed by token %s (owner: %s)",
+			contentHash, binding.TokenId, claimed.OwnerAccount)
+	}
+	return contentHash, nil
+}
+
+// getContentBinding 读取内容哈希的绑定记录, 不存在时返回 nil
+func getContentBinding(stub shim.CMStubInterface, contentHash string) (*ContentBinding, error) {
+	bindingBytes, err := stub.GetStateFromKeyByte("content_hash_" + contentHash)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for content hash %s: %s", contentHash, err.Error())
+	}
+	if len(bindingBytes) == 0 {
+		return nil, nil
+	}
+	var binding ContentBinding
+	if err := json.Unmarshal(bindingBytes, &binding); err != nil {
+		return nil, fmt.Errorf("unmarshal content binding error: %s", err.Error())
+	}
+	return &binding, nil
+}
+
+// bindContentHash 记录内容哈希与版权通证的绑定, 已被其他通证登记时保留最先登记者
+func bindContentHash(stub shim.CMStubInterface, contentHash, tokenId string) error {
+	binding, err := getContentBinding(stub, contentHash)
+	if err != nil {
+		return err
+	}
+	if binding != nil && binding.TokenId != tokenId {
+		return nil
+	}
+
+	bindingBytes, err := json.Marshal(&ContentBinding{
+		ContentHash: contentHash,
+		TokenId:     tokenId,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal content binding error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte("content_hash_"+contentHash, bindingBytes); err != nil {
+		return fmt.Errorf("fail to PutState content binding: %s", err.Error())
+	}
+	return nil
+}
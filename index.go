@@ -0,0 +1,69 @@
+package main
+
+import (
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+)
+
+// readIndex 读取以 JSON 数组存储的索引, 不存在时返回空数组
+func readIndex(stub shim.CMStubInterface, key string) ([]string, error) {
+	indexBytes, err := stub.GetStateFromKeyByte(key)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for %s: %s", key, err.Error())
+	}
+	if len(indexBytes) == 0 {
+		return []string{}, nil
+	}
+	var ids []string
+	if err := json.Unmarshal(indexBytes, &ids); err != nil {
+		return nil, fmt.Errorf("fail to Unmarshal index %s: %s", key, err.Error())
+	}
+	return ids, nil
+}
+
+// appendIndex 向索引追加一个ID, 已存在时忽略
+func appendIndex(stub shim.CMStubInterface, key, id string) error {
+	ids, err := readIndex(stub, key)
+	if err != nil {
+		return err
+	}
+	for _, existing := range ids {
+		if existing == id {
+			return nil
+		}
+	}
+	indexBytes, err := json.Marshal(append(ids, id))
+	if err != nil {
+		return fmt.Errorf("fail to marshal index %s: %s", key, err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(key, indexBytes); err != nil {
+		return fmt.Errorf("fail to PutState for %s: %s", key, err.Error())
+	}
+	return nil
+}
+
+// removeIndex 从索引中移除一个ID, 不存在时忽略
+func removeIndex(stub shim.CMStubInterface, key, id string) error {
+	ids, err := readIndex(stub, key)
+	if err != nil {
+		return err
+	}
+	kept := make([]string, 0, len(ids))
+	for _, existing := range ids {
+		if existing != id {
+			kept = append(kept, existing)
+		}
+	}
+	if len(kept) == len(ids) {
+		return nil
+	}
+	indexBytes, err := json.Marshal(kept)
+	if err != nil {
+		return fmt.Errorf("fail to marshal index %s: %s", key, err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(key, indexBytes); err != nil {
+		return fmt.Errorf("fail to PutState for %s: %s", key, err.Error())
+	}
+	return nil
+}
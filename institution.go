@@ -0,0 +1,347 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// 确权机构登记: 管理员维护, 确权信息只接受已登记且有效的确权机构
+// 由机构的登记账户提交, 或附带机构公钥之一的签名
+//
+//	auth_institution_<institutionId> -> AuthInstitution
+//	auth_institution_ids             -> 全部 institutionId, JSON 数组
+//	auth_nonce_<institutionId>#<nonce> -> 使用该 nonce 的 txId, 机构签名不可重放
+const (
+	institutionKeyPrefix = "auth_institution_"
+	institutionIdsKey    = "auth_institution_ids"
+	authNonceKeyPrefix   = "auth_nonce_"
+
+	institutionActive    = "active"
+	institutionSuspended = "suspended"
+)
+
+// AuthInstitution 确权机构
+type AuthInstitution struct {
+	InstitutionId string   `json:"institutionId"`
+	Name          string   `json:"name"`              // 写入 AuthenticationInfo.authenticationInstitudeName
+	Accounts      []string `json:"accounts"`          // 可代表机构提交确权信息的账户
+	PublicKeys    []string `json:"publicKeys"`        // 机构签名公钥, PEM 或 base64 编码的 PKIX 公钥(Ed25519 / ECDSA)
+	Issuers       []string `json:"issuers,omitempty"` // 签发可验证凭证时的 issuer 标识(如 DID), 见 vc.go
+	// 凭证 proof.verificationMethod(如 did:example:ipc#key-1) -> 该方法的公钥, 格式同 publicKeys
+	VerificationMethods map[string]string `json:"verificationMethods,omitempty"`
+	Status              string            `json:"status"` // active / suspended
+	UpdatedBy           string            `json:"updatedBy"`
+	UpdatedAt           string            `json:"updatedAt"`
+	TxId                string            `json:"txId"`
+	RecordVersion       int               `json:"recordVersion"` // 记录版本号, 每次写入递增
+}
+
+// getInstitution 读取确权机构, 不存在时返回 nil
+func getInstitution(stub shim.CMStubInterface, institutionId string) (*AuthInstitution, error) {
+	institutionBytes, err := stub.GetStateFromKeyByte(institutionKeyPrefix + institutionId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState institution %s: %s", institutionId, err.Error())
+	}
+	if len(institutionBytes) == 0 {
+		return nil, nil
+	}
+	var institution AuthInstitution
+	if err := json.Unmarshal(institutionBytes, &institution); err != nil {
+		return nil, fmt.Errorf("unmarshal institution %s error: %s", institutionId, err.Error())
+	}
+	return &institution, nil
+}
+
+func putInstitution(stub shim.CMStubInterface, institution *AuthInstitution) error {
+	institution.RecordVersion++
+	institutionBytes, err := json.Marshal(institution)
+	if err != nil {
+		return fmt.Errorf("marshal institution error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(institutionKeyPrefix+institution.InstitutionId, institutionBytes); err != nil {
+		return fmt.Errorf("fail to PutState institution %s: %s", institution.InstitutionId, err.Error())
+	}
+	return nil
+}
+
+// parsePublicKey 解析 PEM 或 base64 编码的 PKIX 公钥, 只支持 Ed25519 及 ECDSA
+func parsePublicKey(s string) (interface{}, error) {
+	var der []byte
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		der = block.Bytes
+	} else {
+		var err error
+		if der, err = base64.StdEncoding.DecodeString(strings.TrimSpace(s)); err != nil {
+			return nil, fmt.Errorf("public key is neither PEM nor base64: %s", err.Error())
+		}
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	switch pub.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return pub, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// verifySignature 校验签名: Ed25519 直接签名 msg, ECDSA 签名 SHA-256(msg), 接受 ASN.1 或 r||s 格式
+func verifySignature(pub interface{}, msg, sig []byte) bool {
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, msg, sig)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(msg)
+		if ecdsa.VerifyASN1(key, digest[:], sig) {
+			return true
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	}
+	return false
+}
+
+// verifiedBy 机构任一公钥可验证签名
+func (inst *AuthInstitution) verifiedBy(msg, sig []byte) bool {
+	for _, s := range inst.PublicKeys {
+		if pub, err := parsePublicKey(s); err == nil && verifySignature(pub, msg, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// authSigningMessage 确权信息签名的原文, 各字段以换行分隔
+func authSigningMessage(action, tokenId string, info *AuthenticationInfo) []byte {
+	return []byte(strings.Join([]string{action, tokenId, info.InstitutionId, info.AuthenticationId, info.AuthenticatedDate,
+		info.Nonce, info.SignatureExpiresAt}, "\n"))
+}
+
+// resolveInstitution 读取确权信息引用的已登记机构, 并以登记名称填写 authenticationInstitudeName
+// activeOnly 为 true 时机构须为 active
+func resolveInstitution(stub shim.CMStubInterface, field string, info *AuthenticationInfo, activeOnly bool) (*AuthInstitution, error) {
+	if info.InstitutionId == "" {
+		return nil, newError(ErrMissingParam, field, "missing required field: 'institutionId'")
+	}
+	institution, err := getInstitution(stub, info.InstitutionId)
+	if err != nil {
+		return nil, err
+	}
+	if institution == nil {
+		return nil, newError(ErrNotFound, field, "institution not registered: %s", info.InstitutionId)
+	}
+	if activeOnly && institution.Status != institutionActive {
+		return nil, newError(ErrNotActive, field, "institution %s is %s", info.InstitutionId, institution.Status)
+	}
+	info.AuthenticationInstitudeName = institution.Name
+	return institution, nil
+}
+
+// authorize 校验确权信息由机构提交: account 为机构登记账户, 或 signature 可由机构公钥验证
+// 签名须在有效期内, nonce 验证通过后记录, 同一机构下不可再次使用
+func (inst *AuthInstitution) authorize(stub shim.CMStubInterface, field, action, tokenId, account string, info *AuthenticationInfo) error {
+	if containsId(inst.Accounts, account) {
+		return nil
+	}
+	if info.Signature == "" {
+		return newError(ErrForbidden, "account", "%s is not an account of institution %s and no signature is given", account, inst.InstitutionId)
+	}
+	if info.Nonce == "" {
+		return newError(ErrMissingParam, field, "missing required field: 'nonce'")
+	}
+	if info.SignatureExpiresAt == "" {
+		return newError(ErrMissingParam, field, "missing required field: 'signatureExpiresAt'")
+	}
+	if err := validateDate(field+".signatureExpiresAt", info.SignatureExpiresAt); err != nil {
+		return err
+	}
+	expiresAt, _ := parseISODate(info.SignatureExpiresAt, true)
+	now, err := txTime(stub)
+	if err != nil {
+		return err
+	}
+	if now.After(expiresAt) {
+		return newError(ErrNotActive, field, "signature expired at %s", info.SignatureExpiresAt)
+	}
+	sig, err := base64.StdEncoding.DecodeString(info.Signature)
+	if err != nil {
+		return newError(ErrInvalidParam, field, "signature must be base64: %s", err.Error())
+	}
+	if !inst.verifiedBy(authSigningMessage(action, tokenId, info), sig) {
+		return newError(ErrForbidden, field, "signature does not verify against the public keys of institution %s", inst.InstitutionId)
+	}
+	return useAuthNonce(stub, field, inst.InstitutionId, info.Nonce)
+}
+
+// useAuthNonce 记录机构签名的 nonce, 已使用过时返回 ALREADY_EXISTS
+func useAuthNonce(stub shim.CMStubInterface, field, institutionId, nonce string) error {
+	key := authNonceKeyPrefix + institutionId
+	used, err := stub.GetStateByte(key, nonce)
+	if err != nil {
+		return fmt.Errorf("fail to GetState nonce of %s: %s", institutionId, err.Error())
+	}
+	if len(used) > 0 {
+		return newError(ErrAlreadyExists, field, "nonce %s of institution %s was already used in tx %s", nonce, institutionId, string(used))
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	if err := stub.PutStateByte(key, nonce, []byte(txId)); err != nil {
+		return fmt.Errorf("fail to PutState nonce of %s: %s", institutionId, err.Error())
+	}
+	return nil
+}
+
+// RegisterInstitution 管理方法: 登记或更新确权机构, 暂停机构将 status 置为 suspended
+// 文档: registerInstitution({account, institution, expectedVersion?})
+func (tc *TokenContract) RegisterInstitution(stub shim.CMStubInterface) protogo.Response {
+	const method = "registerInstitution"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	if name := missingArg(args, "account", "institution"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkAdmin(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	var input AuthInstitution
+	if err := json.Unmarshal(args["institution"], &input); err != nil {
+		return errorResponse(stub, method, ErrInvalidParam, "institution", "fail to parse institution: "+err.Error())
+	}
+	if input.InstitutionId == "" {
+		return errorResponse(stub, method, ErrMissingParam, "institution", "missing required field: 'institutionId'")
+	}
+	if input.Name == "" {
+		return errorResponse(stub, method, ErrMissingParam, "institution", "missing required field: 'name'")
+	}
+	if input.Status == "" {
+		input.Status = institutionActive
+	} else if input.Status != institutionActive && input.Status != institutionSuspended {
+		return errorResponse(stub, method, ErrInvalidParam, "institution", "status must be 'active' or 'suspended', got: "+input.Status)
+	}
+	if len(input.Accounts) == 0 && len(input.PublicKeys) == 0 {
+		return errorResponse(stub, method, ErrMissingParam, "institution", "at least one of 'accounts' or 'publicKeys' is required")
+	}
+	for _, key := range input.PublicKeys {
+		if _, err := parsePublicKey(key); err != nil {
+			return errorResponse(stub, method, ErrInvalidParam, "institution", "invalid public key: "+err.Error())
+		}
+	}
+	for id, key := range input.VerificationMethods {
+		if _, err := parsePublicKey(key); err != nil {
+			return errorResponse(stub, method, ErrInvalidParam, "institution", "invalid public key of verification method "+id+": "+err.Error())
+		}
+	}
+
+	ids, err := readIndex(stub, institutionIdsKey)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	// 名称唯一, 版本管理前只记录名称的确权信息按名称对应机构
+	for _, id := range ids {
+		if id == input.InstitutionId {
+			continue
+		}
+		other, err := getInstitution(stub, id)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if other == nil {
+			continue
+		}
+		if other.Name == input.Name {
+			return errorResponse(stub, method, ErrAlreadyExists, "institution", "institution name already registered by "+id)
+		}
+		for _, issuer := range input.Issuers {
+			if containsId(other.Issuers, issuer) {
+				return errorResponse(stub, method, ErrAlreadyExists, "institution", "credential issuer already registered by "+id)
+			}
+		}
+	}
+	old, err := getInstitution(stub, input.InstitutionId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	var currentVersion int
+	if old != nil {
+		currentVersion = old.RecordVersion
+	}
+	if err := checkExpectedVersion(args, currentVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if input.TxId, err = stub.GetTxId(); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	if input.Accounts == nil {
+		input.Accounts = []string{}
+	}
+	if input.PublicKeys == nil {
+		input.PublicKeys = []string{}
+	}
+	input.UpdatedBy = account
+	input.UpdatedAt = now.Format(time.RFC3339)
+	input.RecordVersion = currentVersion
+	if err := putInstitution(stub, &input); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if old == nil {
+		if err := putIndex(stub, institutionIdsKey, append(ids, input.InstitutionId)); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+	if _, _, err := audit(stub, method, account, input.InstitutionId, input.Status); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_register_institution", []string{input.InstitutionId, input.Status})
+	return rec.success(method, input.InstitutionId, institutionKeyPrefix+input.InstitutionId, input.RecordVersion, input)
+}
+
+// RequestInstitutions 查询已登记的确权机构
+// 文档: requestInstitutions({})
+func (tc *TokenContract) RequestInstitutions(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestInstitutions"
+	ids, err := readIndex(stub, institutionIdsKey)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	institutions := []AuthInstitution{}
+	for _, id := range ids {
+		institution, err := getInstitution(stub, id)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if institution != nil {
+			institutions = append(institutions, *institution)
+		}
+	}
+	institutionsBytes, err := json.Marshal(institutions)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal institutions error: %s", err.Error()))
+	}
+	return shim.Success(institutionsBytes)
+}
@@ -5,22 +5,22 @@ import (
 	"chainmaker/shim"
 	"encoding/json"
 	"fmt"
-	"log"
 	"math"
 	"strconv"
+	"time"
 )
 
 // TokenContract 合约对象
 type TokenContract struct{}
 
-//Fact 存证对象,存证合约的数据内容
+// Fact 存证对象,存证合约的数据内容
 type Fact struct {
 	FileHash string
 	FileName string
 	Time     int
 }
 
-//NewFact 新建存证对象
+// NewFact 新建存证对象
 func NewFact(fileHash, fileName string, time int) *Fact {
 	return &Fact{
 		FileHash: fileHash,
@@ -39,6 +39,9 @@ type TokenIssue struct {
 	Version       string      `json:"version"`         // 版本号，固定值："v1"或"v2"
 	Roles         []TokenRole `json:"roles,omitempty"` // 控制token权限列表
 	ReferenceFlag int         `json:"reference_flag"`  // 许可/通证标识: v1(0/1等), v2(1/2/3)
+	Issued        int         `json:"issued"`          // 已发行数量
+	Burned        int         `json:"burned"`          // 已销毁数量
+	RecordVersion int         `json:"recordVersion"`   // 记录版本号, 每次写入递增
 }
 
 // TokenRole 描述 TokenIssue 中的 roles 数组的单项
@@ -49,9 +52,10 @@ type TokenRole struct {
 
 // tokenObject 对应文档中一般通证发行时的参数结构
 type TokenObject struct {
-	Flag                int                   `json:"flag"`                // 0=可流通，1=不可流通
-	TokenId             string                `json:"tokenId"`             // 版权通证的唯一标识符 (hash256)
-	AuthenticationInfos []AuthenticationInfo  `json:"authenticationInfos"` // 确权信息列表(可选)
+	Flag                int                   `json:"flag"`                  // 0=可流通，1=不可流通
+	TokenId             string                `json:"tokenId"`               // 版权通证的唯一标识符 (hash256)
+	ContentHash         string                `json:"contentHash,omitempty"` // 作品内容哈希(hash256)，为空时以 TokenId 作为内容哈希
+	AuthenticationInfos []AuthenticationInfo  `json:"authenticationInfos"`   // 确权信息列表(可选)
 	CopyrightType       int                   `json:"copyrightType"`
 	CopyrightGetType    int                   `json:"copyrightGetType"`
 	CopyrightUnits      []CopyrightUnit       `json:"copyrightUnits"` // 权利主体组
@@ -61,7 +65,9 @@ type TokenObject struct {
 	ApprConstraint      []ApprConstraint      `json:"apprConstraint"`
 	LicenseConstraint   []LicenseConstraint   `json:"licenseConstraint"`
 	WorkId              string                `json:"workId"`
+	ParentLinks         []ParentLink          `json:"parentLinks,omitempty"` // 衍生作品: 对原作品版权通证的关联
 	CopyrightStatus     []CopyrightStatus     `json:"copyrightStatus"`
+	Term                string                `json:"term,omitempty"` // 版权保护期, ISO-8601 区间 start/end
 }
 
 // AuthenticationInfo 单个确权信息
@@ -69,6 +75,25 @@ type AuthenticationInfo struct {
 	AuthenticationInstitudeName string `json:"authenticationInstitudeName"`
 	AuthenticationId            string `json:"authenticationId"`
 	AuthenticatedDate           string `json:"authenticatedDate"`
+	InstitutionId               string `json:"institutionId,omitempty"` // 已登记的确权机构, 名称由合约按登记信息填写
+	// Signature 机构签名(base64), 提交账户不是机构账户时必填, 原文见 authSigningMessage
+	Signature string `json:"signature,omitempty"`
+	// 签名时须同时给出一次性的 nonce 及签名有效期 signatureExpiresAt(ISO-8601), 防止重放
+	Nonce              string `json:"nonce,omitempty"`
+	SignatureExpiresAt string `json:"signatureExpiresAt,omitempty"`
+	// 以下由合约填写, 见 auth_info.go
+	Version   int    `json:"version,omitempty"`   // 同一确权编号的版本号, 从 1 开始
+	Status    string `json:"status,omitempty"`    // valid / revoked / superseded
+	Issuer    string `json:"issuer,omitempty"`    // 登记第 1 版的账户
+	UpdatedBy string `json:"updatedBy,omitempty"` // 写入本版本的账户
+	UpdatedAt string `json:"updatedAt,omitempty"`
+	TxId      string `json:"txId,omitempty"`
+	RevokedBy string `json:"revokedBy,omitempty"`
+	RevokedAt string `json:"revokedAt,omitempty"`
+	Reason    string `json:"reason,omitempty"` // 更正或撤销原因
+	// 导入可验证凭证时填写, 见 vc.go
+	CredentialId   string `json:"credentialId,omitempty"`
+	CredentialHash string `json:"credentialHash,omitempty"` // 凭证原文 sha256
 }
 
 // CopyrightUnit 单个版权单元
@@ -119,14 +144,17 @@ type CopyrightStatus struct {
 
 // ApproveToken 授权通证发行结构
 type ApproveToken struct {
-	Publisher          string              `json:"publisher"`          // 发行者账户地址
-	Receiver           string              `json:"receiver"`           // 授权通证的接收者
-	Token              string              `json:"token"`              // ERC-721通证名称
-	TokenId            string              `json:"tokenId"`            // 授权通证id，hash256格式
-	ReferenceID        string              `json:"referenceID"`        // 关联的版权通证tokenId, hash256格式
-	ApproveType        int                 `json:"approveType"`        // 授权类型
-	ApproveConstraints []ApproveConstraint `json:"approveConstraints"` // 约束信息（Array）
-	Duty               []DutyInfo          `json:"duty"`               // 计酬信息（Array）
+	Publisher          string              `json:"publisher"`                // 发行者账户地址
+	Receiver           string              `json:"receiver"`                 // 授权通证的接收者
+	Token              string              `json:"token"`                    // ERC-721通证名称
+	TokenId            string              `json:"tokenId"`                  // 授权通证id，hash256格式
+	ReferenceID        string              `json:"referenceID"`              // 关联的版权通证tokenId, hash256格式
+	ApproveType        int                 `json:"approveType"`              // 授权类型
+	ApproveConstraints []ApproveConstraint `json:"approveConstraints"`       // 约束信息（Array）
+	Duty               []DutyInfo          `json:"duty"`                     // 计酬信息（Array）
+	DerivativeUses     []int               `json:"derivativeUses,omitempty"` // 允许的衍生用途(关联类型: 0=改编,1=翻译,2=汇编,3=节选)
+	Time               string              `json:"time,omitempty"`           // 授权期限, ISO-8601 区间 start/end, 期限外视为失效
+	RecordVersion      int                 `json:"recordVersion"`            // 记录版本号, 每次写入递增
 }
 
 // ApproveConstraint 单个授权约束内容
@@ -156,11 +184,14 @@ type PubTokenTx struct {
 	TokenId     string      `json:"tokenId"`
 	ReferenceId string      `json:"referenceId"`
 	TokenInfos  []TokenInfo `json:"tokenInfos,omitempty"`
+	Time        string      `json:"time,omitempty"` // 许可期限, ISO-8601 区间 start/end, 期限外视为失效
 
 	// 为了演示 ownerSign 的结果，我们额外加一些字段
 	OwnerSigned    bool   `json:"ownerSigned"`    // 是否完成owner签名
 	OwnerAccount   string `json:"ownerAccount"`   // 版权通证拥有者账户
 	OwnerSignature string `json:"ownerSignature"` // owner签名(演示用,可存放签名后的字符串)
+
+	RecordVersion int `json:"recordVersion"` // 记录版本号, 每次写入递增
 }
 
 // TokenInfo 通证的属性
@@ -176,10 +207,23 @@ type TokenDetail struct {
 	Flag                int                  `json:"flag"`                          // v2版本的字段 (1=版权,2=授权,3=操作许可)；v1可忽略
 	OwnerAccount        string               `json:"ownerAccount"`                  // 当前持有者(若是NFT，一般只有一个owner)
 	Frozen              bool                 `json:"frozen"`                        // 是否冻结
+	DisputeId           string               `json:"disputeId,omitempty"`           // 处于争议状态时为受理中的争议ID
 	AuthenticationInfos []AuthenticationInfo `json:"authenticationInfos,omitempty"` // 确权信息数组
 
 	// 这里可扩展更多字段
-	// ...
+	Publisher         string            `json:"publisher,omitempty"`         // 发行账户
+	Token             string            `json:"token,omitempty"`             // 通证名称(类别)
+	CirculationFlag   int               `json:"circulationFlag"`             // 0=可流通，1=不可流通 (TokenObject.Flag)
+	WorkId            string            `json:"workId,omitempty"`            // 作品ID
+	ContentHash       string            `json:"contentHash,omitempty"`       // 作品内容哈希
+	CopyrightType     int               `json:"copyrightType"`               // 作品类型
+	CopyrightGetType  int               `json:"copyrightGetType"`            // 版权取得方式
+	ParentLinks       []ParentLink      `json:"parentLinks,omitempty"`       // 衍生作品的上级关联
+	CopyrightStatus   []CopyrightStatus `json:"copyrightStatus,omitempty"`   // 发行状态信息
+	ConstraintExplain string            `json:"constraintExplain,omitempty"` // 约束说明
+	ConstraintExpand  int               `json:"constraintExpand"`            // 约束扩展标识
+	Term              string            `json:"term,omitempty"`              // 版权保护期
+	PublishedAt       string            `json:"publishedAt,omitempty"`       // 首次发行时间(RFC3339, 区块时间)
 
 	TokenInfos []TokenInfo `json:"tokenInfos,omitempty"` // 可选的属性信息列表
 	// 版权单元数组
@@ -189,6 +233,8 @@ type TokenDetail struct {
 	CopyrightConstraint []CopyrightConstraint `json:"copyrightConstraint,omitempty"`
 	ApprConstraint      []ApprConstraint      `json:"apprConstraint,omitempty"`
 	LicenseConstraint   []LicenseConstraint   `json:"licenseConstraint,omitempty"`
+
+	RecordVersion int `json:"recordVersion"` // 记录版本号, 每次写入递增
 }
 
 // ConstraintUpdate 更新约束时需要的一些数据
@@ -210,16 +256,60 @@ type ConstraintUpdate struct {
 }
 
 // InitContract 合约初始化方法
+// 可选参数 strictContent: "true" 时开启内容哈希严格校验模式
+// 可选参数 regulators: 监管机构账户 JSON 数组
 func (tc *TokenContract) InitContract(stub shim.CMStubInterface) protogo.Response {
+	const method = "InitContract"
+	if err := saveStrictContentMode(stub); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := saveRegulators(stub); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := saveInitConfig(stub); err != nil {
+		return failResponse(stub, method, err)
+	}
+	admins, err := saveInitAdmins(stub, false)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	// 新部署的合约没有旧数据, 直接标记为最新数据版本
+	if err := putSchemaVersion(stub, latestSchemaVersion()); err != nil {
+		return failResponse(stub, method, err)
+	}
+	adminsBytes, _ := json.Marshal(admins)
+	if _, _, err := audit(stub, method, admins[0], "admins", string(adminsBytes)); err != nil {
+		return failResponse(stub, method, err)
+	}
 	return shim.Success([]byte("TokenContract Init Success"))
 }
 
 // UpgradeContract 合约升级方法
 func (tc *TokenContract) UpgradeContract(stub shim.CMStubInterface) protogo.Response {
+	const method = "UpgradeContract"
+	// 早于管理功能部署的合约没有管理员, 升级时按 admins 参数或交易发送者设置
+	admins, err := saveInitAdmins(stub, true)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if admins != nil {
+		adminsBytes, _ := json.Marshal(admins)
+		if _, _, err := audit(stub, method, admins[0], "admins", string(adminsBytes)); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+	// 执行第一批数据迁移, 未完成的部分由管理员调用 runMigration 继续
+	status, err := runMigrations(stub, defaultMigrationBatch)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if !status.Done {
+		return shim.Success([]byte("TokenContract Upgrade Success, migration pending: call runMigration to continue"))
+	}
 	return shim.Success([]byte("TokenContract Upgrade Success"))
 }
 
-//InvokeContract 调用合约
+// InvokeContract 调用合约
 func (tc *TokenContract) InvokeContract(stub shim.CMStubInterface) protogo.Response {
 
 	//获取调用合约哪个方法
@@ -235,6 +325,32 @@ func (tc *TokenContract) InvokeContract(stub shim.CMStubInterface) protogo.Respo
 	// 如果 method == "save", 执行FactContract的save方法
 	// 如果 method == "findByFileHash", 执行FactContract的findByFileHash方法
 	// 如果没有对应的 case 语句，返回错误
+
+	// 暂停期间拒绝写方法, 查询方法及管理方法不受影响
+	if err := checkPaused(stub, method); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 操作员代理调用: 校验委托人的授权, 成功后记录审计(委托人及操作员)
+	operator, err := checkOperator(stub, method)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	resp := tc.dispatch(stub, method)
+	if operator == "" || resp.Status != shim.OK {
+		return resp
+	}
+	spec, _ := findMethodSpec(method)
+	if _, _, err := auditOperator(stub, method, string(stub.GetArgs()[spec.Principal]), operator, string(stub.GetArgs()["tokenId"])); err != nil {
+		return failResponse(stub, method, err)
+	}
+	return resp
+}
+
+// dispatch 按方法名调用合约方法
+func (tc *TokenContract) dispatch(stub shim.CMStubInterface, method string) protogo.Response {
+	// 这里必须写成 switch {case "a": ... [case "b": ...[...]] default:...} 形式
+	// 而且case后面的内容必须是字符串,不能是常量
 	switch method {
 	// 5.1 通证初始化
 	case "buildTokenIssueTx":
@@ -259,6 +375,126 @@ func (tc *TokenContract) InvokeContract(stub shim.CMStubInterface) protogo.Respo
 	case "requestTokenInfo":
 		return tc.RequestTokenInfo(stub)
 
+	// 文件存证 (严格模式下版权通证发行需关联存证)
+	case "saveFact":
+		return tc.SaveFact(stub)
+	case "findByFileHash":
+		return tc.FindByFileHash(stub)
+
+	// 作品登记及作品下通证查询
+	case "buildRegisterWorkTx":
+		return tc.BuildRegisterWorkTx(stub)
+	case "requestWorkInfo":
+		return tc.RequestWorkInfo(stub)
+	case "requestWorkTokens":
+		return tc.RequestWorkTokens(stub)
+
+	// 衍生作品溯源
+	case "requestTokenLineage":
+		return tc.RequestTokenLineage(stub)
+
+	// 通证销毁及销毁记录查询
+	case "buildBurnTokenTx":
+		return tc.BuildBurnTokenTx(stub)
+	case "requestTombstone":
+		return tc.RequestTombstone(stub)
+
+	// 期限到期查询
+	case "requestExpiringTokens":
+		return tc.RequestExpiringTokens(stub)
+
+	// ERC-721 兼容接口
+	case "ownerOf":
+		return tc.OwnerOf(stub)
+	case "balanceOf":
+		return tc.BalanceOf(stub)
+	case "tokenURI":
+		return tc.TokenURI(stub)
+	case "approve":
+		return tc.Approve(stub)
+	case "getApproved":
+		return tc.GetApproved(stub)
+	case "setApprovalForAll":
+		return tc.SetApprovalForAll(stub)
+	case "isApprovedForAll":
+		return tc.IsApprovedForAll(stub)
+	case "transferFrom":
+		return tc.TransferFrom(stub)
+	case "safeTransferFrom":
+		return tc.SafeTransferFrom(stub)
+
+	// 挂单/出价交易
+	case "createOffer":
+		return tc.CreateOffer(stub)
+	case "acceptOffer":
+		return tc.AcceptOffer(stub)
+	case "attestPayment":
+		return tc.AttestPayment(stub)
+	case "cancelOffer":
+		return tc.CancelOffer(stub)
+	case "requestOffers":
+		return tc.RequestOffers(stub)
+
+	// 优先购买权
+	case "setPreemptionRule":
+		return tc.SetPreemptionRule(stub)
+	case "respondPreemption":
+		return tc.RespondPreemption(stub)
+	case "settlePreemption":
+		return tc.SettlePreemption(stub)
+	case "requestPreemptionNotices":
+		return tc.RequestPreemptionNotices(stub)
+
+	// 权属争议
+	case "fileDispute":
+		return tc.FileDispute(stub)
+	case "addDisputeEvidence":
+		return tc.AddDisputeEvidence(stub)
+	case "acceptDispute":
+		return tc.AcceptDispute(stub)
+	case "resolveDispute":
+		return tc.ResolveDispute(stub)
+	case "requestDisputes":
+		return tc.RequestDisputes(stub)
+
+	// 操作员代理授权
+	case "grantOperator":
+		return tc.GrantOperator(stub)
+	case "revokeOperator":
+		return tc.RevokeOperator(stub)
+	case "requestOperatorGrants":
+		return tc.RequestOperatorGrants(stub)
+
+	// 合约管理
+	case "pauseContract":
+		return tc.PauseContract(stub)
+	case "unpauseContract":
+		return tc.UnpauseContract(stub)
+	case "setConfig":
+		return tc.SetConfig(stub)
+	case "rotateAdmin":
+		return tc.RotateAdmin(stub)
+	case "requestAdminInfo":
+		return tc.RequestAdminInfo(stub)
+	case "requestAuditLog":
+		return tc.RequestAuditLog(stub)
+
+	// 确权机构登记
+	case "registerInstitution":
+		return tc.RegisterInstitution(stub)
+	case "requestInstitutions":
+		return tc.RequestInstitutions(stub)
+
+	// 数据迁移
+	case "runMigration":
+		return tc.RunMigration(stub)
+	case "requestMigrationStatus":
+		return tc.RequestMigrationStatus(stub)
+
+	// 方法目录及参数 JSON Schema
+	case "describe":
+		return tc.Describe(stub)
+
 	// 5.5 通证信息修改
 	// (1) 修改通证标识位（冻结/解冻）
 	case "BuildModifyCopyrightTokenFlagTx":
@@ -269,6 +505,8 @@ func (tc *TokenContract) InvokeContract(stub shim.CMStubInterface) protogo.Respo
 	// (2) 修改通证确权信息
 	case "buildModifyAuthenticationInfoTx":
 		return tc.BuildModifyAuthenticationInfoTx(stub)
+	case "buildImportCredentialTx":
+		return tc.BuildImportCredentialTx(stub)
 
 	// (3) 修改版权通证的权利主体组
 	case "buildModifyCopyrightUnitTx":
@@ -281,6 +519,12 @@ func (tc *TokenContract) InvokeContract(stub shim.CMStubInterface) protogo.Respo
 	// (5) 通证变更方法
 	case "buildTokenChangeTx":
 		return tc.BuildTokenChangeTx(stub)
+	case "acceptTokenChange":
+		return tc.AcceptTokenChange(stub)
+	case "cancelTokenChange":
+		return tc.CancelTokenChange(stub)
+	case "requestTokenChange":
+		return tc.RequestTokenChange(stub)
 
 	// (6) 版权份额转让方法
 	case "buildTransferProportionTx":
@@ -288,12 +532,15 @@ func (tc *TokenContract) InvokeContract(stub shim.CMStubInterface) protogo.Respo
 
 	default:
 		// 未匹配到任何已知方法，返回错误
-		return shim.Error("[TokenContract] invalid method: " + method)
+		return errorResponse(stub, method, ErrInvalidParam, "method", "invalid method: "+method)
 	}
 }
 
 // BuildTokenIssueTx 通证初始化（5.1 通证初始化）
 func (tc *TokenContract) BuildTokenIssueTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildTokenIssueTx"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
 	// 读取参数
@@ -307,46 +554,36 @@ func (tc *TokenContract) BuildTokenIssueTx(stub shim.CMStubInterface) protogo.Re
 	referenceFlagStr := string(args["reference_flag"]) // 新增: referenceFlag
 
 	// 基础校验
-	if account == "" || publisher == "" || tokenName == "" ||
-		numberStr == "" || flagStr == "" || version == "" || referenceFlagStr == "" {
-		return shim.Error("some required params are empty, please check 'account','publisher','token','number','flag','version','reference_flag'")
+	if name := missingArg(args, "account", "publisher", "token", "number", "flag", "version", "reference_flag"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 转换 number
 	number, err := strconv.Atoi(numberStr)
 	if err != nil {
-		msg := "[buildTokenIssueTx] number must be integer, got: " + numberStr
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "number", "number must be integer, got: "+numberStr)
 	}
 	// 转换 flag
 	flag, err := strconv.Atoi(flagStr)
 	if err != nil {
-		msg := "[buildTokenIssueTx] flag must be integer, got: " + flagStr
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "flag", "flag must be integer, got: "+flagStr)
 	}
 	// 转换 referenceFlag
 	referenceFlag, err := strconv.Atoi(referenceFlagStr)
 	if err != nil {
-		msg := "[buildTokenIssueTx] reference_flag must be integer, got: " + referenceFlagStr
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "reference_flag", "reference_flag must be integer, got: "+referenceFlagStr)
 	}
 	// 校验 referenceFlag 是否为 1、2 或 3
-	if referenceFlag != 1 && referenceFlag != 2 && referenceFlag != 3 {
-		msg := fmt.Sprintf("[buildTokenIssueTx] reference_flag out of valid range (1:版权通证, 2:授权通证, 3:操作许可通证), got: %d", referenceFlag)
-		stub.Log(msg)
-		return shim.Error(msg)
+	if !validEnum(stub, "referenceFlag", referenceFlag) {
+		return errorResponse(stub, method, ErrInvalidEnum, "reference_flag",
+			fmt.Sprintf("reference_flag out of valid range (1:版权通证, 2:授权通证, 3:操作许可通证), got: %d", referenceFlag))
 	}
 
 	// 解析 roles
 	var roles []TokenRole
 	if rolesStr != "" {
 		if err := json.Unmarshal([]byte(rolesStr), &roles); err != nil {
-			msg := "[buildTokenIssueTx] fail to parse 'roles' json array: " + err.Error()
-			stub.Log(msg)
-			return shim.Error(msg)
+			return errorResponse(stub, method, ErrInvalidParam, "roles", "fail to parse 'roles' json array: "+err.Error())
 		}
 	}
 	// ---------- 【关键】ERC‑721 注册占位 ----------
@@ -369,33 +606,46 @@ func (tc *TokenContract) BuildTokenIssueTx(stub shim.CMStubInterface) protogo.Re
 		ReferenceFlag: referenceFlag,
 	}
 
-	// 序列化存储
-	issueBytes, err := json.Marshal(tokenIssue)
+	// 重复初始化时保留已发行/已销毁计数
+	oldIssue, err := getTokenIssue(stub, tokenName)
 	if err != nil {
-		msg := "[buildTokenIssueTx] fail to marshal TokenIssue: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, err)
 	}
 
-	// 定义存储键
-	key := "token_issue_" + tokenName
-	err = stub.PutStateFromKeyByte(key, issueBytes)
-	if err != nil {
-		msg := "[buildTokenIssueTx] fail to PutState: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+	// 乐观并发控制, 首次初始化时当前版本号为 0
+	var currentVersion int
+	if oldIssue != nil {
+		currentVersion = oldIssue.RecordVersion
+	}
+	if err := checkExpectedVersion(args, currentVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	if oldIssue != nil {
+		if number > 0 && number < oldIssue.Issued {
+			return errorResponse(stub, method, ErrInvalidParam, "number", fmt.Sprintf("number %d less than already issued %d", number, oldIssue.Issued))
+		}
+		tokenIssue.Issued = oldIssue.Issued
+		tokenIssue.Burned = oldIssue.Burned
+		tokenIssue.RecordVersion = oldIssue.RecordVersion
 	}
 
-	// 输出日志
-	stub.Log("[buildTokenIssueTx] success, key=" + key)
+	// 序列化存储, 键为 token_issue_<token>
+	if err := putTokenIssue(stub, tokenIssue); err != nil {
+		return failResponse(stub, method, err)
+	}
+	stub.EmitEvent("event_issue_token", []string{tokenName, account})
 
 	// 返回执行成功
-	return shim.Success([]byte("buildTokenIssueTx success: " + tokenName))
+	return rec.success(method, tokenName, "token_issue_"+tokenName, tokenIssue.RecordVersion, tokenIssue)
 }
 
 // BuildPublishTokenTx 一般通证发行
 // 文档：buildPublishTokenTx({})
 func (tc *TokenContract) BuildPublishTokenTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildPublishTokenTx"
+	rec := recordTx(stub)
+	stub = rec
 	// 1. 获取参数
 	args := stub.GetArgs()
 	publisher := string(args["publisher"])            // 发行账户地址
@@ -405,41 +655,78 @@ func (tc *TokenContract) BuildPublishTokenTx(stub shim.CMStubInterface) protogo.
 	tokenObjectStr := string(args["tokenObject"])     // JSON格式字符串
 
 	// 2. 基础校验
-	if publisher == "" || receiver == "" || tokenName == "" || referenceFlagStr == "" || tokenObjectStr == "" {
-		return shim.Error("[buildPublishTokenTx] missing required params: 'publisher','receiver','token','referenceFlag','tokenObject'")
+	if name := missingArg(args, "publisher", "receiver", "token", "referenceFlag", "tokenObject"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 3. 转换 referenceFlag -> int
 	referenceFlag, err := strconv.Atoi(referenceFlagStr)
 	if err != nil {
-		msg := "[buildPublishTokenTx] referenceFlag must be integer, got: " + referenceFlagStr
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "referenceFlag", "referenceFlag must be integer, got: "+referenceFlagStr)
 	}
 	// 校验 referenceFlag 是否为 1、2 或 3
-	if referenceFlag != 1 && referenceFlag != 2 && referenceFlag != 3 {
-		msg := fmt.Sprintf("[buildTokenIssueTx] reference_flag out of valid range (1:版权通证, 2:授权通证, 3:操作许可通证), got: %d", referenceFlag)
-		stub.Log(msg)
-		return shim.Error(msg)
+	if !validEnum(stub, "referenceFlag", referenceFlag) {
+		return errorResponse(stub, method, ErrInvalidEnum, "referenceFlag",
+			fmt.Sprintf("referenceFlag out of valid range (1:版权通证, 2:授权通证, 3:操作许可通证), got: %d", referenceFlag))
 	}
 
 	// 4. 解析 tokenObject
 	var tokenObj TokenObject
 	err = json.Unmarshal([]byte(tokenObjectStr), &tokenObj)
 	if err != nil {
-		msg := "[buildPublishTokenTx] fail to parse tokenObject JSON, err: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "tokenObject", "fail to parse tokenObject JSON, err: "+err.Error())
+	}
+	if tokenObj.TokenId == "" {
+		return errorResponse(stub, method, ErrMissingParam, "tokenObject.tokenId", "tokenObject missing required field: 'tokenId'")
+	}
+	burned, err := isBurned(stub, tokenObj.TokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if burned {
+		return errorResponse(stub, method, ErrBurned, "tokenObject.tokenId", "tokenId was burned and can not be reissued: "+tokenObj.TokenId)
 	}
 
-	// 校验 copyrightType 的值是否在 0~16 之间
-	if tokenObj.CopyrightType < 0 || tokenObj.CopyrightType > 16 {
-		return shim.Error(fmt.Sprintf("[TokenObject] copyrightType out of valid range (0-16), got: %d", tokenObj.CopyrightType))
+	// 校验 copyrightType 的取值范围(默认 0~16, 可由管理员调整)
+	if !validEnum(stub, "copyrightType", tokenObj.CopyrightType) {
+		return errorResponse(stub, method, ErrInvalidEnum, "tokenObject.copyrightType", fmt.Sprintf("copyrightType out of valid range (%s), got: %d", enumRange(stub, "copyrightType"), tokenObj.CopyrightType))
 	}
 
-	// 校验 copyrightGetType 的值是否在 0~5 之间
-	if tokenObj.CopyrightGetType < 0 || tokenObj.CopyrightGetType > 5 {
-		return shim.Error(fmt.Sprintf("[TokenObject] copyrightGetType out of valid range (0-5), got: %d", tokenObj.CopyrightGetType))
+	// 校验 copyrightGetType 的取值范围(默认 0~5, 可由管理员调整)
+	if !validEnum(stub, "copyrightGetType", tokenObj.CopyrightGetType) {
+		return errorResponse(stub, method, ErrInvalidEnum, "tokenObject.copyrightGetType", fmt.Sprintf("copyrightGetType out of valid range (%s), got: %d", enumRange(stub, "copyrightGetType"), tokenObj.CopyrightGetType))
+	}
+
+	// 校验日期(ISO-8601)及时间区间
+	if err := validateTokenObjectDates(&tokenObj); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 版权通证: 校验作品已登记, 以及内容哈希与存证的绑定关系(严格模式)
+	var contentHash string
+	if referenceFlag == 1 {
+		if tokenObj.WorkId == "" {
+			return errorResponse(stub, method, ErrMissingParam, "tokenObject.workId", "copyright token requires 'workId'")
+		}
+		work, err := getWork(stub, tokenObj.WorkId)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if work == nil {
+			return errorResponse(stub, method, ErrNotFound, "tokenObject.workId", "workId not registered: "+tokenObj.WorkId)
+		}
+
+		contentHash, err = checkContentBinding(stub, &tokenObj)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+
+		// 衍生作品: 需持有每个原作品覆盖该用途的授权
+		if err := checkParentLinks(stub, &tokenObj, receiver); err != nil {
+			return failResponse(stub, method, err)
+		}
+	} else if len(tokenObj.ParentLinks) > 0 {
+		return errorResponse(stub, method, ErrInvalidLink, "tokenObject.parentLinks", "parentLinks only allowed on copyright tokens (referenceFlag=1)")
 	}
 
 	// 5. 这里可根据 referenceFlag 判断通证类型, 做一些业务逻辑分支(可选)
@@ -449,43 +736,108 @@ func (tc *TokenContract) BuildPublishTokenTx(stub shim.CMStubInterface) protogo.
 	stub.Log("[buildPublishTokenTx] referenceFlag: " + referenceFlagStr)
 
 	// 6. 组装要写入状态的结构
-	//    你可以直接把前面解析好的数据打包到一个临时结构，然后序列化写入
-	issueData := map[string]interface{}{
-		"publisher":     publisher,
-		"receiver":      receiver,
-		"tokenName":     tokenName,
-		"referenceFlag": referenceFlag,
-		"tokenObject":   tokenObj,
+	//    以 TokenDetail 存储, 与查询/修改方法读取的结构保持一致
+	//    已发行的通证不可重新发行: 持有者变更须经 buildTokenChangeTx / acceptTokenChange,
+	//    确权信息须经 buildModifyAuthenticationInfoTx, 争议状态须经裁决解除
+	oldDetail, err := getTokenDetail(stub, tokenObj.TokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if oldDetail != nil {
+		return errorResponse(stub, method, ErrAlreadyExists, "tokenObject.tokenId", "tokenId already published: "+tokenObj.TokenId)
 	}
 
-	dataBytes, err := json.Marshal(issueData)
+	// 乐观并发控制, 首次发行时当前版本号为 0
+	if err := checkExpectedVersion(args, 0); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 累计通证类别的已发行数量
+	tokenIssue, err := recordIssued(stub, tokenName)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if tokenIssue == nil {
+		return errorResponse(stub, method, ErrNotFound, "token", "token class not issued: "+tokenName)
+	}
+
+	if err := stampAuthInfos(stub, tokenObj.TokenId, tokenObj.AuthenticationInfos, publisher); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	detail := &TokenDetail{
+		TokenId:             tokenObj.TokenId,
+		Version:             tokenIssue.Version,
+		Flag:                referenceFlag,
+		OwnerAccount:        receiver,
+		AuthenticationInfos: tokenObj.AuthenticationInfos,
+		Publisher:           publisher,
+		Token:               tokenName,
+		CirculationFlag:     tokenObj.Flag,
+		WorkId:              tokenObj.WorkId,
+		ContentHash:         contentHash,
+		CopyrightType:       tokenObj.CopyrightType,
+		CopyrightGetType:    tokenObj.CopyrightGetType,
+		ParentLinks:         tokenObj.ParentLinks,
+		CopyrightStatus:     tokenObj.CopyrightStatus,
+		ConstraintExplain:   tokenObj.ConstraintExplain,
+		ConstraintExpand:    tokenObj.ConstraintExpand,
+		Term:                tokenObj.Term,
+		CopyrightUnits:      tokenObj.CopyrightUnits,
+		CopyrightConstraint: tokenObj.CopyrightConstraint,
+		ApprConstraint:      tokenObj.ApprConstraint,
+		LicenseConstraint:   tokenObj.LicenseConstraint,
+	}
+	now, err := txTime(stub)
 	if err != nil {
-		msg := "[buildPublishTokenTx] marshal data fail: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, err)
 	}
+	detail.PublishedAt = now.Format(time.RFC3339)
 
-	// 7. 存储到区块链状态, key可自定义
-	//    例如: "publish_token_" + tokenObj.TokenId
-	//    如果 tokenObj.TokenId 为空，可以用别的拼装
+	// 7. 存储到区块链状态, key: "publish_token_" + tokenObj.TokenId
 	storeKey := "publish_token_" + tokenObj.TokenId
-	if err := stub.PutStateFromKeyByte(storeKey, dataBytes); err != nil {
-		msg := "[buildPublishTokenTx] PutState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+	if err := putTokenDetail(stub, detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 记录内容哈希 -> 版权通证的绑定, 防止重复登记
+	if contentHash != "" {
+		if err := bindContentHash(stub, contentHash, tokenObj.TokenId); err != nil {
+			return failResponse(stub, method, err)
+		}
 	}
 
-	// 8. (可选) 记录日志，或发事件
-	stub.Log("[buildPublishTokenTx] success with key: " + storeKey)
-	//    发事件： stub.EmitEvent("event_publish_token", []string{tokenName, tokenObj.TokenId})
+	// 持有账户 -> 通证索引, 保护期到期日索引
+	if err := addHolding(stub, receiver, detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := indexExpiry(stub, TokenTypeCopyright, tokenObj.TokenId, tokenObj.Term); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 作品 -> 版权通证索引, 原作品 -> 衍生作品索引
+	if referenceFlag == 1 {
+		if err := appendIndex(stub, "work_tokens_"+tokenObj.WorkId, tokenObj.TokenId); err != nil {
+			return failResponse(stub, method, err)
+		}
+		if err := saveParentLinks(stub, tokenObj.TokenId, tokenObj.ParentLinks); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+
+	// 8. 发事件
+	stub.EmitEvent("event_publish_token", []string{tokenName, tokenObj.TokenId})
 
 	// 9. 返回成功
-	return shim.Success([]byte("[buildPublishTokenTx] success for token: " + tokenObj.TokenId))
+	return rec.success(method, tokenObj.TokenId, storeKey, detail.RecordVersion, detail)
 }
 
 // BuildPublishApproveTokenTx 授权通证发行
 // 对应文档：buildPublishApproveTokenTx({})
 func (tc *TokenContract) BuildPublishApproveTokenTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildPublishApproveTokenTx"
+	rec := recordTx(stub)
+	stub = rec
 	// 1. 从stub获取调用参数
 	args := stub.GetArgs()
 	publisher := string(args["publisher"])        // 发行者账户地址
@@ -498,39 +850,56 @@ func (tc *TokenContract) BuildPublishApproveTokenTx(stub shim.CMStubInterface) p
 	// 这两个是数组结构，用 JSON 解析
 	approveConstraintsStr := string(args["approveConstraints"]) // JSON数组
 	dutyStr := string(args["duty"])                             // JSON数组
+	derivativeUsesStr := string(args["derivativeUses"])         // JSON数组(可选)
+	approveTime := string(args["time"])                         // 授权期限(可选), ISO-8601 区间 start/end
 
 	// 2. 基础校验
-	if publisher == "" || receiver == "" || tokenName == "" ||
-		tokenId == "" || referenceID == "" || approveTypeStr == "" {
-		return shim.Error("[buildPublishApproveTokenTx] missing required params: 'publisher','receiver','token','tokenId','referenceID','approveType'")
+	if name := missingArg(args, "publisher", "receiver", "token", "tokenId", "referenceID", "approveType"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 3. 转换approveType -> int
 	approveType, err := strconv.Atoi(approveTypeStr)
 	if err != nil {
-		msg := "[buildPublishApproveTokenTx] approveType must be integer, got: " + approveTypeStr
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "approveType", "approveType must be integer, got: "+approveTypeStr)
+	}
+	burned, err := isBurned(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if burned {
+		return errorResponse(stub, method, ErrBurned, "tokenId", "tokenId was burned and can not be reissued: "+tokenId)
 	}
 	// === 查询版权通证状态 ===
-	detailKey := "publish_token_" + referenceID
-	detailBytes, err := stub.GetStateFromKeyByte(detailKey)
+	detail, err := getTokenDetail(stub, referenceID)
 	if err != nil {
-		msg := "[buildPublishApproveTokenTx] fail to GetState for referenceID " + referenceID + ": " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to GetState for referenceID %s: %s", referenceID, err.Error()))
 	}
-	if len(detailBytes) == 0 {
-		return shim.Error("[buildPublishApproveTokenTx] referenceID not found: no copyright token found")
+	if detail == nil {
+		return errorResponse(stub, method, ErrNotFound, "referenceID", "referenceID not found: no copyright token found")
+	}
+	// 只有版权通证的持有者或权利主体可以出具授权, 操作员须以其为委托人并在代理范围内发行
+	if !holdsRights(detail, publisher) {
+		return errorResponse(stub, method, ErrForbidden, "publisher", "publisher "+publisher+" is neither the owner nor a copyright unit holder of "+referenceID)
+	}
+
+	// 校验授权期限: 格式合法, 且不超出版权保护期
+	if err := validateWindow("time", approveTime); err != nil {
+		return failResponse(stub, method, err)
+	}
+	within, err := windowWithin(approveTime, detail.Term)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if !within {
+		return errorResponse(stub, method, ErrInvalidDate, "time", "time must lie within the copyright term: "+detail.Term)
 	}
 
 	// 4. 解析 approveConstraints 数组(JSON)
 	var approveConstraints []ApproveConstraint
 	if approveConstraintsStr != "" {
 		if err := json.Unmarshal([]byte(approveConstraintsStr), &approveConstraints); err != nil {
-			msg := "[buildPublishApproveTokenTx] fail to parse 'approveConstraints': " + err.Error()
-			stub.Log(msg)
-			return shim.Error(msg)
+			return errorResponse(stub, method, ErrInvalidParam, "approveConstraints", "fail to parse 'approveConstraints': "+err.Error())
 		}
 	}
 
@@ -538,34 +907,66 @@ func (tc *TokenContract) BuildPublishApproveTokenTx(stub shim.CMStubInterface) p
 	var dutyList []DutyInfo
 	if dutyStr != "" {
 		if err := json.Unmarshal([]byte(dutyStr), &dutyList); err != nil {
-			msg := "[buildPublishApproveTokenTx] fail to parse 'duty': " + err.Error()
-			stub.Log(msg)
-			return shim.Error(msg)
+			return errorResponse(stub, method, ErrInvalidParam, "duty", "fail to parse 'duty': "+err.Error())
 		}
 	}
 	//校验 approveConstraints 的字段值范围
 	for i, constraint := range approveConstraints {
-		if constraint.ApproveStatus < 0 || constraint.ApproveStatus > 1 {
-			return shim.Error(fmt.Sprintf("[buildPublishApproveTokenTx] approveConstraints[%d].ApproveStatus out of range (0-1)", i))
+		field := fmt.Sprintf("approveConstraints[%d]", i)
+		if !validEnum(stub, "approveStatus", constraint.ApproveStatus) {
+			return errorResponse(stub, method, ErrInvalidEnum, field+".approveStatus", field+".ApproveStatus out of range ("+enumRange(stub, "approveStatus")+")")
 		}
-		if constraint.ApproveChannel < 0 || constraint.ApproveChannel > 4 {
-			return shim.Error(fmt.Sprintf("[buildPublishApproveTokenTx] approveConstraints[%d].ApproveChannel out of range (0-4)", i))
+		if !validEnum(stub, "approveChannel", constraint.ApproveChannel) {
+			return errorResponse(stub, method, ErrInvalidEnum, field+".approveChannel", field+".ApproveChannel out of range ("+enumRange(stub, "approveChannel")+")")
 		}
-		if constraint.ApproveArea < 0 || constraint.ApproveArea > 2 {
-			return shim.Error(fmt.Sprintf("[buildPublishApproveTokenTx] approveConstraints[%d].ApproveArea out of range (0-2)", i))
+		if !validEnum(stub, "approveArea", constraint.ApproveArea) {
+			return errorResponse(stub, method, ErrInvalidEnum, field+".approveArea", field+".ApproveArea out of range ("+enumRange(stub, "approveArea")+")")
 		}
-		if constraint.ApproveTime < 0 || constraint.ApproveTime > 3 {
-			return shim.Error(fmt.Sprintf("[buildPublishApproveTokenTx] approveConstraints[%d].ApproveTime out of range (0-3)", i))
+		if !validEnum(stub, "approveTime", constraint.ApproveTime) {
+			return errorResponse(stub, method, ErrInvalidEnum, field+".approveTime", field+".ApproveTime out of range ("+enumRange(stub, "approveTime")+")")
 		}
 	}
 
 	//校验 dutyList 的字段值范围
 	for i, duty := range dutyList {
-		if duty.DistributionMethod < 0 || duty.DistributionMethod > 3 {
-			return shim.Error(fmt.Sprintf("[buildPublishApproveTokenTx] dutyList[%d].DistributionMethod out of range (0-3)", i))
+		if !validEnum(stub, "distributionMethod", duty.DistributionMethod) {
+			return errorResponse(stub, method, ErrInvalidEnum, fmt.Sprintf("duty[%d].distributionMethod", i), fmt.Sprintf("dutyList[%d].DistributionMethod out of range (%s)", i, enumRange(stub, "distributionMethod")))
 		}
 	}
 
+	// 解析并校验 derivativeUses (允许的衍生用途)
+	var derivativeUses []int
+	if derivativeUsesStr != "" {
+		if err := json.Unmarshal([]byte(derivativeUsesStr), &derivativeUses); err != nil {
+			return errorResponse(stub, method, ErrInvalidParam, "derivativeUses", "fail to parse 'derivativeUses': "+err.Error())
+		}
+	}
+	for i, use := range derivativeUses {
+		if !validEnum(stub, "relationType", use) {
+			field := fmt.Sprintf("derivativeUses[%d]", i)
+			return errorResponse(stub, method, ErrInvalidEnum, field, field+" out of range (0-3)")
+		}
+	}
+
+	// 已发行的授权通证不可重新发行, 否则发行者、接收者及关联的版权通证可被他人改写
+	oldApprove, err := getApproveToken(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if oldApprove != nil {
+		return errorResponse(stub, method, ErrAlreadyExists, "tokenId", "tokenId already published: "+tokenId)
+	}
+
+	// 乐观并发控制, 首次发行时当前版本号为 0
+	if err := checkExpectedVersion(args, 0); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 累计通证类别的已发行数量
+	if _, err := recordIssued(stub, tokenName); err != nil {
+		return failResponse(stub, method, err)
+	}
+
 	// 6. 构造 ApproveToken 对象
 	approveToken := &ApproveToken{
 		Publisher:          publisher,
@@ -576,14 +977,15 @@ func (tc *TokenContract) BuildPublishApproveTokenTx(stub shim.CMStubInterface) p
 		ApproveType:        approveType,
 		ApproveConstraints: approveConstraints,
 		Duty:               dutyList,
+		DerivativeUses:     derivativeUses,
+		Time:               approveTime,
+		RecordVersion:      1,
 	}
 
 	// 7. 序列化
 	tokenBytes, err := json.Marshal(approveToken)
 	if err != nil {
-		msg := "[buildPublishApproveTokenTx] fail to marshal ApproveToken: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to marshal ApproveToken: %s", err.Error()))
 	}
 
 	// 8. 构造存储Key，并写入区块链状态
@@ -591,22 +993,30 @@ func (tc *TokenContract) BuildPublishApproveTokenTx(stub shim.CMStubInterface) p
 	storeKey := "approve_token_" + tokenId
 	err = stub.PutStateFromKeyByte(storeKey, tokenBytes)
 	if err != nil {
-		msg := "[buildPublishApproveTokenTx] PutState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState failed: %s", err.Error()))
 	}
 
-	// 9. (可选) 发送合约事件
-	// stub.EmitEvent("event_approve_token", []string{tokenId, referenceID})
+	// 版权通证 -> 授权通证索引, 授权期限到期日索引
+	if err := addApproveRef(stub, referenceID, tokenId); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := indexExpiry(stub, TokenTypeApprove, tokenId, approveTime); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 9. 发送合约事件
+	stub.EmitEvent("event_approve_token", []string{tokenId, referenceID})
 
 	// 10. 返回执行成功
-	stub.Log("[buildPublishApproveTokenTx] success with key: " + storeKey)
-	return shim.Success([]byte("[buildPublishApproveTokenTx] success for token: " + tokenName))
+	return rec.success(method, tokenId, storeKey, approveToken.RecordVersion, approveToken)
 }
 
 // BuildPubTokenTx 通证许可 - 第一步
 // 对应文档: buildPubTokenTx({publisher,receiver,token,tokenId,tokenInfos,referenceId})
 func (tc *TokenContract) BuildPubTokenTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildPubTokenTx"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
 	publisher := string(args["publisher"])
@@ -615,32 +1025,76 @@ func (tc *TokenContract) BuildPubTokenTx(stub shim.CMStubInterface) protogo.Resp
 	tokenId := string(args["tokenId"])
 	referenceId := string(args["referenceId"])  // 关联的授权通证ID
 	tokenInfosStr := string(args["tokenInfos"]) // JSON数组
+	licenseTime := string(args["time"])         // 许可期限(可选), ISO-8601 区间 start/end
 
 	// 校验必填项
-	if publisher == "" || receiver == "" || tokenName == "" || tokenId == "" || referenceId == "" {
-		return shim.Error("[buildPubTokenTx] missing required params: 'publisher','receiver','token','tokenId','referenceId'")
+	if name := missingArg(args, "publisher", "receiver", "token", "tokenId", "referenceId"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 解析 tokenInfos (可选)
 	var tokenInfos []TokenInfo
 	if tokenInfosStr != "" {
 		if err := json.Unmarshal([]byte(tokenInfosStr), &tokenInfos); err != nil {
-			msg := "[buildPubTokenTx] fail to parse tokenInfos: " + err.Error()
-			stub.Log(msg)
-			return shim.Error(msg)
+			return errorResponse(stub, method, ErrInvalidParam, "tokenInfos", "fail to parse tokenInfos: "+err.Error())
 		}
 	}
+	burned, err := isBurned(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if burned {
+		return errorResponse(stub, method, ErrBurned, "tokenId", "tokenId was burned and can not be reissued: "+tokenId)
+	}
+
 	// === 查询授权通证状态 ===
-	detailKey := "publish_token_" + referenceId
-	detailBytes, err := stub.GetStateFromKeyByte(detailKey)
+	approveToken, err := getApproveToken(stub, referenceId)
 	if err != nil {
-		msg := "[buildPublishApproveTokenTx] fail to GetState for referenceID " + referenceId + ": " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to GetState for referenceId %s: %s", referenceId, err.Error()))
 	}
-	if len(detailBytes) == 0 {
-		return shim.Error("[buildPublishApproveTokenTx] referenceID not found: no copyright token found")
+	if approveToken == nil {
+		return errorResponse(stub, method, ErrNotFound, "referenceId", "referenceId not found: no approve token found")
+	}
+
+	// 授权通证须在有效期内, 许可期限不得超出授权期限
+	if err := validateWindow("time", licenseTime); err != nil {
+		return failResponse(stub, method, err)
+	}
+	active, err := windowActive(stub, approveToken.Time)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if !active {
+		return errorResponse(stub, method, ErrNotActive, "referenceId", "approve token is not active at block time, window: "+approveToken.Time)
+	}
+	within, err := windowWithin(licenseTime, approveToken.Time)
+	if err != nil {
+		return failResponse(stub, method, err)
 	}
+	if !within {
+		return errorResponse(stub, method, ErrInvalidDate, "time", "time must lie within the approve token window: "+approveToken.Time)
+	}
+	// 首次发行时累计通证类别的已发行数量
+	oldPubTx, err := getPubTokenTx(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 乐观并发控制, 首次发行时当前版本号为 0
+	var currentVersion int
+	if oldPubTx != nil {
+		currentVersion = oldPubTx.RecordVersion
+	}
+	if err := checkExpectedVersion(args, currentVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	if oldPubTx == nil {
+		if _, err := recordIssued(stub, tokenName); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+
 	// 构造 PubTokenTx 对象
 	pubTx := &PubTokenTx{
 		Publisher:   publisher,
@@ -649,45 +1103,57 @@ func (tc *TokenContract) BuildPubTokenTx(stub shim.CMStubInterface) protogo.Resp
 		TokenId:     tokenId,
 		ReferenceId: referenceId,
 		TokenInfos:  tokenInfos,
+		Time:        licenseTime,
 
 		// 初始状态下，还没有owner签名
 		OwnerSigned:    false,
 		OwnerAccount:   "",
 		OwnerSignature: "",
+		RecordVersion:  1,
+	}
+	if oldPubTx != nil {
+		pubTx.RecordVersion = oldPubTx.RecordVersion + 1
 	}
 
 	// 序列化
 	txBytes, err := json.Marshal(pubTx)
 	if err != nil {
-		msg := "[buildPubTokenTx] fail to marshal pubTx: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to marshal pubTx: %s", err.Error()))
 	}
 
 	// 存储到区块链状态中
 	// key 可以自定义, 比如 "pub_token_tx_" + tokenId
 	storeKey := "pub_token_tx_" + tokenId
 	if err := stub.PutStateFromKeyByte(storeKey, txBytes); err != nil {
-		msg := "[buildPubTokenTx] PutState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState failed: %s", err.Error()))
+	}
+
+	// 授权通证 -> 许可索引, 许可期限到期日索引
+	if err := appendIndex(stub, "license_index_"+referenceId, tokenId); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := indexExpiry(stub, TokenTypeLicense, tokenId, licenseTime); err != nil {
+		return failResponse(stub, method, err)
 	}
 
-	stub.Log("[buildPubTokenTx] success with key: " + storeKey)
+	stub.EmitEvent("event_license_token", []string{tokenId, referenceId})
 
 	// 返回成功
-	return shim.Success([]byte("[buildPubTokenTx] success for tokenId: " + tokenId))
+	return rec.success(method, tokenId, storeKey, pubTx.RecordVersion, pubTx)
 }
 
 // OwnerSign 通证许可 - 第二步(Owner签名)
 // 对应文档: ownerSign({account, secret})
 func (tc *TokenContract) OwnerSign(stub shim.CMStubInterface) protogo.Response {
+	const method = "ownerSign"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
 	account := string(args["account"])
 	secret := string(args["secret"])
 	if account == "" || secret == "" {
-		return shim.Error("[ownerSign] missing required params: 'account','secret'")
+		return missingParam(stub, method, missingArg(args, "account", "secret"))
 	}
 
 	// 这里你可能需要知道要签的是哪个许可交易
@@ -696,27 +1162,35 @@ func (tc *TokenContract) OwnerSign(stub shim.CMStubInterface) protogo.Response {
 	// 假如我们通过 "tokenId" 来区分：
 	tokenId := string(args["tokenId"])
 	if tokenId == "" {
-		return shim.Error("[ownerSign] missing required param: 'tokenId'")
+		return missingParam(stub, method, "tokenId")
 	}
 
 	// 从区块链状态中把 pub_token_tx_ + tokenId 取出来
 	storeKey := "pub_token_tx_" + tokenId
 	oldData, err := stub.GetStateFromKeyByte(storeKey)
 	if err != nil {
-		msg := "[ownerSign] GetState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("GetState failed: %s", err.Error()))
 	}
 	if len(oldData) == 0 {
-		return shim.Error("[ownerSign] no such pubTokenTx for tokenId: " + tokenId)
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no such pubTokenTx for tokenId: "+tokenId)
 	}
 
 	// 反序列化为 PubTokenTx
 	var pubTx PubTokenTx
 	if err := json.Unmarshal(oldData, &pubTx); err != nil {
-		msg := "[ownerSign] Unmarshal pubTx failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("Unmarshal pubTx failed: %s", err.Error()))
+	}
+	if err := checkExpectedVersion(args, pubTx.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	// 期限外的许可视为失效, 不可签名
+	active, err := windowActive(stub, pubTx.Time)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if !active {
+		return errorResponse(stub, method, ErrNotActive, "tokenId", "license is not active at block time, window: "+pubTx.Time)
 	}
 
 	// 这里应该校验一下 account 是否真的是版权通证 owner
@@ -730,35 +1204,36 @@ func (tc *TokenContract) OwnerSign(stub shim.CMStubInterface) protogo.Response {
 	pubTx.OwnerSigned = true
 	pubTx.OwnerAccount = account
 	pubTx.OwnerSignature = signature
+	pubTx.RecordVersion++
 
 	// 重新序列化并写回状态
 	newData, err := json.Marshal(pubTx)
 	if err != nil {
-		msg := "[ownerSign] marshal updated pubTx failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("marshal updated pubTx failed: %s", err.Error()))
 	}
 	if err := stub.PutStateFromKeyByte(storeKey, newData); err != nil {
-		msg := "[ownerSign] PutState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState failed: %s", err.Error()))
 	}
 
-	stub.Log("[ownerSign] success for tokenId: " + tokenId)
-	return shim.Success([]byte("[ownerSign] success, tokenId=" + tokenId))
+	stub.EmitEvent("event_owner_sign", []string{tokenId, account})
+	return rec.success(method, tokenId, storeKey, pubTx.RecordVersion, pubTx)
 }
 
-// RequestAccountToken 查询账户所持有的通证
-// 文档: requestAccountToken({account, version, flag?})
+// RequestAccountToken 查询账户所持有的通证, 按首次发行时间排序并分页返回
+// 文档: requestAccountToken({account, version, flag?, frozen?, token?, workId?, order?, cursor?, pageSize?})
 func (tc *TokenContract) RequestAccountToken(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestAccountToken"
 	args := stub.GetArgs()
 
 	account := string(args["account"])
 	version := string(args["version"])
 	flagStr := string(args["flag"]) // 只在 v2 有效
+	tokenName := string(args["token"])
+	workId := string(args["workId"])
+	order := string(args["order"])
 
-	if account == "" || version == "" {
-		return shim.Error("[RequestAccountToken] missing required params: 'account','version'")
+	if name := missingArg(args, "account", "version"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 默认值(仅当 version=="v2" 时才使用), 若为空则默认=1
@@ -769,116 +1244,110 @@ func (tc *TokenContract) RequestAccountToken(stub shim.CMStubInterface) protogo.
 		} else {
 			f, err := strconv.Atoi(flagStr)
 			if err != nil {
-				return shim.Error("[RequestAccountToken] invalid 'flag' param: " + flagStr)
+				return errorResponse(stub, method, ErrInvalidParam, "flag", "invalid 'flag' param: "+flagStr)
 			}
 			flag = f
 		}
 	}
-
-	// 1. 读取 account_tokens_<account>
-	indexKey := "account_tokens_" + account
-	indexBytes, err := stub.GetStateFromKeyByte(indexKey)
+	frozen, err := parseBoolFilter("frozen", string(args["frozen"]))
 	if err != nil {
-		msg := "[RequestAccountToken] fail to GetState for " + indexKey + ": " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, err)
 	}
-
-	if len(indexBytes) == 0 {
-		// 说明该账户没有任何通证
-		// 根据文档，可以返回一个空数组
-		return shim.Success([]byte("[]"))
+	filter := &accountTokenFilter{version: version, flag: flag, frozen: frozen, token: tokenName, workId: workId}
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		return errorResponse(stub, method, ErrInvalidParam, "order", "order must be 'asc' or 'desc', got: "+order)
+	}
+	pageSize, err := parsePageSize(string(args["pageSize"]))
+	if err != nil {
+		return failResponse(stub, method, err)
 	}
 
-	// 2. indexBytes 中存的是JSON数组，比如 ["tokenId1","tokenId2"...]
-	var tokenIds []string
-	if err := json.Unmarshal(indexBytes, &tokenIds); err != nil {
-		msg := "[RequestAccountToken] fail to Unmarshal index array: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+	// 1. 自游标处读取 account_holding_<account>, 字段以通证发行时间开头, 即按发行时间升序
+	cursorField, err := decodeCursor(string(args["cursor"]), len(holdingSortField(&TokenDetail{}, 0)))
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	total, err := filter.countMatched(stub, account)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	// 多读一条, 用于判断是否还有下一页
+	entries, err := scanHoldings(stub, account, cursorField, order == "desc", maxPageScan+1)
+	if err != nil {
+		return failResponse(stub, method, err)
 	}
 
-	// 3. 遍历tokenIds, 读取每个token详情, 根据version/flag过滤
-	var resultTokens []TokenDetail
-	for _, tid := range tokenIds {
-		detailKey := "publish_token_" + tid
-		detailBytes, err := stub.GetStateFromKeyByte(detailKey)
+	// 2. 读取每个token详情并过滤, 凑满一页或达到扫描上限时返回游标
+	page := AccountTokenPage{Tokens: []TokenDetail{}, Total: total}
+	for i, entry := range entries {
+		if len(page.Tokens) == pageSize || i == maxPageScan {
+			page.NextCursor = encodeCursor(entry.field)
+			break
+		}
+		tid := entry.tokenId
+		detail, err := getTokenDetail(stub, tid)
 		if err != nil {
 			stub.Log("[RequestAccountToken] skip tokenId=" + tid + ", error: " + err.Error())
 			continue
 		}
-		if len(detailBytes) == 0 {
+		if detail == nil {
 			// 数据不存在,跳过
 			continue
 		}
 
-		var detail TokenDetail
-		if err := json.Unmarshal(detailBytes, &detail); err != nil {
-			stub.Log("[RequestAccountToken] skip tokenId=" + tid + ", unmarshal error: " + err.Error())
+		// 按 version & flag 等进行过滤 (若version=="v1"不比较flag)
+		if !filter.match(groupOf(detail)) {
 			continue
 		}
 
-		// 按 version & flag 进行过滤 (若version=="v1"不比较flag)
-		if detail.Version != version {
-			continue
-		}
-		if version == "v2" {
-			if detail.Flag != flag {
-				continue
-			}
-		}
-
 		// 符合筛选条件, 放进结果数组
-		resultTokens = append(resultTokens, detail)
+		page.Tokens = append(page.Tokens, *detail)
 	}
 
-	// 4. 序列化 resultTokens 返回
-	retBytes, err := json.Marshal(resultTokens)
+	// 3. 序列化分页结果返回
+	retBytes, err := json.Marshal(page)
 	if err != nil {
-		msg := "[RequestAccountToken] fail to marshal result: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to marshal result: %s", err.Error()))
 	}
-
 	return shim.Success(retBytes)
 }
 
 // RequestTokenInfo 查询单个通证详情
 // 文档: requestTokenInfo({ tokenId, version })
 func (tc *TokenContract) RequestTokenInfo(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestTokenInfo"
 	args := stub.GetArgs()
 
 	tokenId := string(args["tokenId"])
 	version := string(args["version"])
-	if tokenId == "" || version == "" {
-		return shim.Error("[RequestTokenInfo] missing required param: 'tokenId','version'")
+	if name := missingArg(args, "tokenId", "version"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 1. 读取通证详情
 	detailKey := "publish_token_" + tokenId
 	detailBytes, err := stub.GetStateFromKeyByte(detailKey)
 	if err != nil {
-		msg := "[RequestTokenInfo] fail to GetState for " + detailKey + ": " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to GetState for %s: %s", detailKey, err.Error()))
 	}
 	if len(detailBytes) == 0 {
 		// 不存在
-		return shim.Error("[RequestTokenInfo] no token found for tokenId: " + tokenId)
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no token found for tokenId: "+tokenId)
 	}
 
 	// 2. 反序列化
 	var detail TokenDetail
 	if err := json.Unmarshal(detailBytes, &detail); err != nil {
-		msg := "[RequestTokenInfo] unmarshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("unmarshal error: %s", err.Error()))
 	}
 
 	// 3. 判断版本是否匹配 (如果需要强制校验)
 	if detail.Version != version {
 		// 也可以返回错误,或直接返回该通证(看你业务要求)
-		return shim.Error("[RequestTokenInfo] version mismatch, the token stored version=" + detail.Version + ", but input=" + version)
+		return errorResponse(stub, method, ErrVersionMismatch, "version", "version mismatch, the token stored version="+detail.Version+", but input="+version)
 	}
 
 	// 4. 返回详情
@@ -889,6 +1358,9 @@ func (tc *TokenContract) RequestTokenInfo(stub shim.CMStubInterface) protogo.Res
 // (1) 修改通证标识位(冻结/解冻)
 // 文档: buildModifyCopyrightTokenFlagTx({account, tokenId, flag})
 func (tc *TokenContract) BuildModifyCopyrightTokenFlagTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "BuildModifyCopyrightTokenFlagTx"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
 	account := string(args["account"]) // 有修改权限的账户(监管机构白名单)
@@ -897,38 +1369,40 @@ func (tc *TokenContract) BuildModifyCopyrightTokenFlagTx(stub shim.CMStubInterfa
 
 	// 基础校验
 	if account == "" || tokenId == "" || flagStr == "" {
-		return shim.Error("[BuildModifyCopyrightTokenFlagTx] missing params: account, tokenId, flag")
+		return missingParam(stub, method, missingArg(args, "account", "tokenId", "flag"))
 	}
 	// 转成 int
 	freezeFlag, err := strconv.Atoi(flagStr)
 	if err != nil {
-		return shim.Error("[BuildModifyCopyrightTokenFlagTx] flag must be int (0 or 1)")
+		return errorResponse(stub, method, ErrInvalidParam, "flag", "flag must be int (0 or 1)")
 	}
 
 	// 1. 读取通证详情
 	storeKey := "publish_token_" + tokenId
 	detailBytes, err := stub.GetStateFromKeyByte(storeKey)
 	if err != nil {
-		msg := "[ModifyTokenFlag] fail to GetState, err: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to GetState, err: %s", err.Error()))
 	}
 	if len(detailBytes) == 0 {
-		return shim.Error("[ModifyTokenFlag] token not found for tokenId=" + tokenId)
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "token not found for tokenId="+tokenId)
 	}
 
 	var detail TokenDetail
 	if err := json.Unmarshal(detailBytes, &detail); err != nil {
-		msg := "[ModifyTokenFlag] unmarshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("unmarshal error: %s", err.Error()))
+	}
+	if err := checkExpectedVersion(args, detail.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
 	}
 
-	// 2. 这里可以校验是否 account 在"监管机构白名单"内, 具体逻辑看你业务
-	//    for example:
-	//    if !checkRegulatorWhitelist(account) {
-	//        return shim.Error("[ModifyTokenFlag] account not in regulator whitelist: " + account)
-	//    }
+	// 2. 只有监管机构白名单内的账户可以冻结/解冻
+	regulator, err := isRegulator(stub, account)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if !regulator {
+		return errorResponse(stub, method, ErrForbidden, "account", "account is not a regulator: "+account)
+	}
 
 	// 3. 根据flag=0/1进行冻结或解冻
 	if freezeFlag == 0 {
@@ -940,118 +1414,121 @@ func (tc *TokenContract) BuildModifyCopyrightTokenFlagTx(stub shim.CMStubInterfa
 	}
 
 	// 4. 序列化并写回
+	detail.RecordVersion++
 	updatedBytes, err := json.Marshal(detail)
 	if err != nil {
-		msg := "[ModifyTokenFlag] marshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("marshal error: %s", err.Error()))
 	}
 	if err := stub.PutStateFromKeyByte(storeKey, updatedBytes); err != nil {
-		msg := "[ModifyTokenFlag] PutState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState failed: %s", err.Error()))
+	}
+	if err := regroupHolding(stub, detail.OwnerAccount, &detail); err != nil {
+		return failResponse(stub, method, err)
 	}
 
-	stub.Log("[ModifyTokenFlag] success for tokenId=" + tokenId)
-	return shim.Success([]byte("[ModifyTokenFlag] success"))
+	stub.EmitEvent("event_modify_token_flag", []string{tokenId, flagStr})
+	return rec.success(method, tokenId, storeKey, detail.RecordVersion, detail)
 }
 
+// BuildModifyAuthenticationInfoTx
+// 登记、更正或撤销确权信息, 更正生成新版本并保留旧版本
+// 文档: buildModifyAuthenticationInfoTx({account, tokenId, authenticationInfo, action?, reason?, expectedVersion?})
 func (tc *TokenContract) BuildModifyAuthenticationInfoTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildModifyAuthenticationInfoTx"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
 	account := string(args["account"])                // 有修改通证权限的账户(确权白名单)
 	tokenId := string(args["tokenId"])                // 通证ID
 	authInfoStr := string(args["authenticationInfo"]) // JSON对象
+	action := string(args["action"])                  // add(默认) / correct / revoke
+	reason := string(args["reason"])
 
 	// 校验
 	if account == "" || tokenId == "" || authInfoStr == "" {
-		return shim.Error("[BuildModifyAuthenticationInfoTx] missing params: 'account','tokenId','authenticationInfo'")
+		return missingParam(stub, method, missingArg(args, "account", "tokenId", "authenticationInfo"))
 	}
 
 	// 1. 解析 authenticationInfo
 	var newAuthInfo AuthenticationInfo
 	if err := json.Unmarshal([]byte(authInfoStr), &newAuthInfo); err != nil {
-		msg := "[ModifyAuthInfo] fail to parse authenticationInfo: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "authenticationInfo", "fail to parse authenticationInfo: "+err.Error())
 	}
 
 	// 2. 读取通证详情
 	storeKey := "publish_token_" + tokenId
 	detailBytes, err := stub.GetStateFromKeyByte(storeKey)
 	if err != nil {
-		msg := "[ModifyAuthInfo] fail to GetState, err: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to GetState, err: %s", err.Error()))
 	}
 	if len(detailBytes) == 0 {
-		return shim.Error("[ModifyAuthInfo] token not found for tokenId=" + tokenId)
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "token not found for tokenId="+tokenId)
 	}
 
 	var detail TokenDetail
 	if err := json.Unmarshal(detailBytes, &detail); err != nil {
-		msg := "[ModifyAuthInfo] unmarshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("unmarshal error: %s", err.Error()))
+	}
+	if err := checkExpectedVersion(args, detail.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
 	}
 
-	// 3. 校验 'account' 是否在确权白名单
-	//    if !checkAuthWhitelist(account) {
-	//        return shim.Error("[ModifyAuthInfo] account not in auth-whitelist: " + account)
-	//    }
-
-	// 4. 更新通证的 "AuthenticationInfos"
-	//    (文档说“一次只能上传一个”，可以把它append到列表中，或者做替换等)
-	detail.AuthenticationInfos = append(detail.AuthenticationInfos, newAuthInfo)
+	// 3. 按 action 更新通证的 "AuthenticationInfos", 更正及撤销只能由签发账户进行
+	if err := applyAuthAction(stub, &detail, action, account, reason, newAuthInfo, false); err != nil {
+		return failResponse(stub, method, err)
+	}
 
-	// 5. 序列化并写回
+	// 4. 序列化并写回
+	detail.RecordVersion++
 	updatedBytes, err := json.Marshal(detail)
 	if err != nil {
-		msg := "[ModifyAuthInfo] marshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("marshal error: %s", err.Error()))
 	}
 	if err := stub.PutStateFromKeyByte(storeKey, updatedBytes); err != nil {
-		msg := "[ModifyAuthInfo] PutState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState failed: %s", err.Error()))
 	}
 
-	stub.Log("[ModifyAuthInfo] success for tokenId=" + tokenId)
-	return shim.Success([]byte("[ModifyAuthInfo] success"))
+	if action == "" {
+		action = authActionAdd
+	}
+	stub.EmitEvent("event_modify_auth_info", []string{tokenId, account, action, newAuthInfo.AuthenticationId})
+	return rec.success(method, tokenId, storeKey, detail.RecordVersion, detail)
 }
 
 // BuildModifyCopyrightUnitTx
 // (3) 修改版权通证的权利主体组/修改版权单元
 // 文档: buildModifyCopyrightUnitTx({account, tokenId, address})
 func (tc *TokenContract) BuildModifyCopyrightUnitTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildModifyCopyrightUnitTx"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
 	account := string(args["account"]) // 有修改通证权限的账户, owner
 	tokenId := string(args["tokenId"])
 	newAddress := string(args["address"])
 
-	if account == "" || tokenId == "" || newAddress == "" {
-		return shim.Error("[BuildModifyCopyrightUnitTx] missing params: 'account','tokenId','address'")
+	if name := missingArg(args, "account", "tokenId", "address"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 1. 读取通证详情
 	storeKey := "publish_token_" + tokenId
 	detailBytes, err := stub.GetStateFromKeyByte(storeKey)
 	if err != nil {
-		msg := "[ModifyCopyrightUnit] fail to GetState: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to GetState: %s", err.Error()))
 	}
 	if len(detailBytes) == 0 {
-		return shim.Error("[ModifyCopyrightUnit] no token found for tokenId=" + tokenId)
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no token found for tokenId="+tokenId)
 	}
 
 	var detail TokenDetail
 	if err := json.Unmarshal(detailBytes, &detail); err != nil {
-		msg := "[ModifyCopyrightUnit] unmarshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("unmarshal error: %s", err.Error()))
+	}
+	if err := checkExpectedVersion(args, detail.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
 	}
 
 	// 2. 校验权限：文档说要由owner来修改
@@ -1076,24 +1553,35 @@ func (tc *TokenContract) BuildModifyCopyrightUnitTx(stub shim.CMStubInterface) p
 	if !replaced {
 		// 如果没找到，也许返回错误，也许直接append(看你业务需求)
 		// 这里演示返回错误
-		return shim.Error("[ModifyCopyrightUnit] no matched old address with account=" + account)
+		return errorResponse(stub, method, ErrForbidden, "account", "no matched old address with account="+account)
+	}
+	if err := checkDisputed(&detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkEscrow(stub, tokenId, account, ""); err != nil {
+		return failResponse(stub, method, err)
+	}
+	// 改名不能成为绕过优先购买通知、把份额交给非权利主体的途径; detail 已替换地址, 以替换前的单元判断
+	var before TokenDetail
+	if err := json.Unmarshal(detailBytes, &before); err != nil {
+		return failResponse(stub, method, fmt.Errorf("unmarshal error: %s", err.Error()))
+	}
+	if err := checkPreemptionOutsider(stub, &before, account, newAddress); err != nil {
+		return failResponse(stub, method, err)
 	}
 
 	// 4. 重新序列化写回
+	detail.RecordVersion++
 	updatedBytes, err := json.Marshal(detail)
 	if err != nil {
-		msg := "[ModifyCopyrightUnit] marshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("marshal error: %s", err.Error()))
 	}
 	if err := stub.PutStateFromKeyByte(storeKey, updatedBytes); err != nil {
-		msg := "[ModifyCopyrightUnit] PutState error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState error: %s", err.Error()))
 	}
 
-	stub.Log("[ModifyCopyrightUnit] success for tokenId=" + tokenId)
-	return shim.Success([]byte("[ModifyCopyrightUnit] success"))
+	stub.EmitEvent("event_modify_copyright_unit", []string{tokenId, account, newAddress})
+	return rec.success(method, tokenId, storeKey, detail.RecordVersion, detail)
 }
 
 // BuildModifyConstraintTx
@@ -1101,40 +1589,46 @@ func (tc *TokenContract) BuildModifyCopyrightUnitTx(stub shim.CMStubInterface) p
 // 文档: buildModifyConstraintTx({tokenId, constraint})
 // 这里演示一次性提交所有签名, 若签名不足, 交易失败
 func (tc *TokenContract) BuildModifyConstraintTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildModifyConstraintTx"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
 	tokenId := string(args["tokenId"])
 	constraintStr := string(args["constraint"])
 
-	if tokenId == "" || constraintStr == "" {
-		return shim.Error("[BuildModifyConstraintTx] missing params: 'tokenId','constraint'")
+	if name := missingArg(args, "tokenId", "constraint"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 解析 constraint
 	var constraintUpdate ConstraintUpdate
 	if err := json.Unmarshal([]byte(constraintStr), &constraintUpdate); err != nil {
-		msg := "[BuildModifyConstraintTx] fail to parse constraint: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "constraint", "fail to parse constraint: "+err.Error())
+	}
+	if err := validateWindow("apprConstraint.time", constraintUpdate.Constraint.ApprConstraint.Time); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := validateWindow("licenseConstraint.time", constraintUpdate.Constraint.LicenseConstraint.Time); err != nil {
+		return failResponse(stub, method, err)
 	}
 
 	// 1. 读取 tokenDetail
 	storeKey := "publish_token_" + tokenId
 	detailBytes, err := stub.GetStateFromKeyByte(storeKey)
 	if err != nil {
-		msg := "[BuildModifyConstraintTx] GetState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("GetState failed: %s", err.Error()))
 	}
 	if len(detailBytes) == 0 {
-		return shim.Error("[BuildModifyConstraintTx] no token found for tokenId=" + tokenId)
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no token found for tokenId="+tokenId)
 	}
 
 	var detail TokenDetail
 	if err := json.Unmarshal(detailBytes, &detail); err != nil {
-		msg := "[BuildModifyConstraintTx] unmarshal detail error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("unmarshal detail error: %s", err.Error()))
+	}
+	if err := checkExpectedVersion(args, detail.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
 	}
 
 	// 2. 多签检查: 版权单元记录的所有地址
@@ -1148,7 +1642,7 @@ func (tc *TokenContract) BuildModifyConstraintTx(stub shim.CMStubInterface) prot
 	for _, cu := range detail.CopyrightUnits {
 		if !signerMap[cu.Address] {
 			// 说明 cu.Address 未签名, 多签不通过
-			return shim.Error("[BuildModifyConstraintTx] multi-sign failed, missing address: " + cu.Address)
+			return errorResponse(stub, method, ErrForbidden, "constraint.signers", "multi-sign failed, missing address: "+cu.Address)
 		}
 	}
 
@@ -1170,147 +1664,153 @@ func (tc *TokenContract) BuildModifyConstraintTx(stub shim.CMStubInterface) prot
 	// 存到 detail 里, 视业务而定
 
 	// 4. 写回
+	detail.RecordVersion++
 	updatedBytes, err := json.Marshal(detail)
 	if err != nil {
-		msg := "[BuildModifyConstraintTx] marshal detail error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("marshal detail error: %s", err.Error()))
 	}
 
 	if err := stub.PutStateFromKeyByte(storeKey, updatedBytes); err != nil {
-		msg := "[BuildModifyConstraintTx] PutState error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState error: %s", err.Error()))
 	}
 
-	stub.Log("[BuildModifyConstraintTx] success, tokenId=" + tokenId)
-	return shim.Success([]byte("[BuildModifyConstraintTx] success"))
+	stub.EmitEvent("event_modify_constraint", []string{tokenId})
+	return rec.success(method, tokenId, storeKey, detail.RecordVersion, detail)
 }
 
 // BuildTokenChangeTx 通证变更方法
-// 用于变更版权通证持有者, 修改冻结标志, 以及可选的 tokenInfos
-// 文档: buildTokenChangeTx({ account, tokenId, flags, tokenInfos? })
+// 由当前持有者调用: 修改冻结标志及可选的 tokenInfos, 指定 to 时发起持有者变更, 受让方 acceptTokenChange 后生效
+// flags 与 to 不可同时传入, 防止同一调用中先解冻再发起变更
+// 文档: buildTokenChangeTx({ account, tokenId, flags?, tokenInfos?, to?, expiresAt? })
 func (tc *TokenContract) BuildTokenChangeTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildTokenChangeTx"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
-	account := string(args["account"])          // 有修改权限的账户（这里视为新的持有者）
+	account := string(args["account"])          // 当前持有者
 	tokenId := string(args["tokenId"])          // 通证ID
 	flagsStr := string(args["flags"])           // 0=解冻,1=冻结
 	tokenInfosStr := string(args["tokenInfos"]) // JSON数组,可选
 
-	if account == "" || tokenId == "" {
-		return shim.Error("[BuildTokenChangeTx] missing required params: 'account','tokenId'")
+	if name := missingArg(args, "account", "tokenId"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if flagsStr != "" && len(args["to"]) > 0 {
+		return errorResponse(stub, method, ErrInvalidParam, "flags", "flags and to can not be given in the same call")
 	}
 
 	// 1. 读取通证详情
 	storeKey := "publish_token_" + tokenId
 	detailBytes, err := stub.GetStateFromKeyByte(storeKey)
 	if err != nil {
-		msg := "[BuildTokenChangeTx] fail to GetState: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("fail to GetState: %s", err.Error()))
 	}
 	if len(detailBytes) == 0 {
-		return shim.Error("[BuildTokenChangeTx] no token found for tokenId=" + tokenId)
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no token found for tokenId="+tokenId)
 	}
 
 	var detail TokenDetail
 	if err := json.Unmarshal(detailBytes, &detail); err != nil {
-		msg := "[BuildTokenChangeTx] unmarshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("unmarshal error: %s", err.Error()))
+	}
+	if err := checkExpectedVersion(args, detail.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
 	}
 
-	// 2. 校验权限: 提取合约调用者的address，判断“account”是否真有修改该token的权限？
-	//    业务可自定义. 这里示例直接允许
-	//    if detail.OwnerAccount != ??? { ... }
+	// 2. 校验权限: 只有当前持有者可以变更
+	if detail.OwnerAccount != account {
+		return errorResponse(stub, method, ErrForbidden, "account", "only the token owner can change the token: "+detail.OwnerAccount)
+	}
 
-	// 3. 若 flagsStr 不空, 解析并进行冻结/解冻操作
+	// 3. 若 flagsStr 不空, 解析并进行冻结操作; 解冻只能由监管机构经 BuildModifyCopyrightTokenFlagTx 进行
 	if flagsStr != "" {
 		flags, err := strconv.Atoi(flagsStr)
 		if err != nil {
-			msg := "[BuildTokenChangeTx] invalid flags param: " + flagsStr
-			stub.Log(msg)
-			return shim.Error(msg)
+			return errorResponse(stub, method, ErrInvalidParam, "flags", "invalid flags param: "+flagsStr)
 		}
-		if flags == 0 {
-			detail.Frozen = false
-		} else {
+		if flags == 0 && detail.Frozen {
+			return errorResponse(stub, method, ErrFrozen, "flags", "token is frozen, only a regulator can unfreeze it: "+tokenId)
+		}
+		if flags != 0 {
 			detail.Frozen = true
 		}
 	}
 
-	// 4. 更新 ownerAccount => 以 “account” 作为新的持有者
-	detail.OwnerAccount = account
-
-	// 5. 如果有 tokenInfos, 解析并更新
+	// 4. 如果有 tokenInfos, 解析并更新
 	if tokenInfosStr != "" {
 		var tInfos []TokenInfo
 		if err := json.Unmarshal([]byte(tokenInfosStr), &tInfos); err != nil {
-			msg := "[BuildTokenChangeTx] fail to parse tokenInfos: " + err.Error()
-			stub.Log(msg)
-			return shim.Error(msg)
+			return errorResponse(stub, method, ErrInvalidParam, "tokenInfos", "fail to parse tokenInfos: "+err.Error())
 		}
 		// 可以覆盖或追加, 看你业务需求
 		detail.TokenInfos = tInfos
 	}
 
+	// 5. 指定 to 时发起持有者变更, OwnerAccount 待受让方接受后才变更
+	if to := string(args["to"]); to != "" {
+		if _, err := openTokenChange(stub, &detail, to, string(args["expiresAt"])); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+
 	// 6. 写回
+	detail.RecordVersion++
 	updatedBytes, err := json.Marshal(detail)
 	if err != nil {
-		msg := "[BuildTokenChangeTx] marshal error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("marshal error: %s", err.Error()))
 	}
 	if err := stub.PutStateFromKeyByte(storeKey, updatedBytes); err != nil {
-		msg := "[BuildTokenChangeTx] PutState error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState error: %s", err.Error()))
+	}
+	if err := regroupHolding(stub, detail.OwnerAccount, &detail); err != nil {
+		return failResponse(stub, method, err)
 	}
 
-	stub.Log("[BuildTokenChangeTx] success, tokenId=" + tokenId)
-	return shim.Success([]byte("[BuildTokenChangeTx] success"))
+	stub.EmitEvent("event_token_change", []string{tokenId, account})
+	return rec.success(method, tokenId, storeKey, detail.RecordVersion, detail)
 }
 
 // BuildTransferProportionTx
 // (6) 版权份额转让
-// 文档: buildTransferProportionTx({ account, tokenId, copyrightUnits })
+// 通证设有优先购买规则且受让方含非现有权利主体时, 发出优先购买通知, 通知期满后由 settlePreemption 完成转让
+// 文档: buildTransferProportionTx({ account, tokenId, copyrightUnits, terms?, price?, asset? })
 func (tc *TokenContract) BuildTransferProportionTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildTransferProportionTx"
+	rec := recordTx(stub)
+	stub = rec
 	args := stub.GetArgs()
 
 	account := string(args["account"]) // 需转移的 address 账户
 	tokenId := string(args["tokenId"])
 	cuStr := string(args["copyrightUnits"]) // JSON数组
 
-	if account == "" || tokenId == "" || cuStr == "" {
-		return shim.Error("[BuildTransferProportionTx] missing params: 'account','tokenId','copyrightUnits'")
+	if name := missingArg(args, "account", "tokenId", "copyrightUnits"); name != "" {
+		return missingParam(stub, method, name)
 	}
 
 	// 1. 解析copyrightUnits
 	var newUnits []CopyrightUnit
 	if err := json.Unmarshal([]byte(cuStr), &newUnits); err != nil {
-		msg := "[BuildTransferProportionTx] fail to parse 'copyrightUnits': " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return errorResponse(stub, method, ErrInvalidParam, "copyrightUnits", "fail to parse 'copyrightUnits': "+err.Error())
 	}
 
 	// 2. 读取 tokenDetail
 	storeKey := "publish_token_" + tokenId
 	detailBytes, err := stub.GetStateFromKeyByte(storeKey)
 	if err != nil {
-		msg := "[BuildTransferProportionTx] GetState failed: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("GetState failed: %s", err.Error()))
 	}
 	if len(detailBytes) == 0 {
-		return shim.Error("[BuildTransferProportionTx] no token found for tokenId=" + tokenId)
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no token found for tokenId="+tokenId)
 	}
 
 	var detail TokenDetail
 	if err := json.Unmarshal(detailBytes, &detail); err != nil {
-		msg := "[BuildTransferProportionTx] unmarshal detail error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("unmarshal detail error: %s", err.Error()))
+	}
+	if err := checkExpectedVersion(args, detail.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
 	}
 
 	// 3. 找到 'account' 对应的版权单元, 获取其 proportion, 并删除/清零
@@ -1326,7 +1826,13 @@ func (tc *TokenContract) BuildTransferProportionTx(stub shim.CMStubInterface) pr
 	}
 	_ = oldProportionStr
 	if foundIndex < 0 {
-		return shim.Error("[BuildTransferProportionTx] the account does not hold any proportion: " + account)
+		return errorResponse(stub, method, ErrForbidden, "account", "the account does not hold any proportion: "+account)
+	}
+	if err := checkDisputed(&detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkEscrow(stub, tokenId, account, ""); err != nil {
+		return failResponse(stub, method, err)
 	}
 
 	// 移除 oldUnits[foundIndex]
@@ -1342,7 +1848,7 @@ func (tc *TokenContract) BuildTransferProportionTx(stub shim.CMStubInterface) pr
 	//    这里示例中假设 oldProportion 是一个可解析的浮点数
 	oldP, err := strconv.ParseFloat(oldProportion, 64)
 	if err != nil {
-		return shim.Error("[BuildTransferProportionTx] old proportion is not numeric: " + oldProportion)
+		return failResponse(stub, method, fmt.Errorf("old proportion is not numeric: %s", oldProportion))
 	}
 
 	// 5. 把 newUnits 追加到 detail, 并验证 newUnits 合计
@@ -1350,7 +1856,7 @@ func (tc *TokenContract) BuildTransferProportionTx(stub shim.CMStubInterface) pr
 	for _, nu := range newUnits {
 		p, err := strconv.ParseFloat(nu.Proportion, 64)
 		if err != nil {
-			return shim.Error("[BuildTransferProportionTx] new proportion invalid: " + nu.Proportion)
+			return errorResponse(stub, method, ErrInvalidParam, "copyrightUnits", "new proportion invalid: "+nu.Proportion)
 		}
 		sumNew += p
 	}
@@ -1358,7 +1864,22 @@ func (tc *TokenContract) BuildTransferProportionTx(stub shim.CMStubInterface) pr
 	// 如果你想严格要求 sumNew == oldP，可以检查:
 	if math.Abs(sumNew-oldP) > 1e-9 {
 		// 不相等
-		return shim.Error("[BuildTransferProportionTx] sum of new proportions != old proportion to be transferred")
+		return errorResponse(stub, method, ErrSumMismatch, "copyrightUnits", "sum of new proportions != old proportion to be transferred")
+	}
+
+	days, err := noticeDays(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if days > 0 {
+		// detail 此时已移除转让方的版权单元, 其余即享有优先购买权的权利主体
+		notice, err := openPreemptionNotice(stub, &detail, account, oldP, newUnits, string(args["terms"]), string(args["price"]), string(args["asset"]), days)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if notice != nil {
+			return rec.success(method, notice.NoticeId, preemptionNoticeKeyPrefix+notice.NoticeId, notice.RecordVersion, notice)
+		}
 	}
 
 	// 把 newUnits 追加到 detail
@@ -1368,28 +1889,16 @@ func (tc *TokenContract) BuildTransferProportionTx(stub shim.CMStubInterface) pr
 	)
 
 	// 6. 写回
+	detail.RecordVersion++
 	updatedBytes, err := json.Marshal(detail)
 	if err != nil {
-		msg := "[BuildTransferProportionTx] marshal detail error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("marshal detail error: %s", err.Error()))
 	}
 
 	if err := stub.PutStateFromKeyByte(storeKey, updatedBytes); err != nil {
-		msg := "[BuildTransferProportionTx] PutState error: " + err.Error()
-		stub.Log(msg)
-		return shim.Error(msg)
+		return failResponse(stub, method, fmt.Errorf("PutState error: %s", err.Error()))
 	}
 
-	stub.Log("[BuildTransferProportionTx] success for tokenId=" + tokenId)
-	return shim.Success([]byte("[BuildTransferProportionTx] success"))
-}
-
-func main() {
-
-	//运行合约
-	err := shim.Start(new(TokenContract))
-	if err != nil {
-		log.Fatal(err)
-	}
+	stub.EmitEvent("event_transfer_proportion", []string{tokenId, account})
+	return rec.success(method, tokenId, storeKey, detail.RecordVersion, detail)
 }
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stubEvent 合约发出的事件
+type stubEvent struct {
+	Topic string   `json:"topic"`
+	Data  []string `json:"data"`
+}
+
+// memStub 内存版 CMStubInterface, 单元测试(stub_test.go)与本地模拟器(sim_stub.go)共用
+// 未实现的方法由嵌入的接口兜底(调用时 panic)
+type memStub struct {
+	shim.CMStubInterface
+
+	args      map[string][]byte
+	state     map[string][]byte
+	events    []stubEvent
+	logs      []string
+	sender    string
+	orgId     string
+	txId      string
+	timestamp int64
+	height    int
+
+	contracts map[string]func(args map[string][]byte) protogo.Response // CallContract 的被调合约
+}
+
+func newMemStub() *memStub {
+	return &memStub{state: make(map[string][]byte), orgId: "org1"}
+}
+
+// snapshot 复制当前状态
+func (s *memStub) snapshot() map[string][]byte {
+	copied := make(map[string][]byte, len(s.state))
+	for k, v := range s.state {
+		copied[k] = v
+	}
+	return copied
+}
+
+// commit 执行一笔交易, 失败时与链上一致丢弃本次交易的写入及事件
+func (s *memStub) commit(call func() protogo.Response) protogo.Response {
+	before := s.snapshot()
+	resp := call()
+	if resp.Status != shim.OK {
+		s.state = before
+		s.events = nil
+	}
+	return resp
+}
+
+// setArgs 设置本次调用的参数, method 非空时写入 method 参数
+func (s *memStub) setArgs(method string, args map[string]string) {
+	s.args = make(map[string][]byte, len(args)+1)
+	for k, v := range args {
+		s.args[k] = []byte(v)
+	}
+	if method != "" {
+		s.args["method"] = []byte(method)
+	}
+	s.events = nil
+	s.logs = nil
+}
+
+// keysWithPrefix 按字典序返回带前缀的全部状态键
+func (s *memStub) keysWithPrefix(prefix string) []string {
+	var keys []string
+	for k := range s.state {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *memStub) GetArgs() map[string][]byte {
+	return s.args
+}
+
+func (s *memStub) GetStateFromKeyByte(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *memStub) GetStateFromKey(key string) (string, error) {
+	return string(s.state[key]), nil
+}
+
+func (s *memStub) PutStateFromKeyByte(key string, value []byte) error {
+	s.state[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *memStub) PutStateFromKey(key string, value string) error {
+	s.state[key] = []byte(value)
+	return nil
+}
+
+func (s *memStub) DelStateFromKey(key string) error {
+	delete(s.state, key)
+	return nil
+}
+
+func (s *memStub) GetStateByte(key, field string) ([]byte, error) {
+	return s.state[key+"#"+field], nil
+}
+
+func (s *memStub) GetState(key, field string) (string, error) {
+	return string(s.state[key+"#"+field]), nil
+}
+
+func (s *memStub) PutStateByte(key, field string, value []byte) error {
+	s.state[key+"#"+field] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *memStub) PutState(key, field string, value string) error {
+	s.state[key+"#"+field] = []byte(value)
+	return nil
+}
+
+func (s *memStub) DelState(key, field string) error {
+	delete(s.state, key+"#"+field)
+	return nil
+}
+
+func (s *memStub) GetSenderAddr() (string, error) {
+	return s.sender, nil
+}
+
+func (s *memStub) GetSenderPk() (string, error) {
+	return s.sender, nil
+}
+
+func (s *memStub) GetSenderOrgId() (string, error) {
+	return s.orgId, nil
+}
+
+func (s *memStub) GetSenderRole() (string, error) {
+	return "client", nil
+}
+
+func (s *memStub) GetCreatorPk() (string, error) {
+	return s.sender, nil
+}
+
+func (s *memStub) GetCreatorOrgId() (string, error) {
+	return s.orgId, nil
+}
+
+func (s *memStub) GetCreatorRole() (string, error) {
+	return "admin", nil
+}
+
+// CallContract 调用 contracts 中登记的被调合约, 未登记时返回错误
+func (s *memStub) CallContract(contractName string, contractVersion string, args map[string][]byte) protogo.Response {
+	if handler, ok := s.contracts[contractName]; ok {
+		return handler(args)
+	}
+	return shim.Error("contract not found: " + contractName)
+}
+
+func (s *memStub) GetTxId() (string, error) {
+	return s.txId, nil
+}
+
+func (s *memStub) GetTxTimeStamp() (string, error) {
+	return strconv.FormatInt(s.timestamp, 10), nil
+}
+
+func (s *memStub) GetBlockHeight() (int, error) {
+	return s.height, nil
+}
+
+func (s *memStub) EmitEvent(topic string, data []string) {
+	s.events = append(s.events, stubEvent{Topic: topic, Data: data})
+}
+
+func (s *memStub) Log(message string) {
+	s.logs = append(s.logs, message)
+}
+
+// memIterator 按字典序遍历的状态迭代器
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+// iterate 返回满足条件的状态键(key 或 key#field)迭代器
+func (s *memStub) iterate(match func(key, field string) bool) *memIterator {
+	iter := &memIterator{}
+	for _, k := range s.keysWithPrefix("") {
+		key, field := splitStateKey(k)
+		if match(key, field) {
+			iter.keys = append(iter.keys, k)
+			iter.values = append(iter.values, s.state[k])
+		}
+	}
+	return iter
+}
+
+// splitStateKey 拆分 key#field 形式的状态键
+func splitStateKey(k string) (string, string) {
+	if i := strings.Index(k, "#"); i >= 0 {
+		return k[:i], k[i+1:]
+	}
+	return k, ""
+}
+
+func (s *memStub) NewIterator(startKey, limitKey string) (shim.ResultSetKV, error) {
+	return s.iterate(func(k, f string) bool {
+		return k >= startKey && k < limitKey
+	}), nil
+}
+
+func (s *memStub) NewIteratorWithField(key, startField, limitField string) (shim.ResultSetKV, error) {
+	return s.iterate(func(k, f string) bool {
+		return k == key && f != "" && f >= startField && f < limitField
+	}), nil
+}
+
+func (s *memStub) NewIteratorPrefixWithKeyField(key, field string) (shim.ResultSetKV, error) {
+	return s.iterate(func(k, f string) bool {
+		return k == key && f != "" && strings.HasPrefix(f, field)
+	}), nil
+}
+
+func (s *memStub) NewIteratorPrefixWithKey(key string) (shim.ResultSetKV, error) {
+	return s.iterate(func(k, f string) bool {
+		return strings.HasPrefix(k, key)
+	}), nil
+}
+
+func (it *memIterator) HasNext() bool {
+	return it.pos < len(it.keys)
+}
+
+func (it *memIterator) Next() (string, string, []byte, error) {
+	key, field := splitStateKey(it.keys[it.pos])
+	value := it.values[it.pos]
+	it.pos++
+	return key, field, value, nil
+}
+
+func (it *memIterator) Close() (bool, error) {
+	return true, nil
+}
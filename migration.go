@@ -0,0 +1,397 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	schemaVersionKey   = "schema_version"   // 已完成迁移的数据版本
+	migrationStatusKey = "migration_status" // 进行中迁移步骤的进度
+
+	defaultMigrationBatch = 200  // UpgradeContract 及未指定 batchSize 时每批处理的记录数
+	maxMigrationBatch     = 2000 // 单次 runMigration 处理的记录数上限
+)
+
+// migrationStep 迁移步骤, 按 Version 升序执行
+// run 自 cursor 处理至多 budget 条记录, 返回新的游标、处理条数及本步骤是否已完成
+type migrationStep struct {
+	Version int
+	Name    string
+	run     func(stub shim.CMStubInterface, cursor string, budget int) (string, int, bool, error)
+}
+
+// migrationSteps 迁移步骤注册表, 修改 TokenDetail 等结构或状态布局时在末尾追加, 版本号递增
+var migrationSteps = []migrationStep{
+	{Version: 1, Name: "token_detail_shape", run: convertLegacyTokenDetails},
+	{Version: 2, Name: "index_layout", run: migrateIndexLayout},
+	{Version: 3, Name: "record_version", run: backfillRecordVersion},
+}
+
+// latestSchemaVersion 当前合约代码要求的数据版本
+func latestSchemaVersion() int {
+	return migrationSteps[len(migrationSteps)-1].Version
+}
+
+// MigrationStatus 数据迁移进度
+type MigrationStatus struct {
+	SchemaVersion int      `json:"schemaVersion"`      // 已完成迁移的数据版本
+	TargetVersion int      `json:"targetVersion"`      // 当前合约代码要求的数据版本
+	Step          string   `json:"step,omitempty"`     // 进行中的迁移步骤
+	Cursor        string   `json:"cursor,omitempty"`   // 进行中步骤的续传位置
+	Processed     int      `json:"processed"`          // 进行中步骤已处理的记录数
+	Pending       []string `json:"pending"`            // 尚未完成的迁移步骤
+	Done          bool     `json:"done"`               // 数据已迁移至最新版本
+	LastTxId      string   `json:"lastTxId,omitempty"` // 最近一次执行迁移的交易
+}
+
+// getSchemaVersion 读取数据版本, 引入迁移框架之前部署的合约为 0
+func getSchemaVersion(stub shim.CMStubInterface) (int, error) {
+	versionBytes, err := stub.GetStateFromKeyByte(schemaVersionKey)
+	if err != nil {
+		return 0, fmt.Errorf("fail to GetState for %s: %s", schemaVersionKey, err.Error())
+	}
+	if len(versionBytes) == 0 {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(string(versionBytes))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", schemaVersionKey, string(versionBytes))
+	}
+	return version, nil
+}
+
+func putSchemaVersion(stub shim.CMStubInterface, version int) error {
+	if err := stub.PutStateFromKeyByte(schemaVersionKey, []byte(strconv.Itoa(version))); err != nil {
+		return fmt.Errorf("fail to PutState for %s: %s", schemaVersionKey, err.Error())
+	}
+	return nil
+}
+
+// getMigrationStatus 读取迁移进度, 并补全待执行步骤
+func getMigrationStatus(stub shim.CMStubInterface) (*MigrationStatus, error) {
+	status := &MigrationStatus{}
+	statusBytes, err := stub.GetStateFromKeyByte(migrationStatusKey)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for %s: %s", migrationStatusKey, err.Error())
+	}
+	if len(statusBytes) > 0 {
+		if err := json.Unmarshal(statusBytes, status); err != nil {
+			return nil, fmt.Errorf("unmarshal migration status error: %s", err.Error())
+		}
+	}
+	if status.SchemaVersion, err = getSchemaVersion(stub); err != nil {
+		return nil, err
+	}
+	status.TargetVersion = latestSchemaVersion()
+	status.Pending = []string{}
+	for _, step := range migrationSteps {
+		if step.Version > status.SchemaVersion {
+			status.Pending = append(status.Pending, step.Name)
+		}
+	}
+	status.Done = len(status.Pending) == 0
+	return status, nil
+}
+
+func putMigrationStatus(stub shim.CMStubInterface, status *MigrationStatus) error {
+	statusBytes, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("marshal migration status error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(migrationStatusKey, statusBytes); err != nil {
+		return fmt.Errorf("fail to PutState for %s: %s", migrationStatusKey, err.Error())
+	}
+	return nil
+}
+
+// runMigrations 按顺序执行未完成的迁移步骤, 至多处理 budget 条记录
+// 中断时记录步骤及游标, 下次调用自该处继续; 步骤完成时更新数据版本
+func runMigrations(stub shim.CMStubInterface, budget int) (*MigrationStatus, error) {
+	status, err := getMigrationStatus(stub)
+	if err != nil {
+		return nil, err
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return nil, fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	for _, step := range migrationSteps {
+		if step.Version <= status.SchemaVersion {
+			continue
+		}
+		if status.Step != step.Name {
+			status.Step, status.Cursor, status.Processed = step.Name, "", 0
+		}
+		if budget <= 0 {
+			break
+		}
+		cursor, processed, done, err := step.run(stub, status.Cursor, budget)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s failed: %s", step.Name, err.Error())
+		}
+		budget -= processed
+		status.Cursor = cursor
+		status.Processed += processed
+		stub.Log("[migration] " + step.Name + " processed " + strconv.Itoa(status.Processed))
+		if !done {
+			break
+		}
+		if err := putSchemaVersion(stub, step.Version); err != nil {
+			return nil, err
+		}
+		status.SchemaVersion = step.Version
+		status.Pending = status.Pending[1:]
+		status.Step, status.Cursor, status.Processed = "", "", 0
+	}
+	status.Done = len(status.Pending) == 0
+	status.LastTxId = txId
+	if err := putMigrationStatus(stub, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// prefixEnd 带前缀状态键的范围上界(不含)
+func prefixEnd(prefix string) string {
+	return prefix[:len(prefix)-1] + string(prefix[len(prefix)-1]+1)
+}
+
+// scanRecords 按 prefixes 的顺序(须为字典序)读取游标之后至多 budget 条无 field 的记录
+// 以范围迭代器自游标处续读, 返回的 done 表示各前缀均已读完
+func scanRecords(stub shim.CMStubInterface, prefixes []string, cursor string, budget int) ([]string, [][]byte, bool, error) {
+	var keys []string
+	var values [][]byte
+	for _, prefix := range prefixes {
+		end := prefixEnd(prefix)
+		if cursor >= end {
+			continue
+		}
+		start := prefix
+		if cursor > start {
+			start = cursor
+		}
+		iter, err := stub.NewIterator(start, end)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("fail to create iterator for %s: %s", prefix, err.Error())
+		}
+		for iter.HasNext() {
+			if len(keys) == budget {
+				iter.Close()
+				return keys, values, false, nil
+			}
+			key, field, value, err := iter.Next()
+			if err != nil {
+				iter.Close()
+				return nil, nil, false, fmt.Errorf("fail to iterate %s: %s", prefix, err.Error())
+			}
+			if field != "" || key == cursor {
+				continue
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+		iter.Close()
+	}
+	return keys, values, true, nil
+}
+
+// legacyTokenDetail 早期版本的 buildPublishTokenTx 原样写入 {publisher, receiver, tokenName, referenceFlag, tokenObject},
+// 按当前发行逻辑转换为 TokenDetail; 已是 TokenDetail 时返回 nil
+func legacyTokenDetail(stub shim.CMStubInterface, key string, value []byte) (*TokenDetail, error) {
+	var legacy struct {
+		Publisher     string       `json:"publisher"`
+		Receiver      string       `json:"receiver"`
+		TokenName     string       `json:"tokenName"`
+		ReferenceFlag int          `json:"referenceFlag"`
+		TokenObject   *TokenObject `json:"tokenObject"`
+		TokenId       string       `json:"tokenId"`
+	}
+	if err := json.Unmarshal(value, &legacy); err != nil {
+		return nil, fmt.Errorf("unmarshal %s error: %s", key, err.Error())
+	}
+	if legacy.TokenObject == nil || legacy.TokenId != "" {
+		return nil, nil
+	}
+	obj := legacy.TokenObject
+	version := "v2"
+	issue, err := getTokenIssue(stub, legacy.TokenName)
+	if err != nil {
+		return nil, err
+	}
+	if issue != nil && issue.Version != "" {
+		version = issue.Version
+	}
+	return &TokenDetail{
+		TokenId:             strings.TrimPrefix(key, "publish_token_"),
+		Version:             version,
+		Flag:                legacy.ReferenceFlag,
+		OwnerAccount:        legacy.Receiver,
+		AuthenticationInfos: obj.AuthenticationInfos,
+		Publisher:           legacy.Publisher,
+		Token:               legacy.TokenName,
+		CirculationFlag:     obj.Flag,
+		WorkId:              obj.WorkId,
+		ContentHash:         obj.ContentHash,
+		CopyrightType:       obj.CopyrightType,
+		CopyrightGetType:    obj.CopyrightGetType,
+		ParentLinks:         obj.ParentLinks,
+		CopyrightStatus:     obj.CopyrightStatus,
+		ConstraintExplain:   obj.ConstraintExplain,
+		ConstraintExpand:    obj.ConstraintExpand,
+		Term:                obj.Term,
+		CopyrightUnits:      obj.CopyrightUnits,
+		CopyrightConstraint: obj.CopyrightConstraint,
+		ApprConstraint:      obj.ApprConstraint,
+		LicenseConstraint:   obj.LicenseConstraint,
+	}, nil
+}
+
+// convertLegacyTokenDetails 迁移步骤: 将早期格式的 publish_token_ 记录转换为 TokenDetail, 并登记接收者的持有索引
+// 须先于按 TokenDetail 解析记录的步骤执行, 否则早期格式的字段会被丢弃; 游标为最后处理的状态键
+func convertLegacyTokenDetails(stub shim.CMStubInterface, cursor string, budget int) (string, int, bool, error) {
+	keys, values, done, err := scanRecords(stub, []string{"publish_token_"}, cursor, budget)
+	if err != nil {
+		return cursor, 0, false, err
+	}
+	// 迭代结束后再写入, 避免边遍历边修改
+	for i, key := range keys {
+		detail, err := legacyTokenDetail(stub, key, values[i])
+		if err != nil {
+			return cursor, i, false, err
+		}
+		if detail != nil {
+			if err := putTokenDetail(stub, detail); err != nil {
+				return cursor, i, false, err
+			}
+			if detail.OwnerAccount != "" {
+				if err := addHolding(stub, detail.OwnerAccount, detail); err != nil {
+					return cursor, i, false, err
+				}
+			}
+		}
+		cursor = key
+	}
+	if done {
+		cursor = ""
+	}
+	return cursor, len(keys), done, nil
+}
+
+// versionedPrefixes 带 recordVersion 字段的记录
+var versionedPrefixes = []struct {
+	prefix string
+	record func() interface{}
+}{
+	{"approve_token_", func() interface{} { return &ApproveToken{} }},
+	{"pub_token_tx_", func() interface{} { return &PubTokenTx{} }},
+	{"publish_token_", func() interface{} { return &TokenDetail{} }},
+	{"token_issue_", func() interface{} { return &TokenIssue{} }},
+}
+
+// backfillRecordVersion 迁移步骤: 引入记录版本号之前写入的记录版本号为 0, 与"记录不存在"无法区分, 统一置为 1
+// 各前缀按字典序排列, 游标为最后处理的状态键; 早期格式的通证记录由 token_detail_shape 步骤转换, 此处不改写
+func backfillRecordVersion(stub shim.CMStubInterface, cursor string, budget int) (string, int, bool, error) {
+	prefixes := make([]string, len(versionedPrefixes))
+	for i, versioned := range versionedPrefixes {
+		prefixes[i] = versioned.prefix
+	}
+	keys, values, done, err := scanRecords(stub, prefixes, cursor, budget)
+	if err != nil {
+		return cursor, 0, false, err
+	}
+	// 迭代结束后再写入, 避免边遍历边修改
+	for i, key := range keys {
+		var record interface{}
+		for _, versioned := range versionedPrefixes {
+			if strings.HasPrefix(key, versioned.prefix) {
+				record = versioned.record()
+			}
+		}
+		if _, ok := record.(*TokenDetail); ok {
+			legacy, err := legacyTokenDetail(stub, key, values[i])
+			if err != nil {
+				return cursor, i, false, err
+			}
+			if legacy != nil {
+				cursor = key
+				continue
+			}
+		}
+		if err := json.Unmarshal(values[i], record); err != nil {
+			return cursor, i, false, fmt.Errorf("unmarshal %s error: %s", key, err.Error())
+		}
+		version := reflect.ValueOf(record).Elem().FieldByName("RecordVersion")
+		if version.Int() == 0 {
+			version.SetInt(1)
+			recordBytes, err := json.Marshal(record)
+			if err != nil {
+				return cursor, i, false, fmt.Errorf("marshal %s error: %s", key, err.Error())
+			}
+			if err := stub.PutStateFromKeyByte(key, recordBytes); err != nil {
+				return cursor, i, false, fmt.Errorf("fail to PutState for %s: %s", key, err.Error())
+			}
+		}
+		cursor = key
+	}
+	if done {
+		cursor = ""
+	}
+	return cursor, len(keys), done, nil
+}
+
+// RunMigration 管理方法: 分批执行未完成的数据迁移, 数据量较大时 UpgradeContract 只完成第一批
+// 文档: runMigration({account, batchSize?})
+func (tc *TokenContract) RunMigration(stub shim.CMStubInterface) protogo.Response {
+	const method = "runMigration"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	if account == "" {
+		return missingParam(stub, method, "account")
+	}
+	if err := checkAdmin(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	batch := defaultMigrationBatch
+	if batchStr := string(args["batchSize"]); batchStr != "" {
+		var err error
+		batch, err = strconv.Atoi(batchStr)
+		if err != nil || batch < 1 || batch > maxMigrationBatch {
+			return errorResponse(stub, method, ErrInvalidParam, "batchSize", fmt.Sprintf("batchSize must be integer 1-%d, got: %s", maxMigrationBatch, batchStr))
+		}
+	}
+
+	status, err := runMigrations(stub, batch)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	stub.EmitEvent("event_migration", []string{strconv.Itoa(status.SchemaVersion), status.Step, strconv.Itoa(status.Processed)})
+	if _, _, err := audit(stub, method, account, status.Step, strconv.Itoa(status.SchemaVersion)); err != nil {
+		return failResponse(stub, method, err)
+	}
+	return rec.success(method, strconv.Itoa(status.SchemaVersion), migrationStatusKey, status.SchemaVersion, status)
+}
+
+// RequestMigrationStatus 查询数据迁移进度
+// 文档: requestMigrationStatus({})
+func (tc *TokenContract) RequestMigrationStatus(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestMigrationStatus"
+	status, err := getMigrationStatus(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	statusBytes, err := json.Marshal(status)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal migration status error: %s", err.Error()))
+	}
+	return shim.Success(statusBytes)
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 操作员代理: 持有者(或权利主体、机构等任一调用账户)授予操作员限定范围、限期的代理权
+// 委托人仍以方法原有的调用者参数(account / publisher)传入, 交易发送者不是委托人时即为操作员调用, 须持有有效代理权
+// operatorAccount 可选, 传入时须为交易发送者
+//
+//	operator_grant_<委托人>#<操作员> -> OperatorGrant
+const (
+	operatorGrantKeyPrefix = "operator_grant_"
+	operatorAccountParam   = "operatorAccount"
+)
+
+// OperatorScope 代理范围, 各项为空时不限
+type OperatorScope struct {
+	Methods         []string `json:"methods,omitempty"`         // 可代理的写方法
+	TokenIds        []string `json:"tokenIds,omitempty"`        // 限定的通证, 调用的 tokenId / referenceID / referenceId 之一须在其中
+	ApproveChannels []int    `json:"approveChannels,omitempty"` // 代发授权通证时允许的授权渠道
+	ApproveAreas    []int    `json:"approveAreas,omitempty"`    // 代发授权通证时允许的授权范围
+}
+
+// OperatorGrant 操作员代理授权
+type OperatorGrant struct {
+	Principal     string        `json:"principal"`
+	Operator      string        `json:"operator"`
+	Scope         OperatorScope `json:"scope"`
+	ExpiresAt     string        `json:"expiresAt"` // ISO-8601 日期或日期时间, 仅有日期时当天结束失效
+	GrantedAt     string        `json:"grantedAt"` // 区块时间(RFC3339)
+	TxId          string        `json:"txId"`
+	RecordVersion int           `json:"recordVersion"` // 记录版本号, 每次写入递增
+}
+
+// getOperatorGrant 读取代理授权, 不存在时返回 nil
+func getOperatorGrant(stub shim.CMStubInterface, principal, operator string) (*OperatorGrant, error) {
+	grantBytes, err := stub.GetStateByte(operatorGrantKeyPrefix+principal, operator)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState operator grant %s of %s: %s", operator, principal, err.Error())
+	}
+	if len(grantBytes) == 0 {
+		return nil, nil
+	}
+	var grant OperatorGrant
+	if err := json.Unmarshal(grantBytes, &grant); err != nil {
+		return nil, fmt.Errorf("unmarshal operator grant %s of %s error: %s", operator, principal, err.Error())
+	}
+	return &grant, nil
+}
+
+// delegable 可由操作员代理的写方法, 其说明须指明委托人参数
+func delegable(spec methodSpec) bool {
+	return !spec.Query && spec.Principal != "" && !adminMethods[spec.Name]
+}
+
+// checkOperator 校验可代理写方法的调用者: 交易发送者即委托人时返回空串
+// 否则发送者为操作员, 校验委托人的授权存在且未过期、调用在授权范围内, 返回操作员
+func checkOperator(stub shim.CMStubInterface, method string) (string, error) {
+	args := stub.GetArgs()
+	spec, ok := findMethodSpec(method)
+	if !ok || spec.Query {
+		return "", nil
+	}
+	if !delegable(spec) {
+		if len(args[operatorAccountParam]) > 0 {
+			return "", newError(ErrInvalidParam, operatorAccountParam, "method %s can not be called by operator", method)
+		}
+		return "", nil
+	}
+	principal := string(args[spec.Principal])
+	if principal == "" {
+		return "", newError(ErrMissingParam, spec.Principal, "missing required param: '%s'", spec.Principal)
+	}
+	sender, err := stub.GetSenderAddr()
+	if err != nil {
+		return "", fmt.Errorf("fail to get sender: %s", err.Error())
+	}
+	if operator := string(args[operatorAccountParam]); operator != "" && operator != sender {
+		return "", newError(ErrForbidden, operatorAccountParam, "operatorAccount %s is not the transaction sender %s", operator, sender)
+	}
+	if sender == principal {
+		return "", nil
+	}
+	if err := checkGrant(stub, method, principal, sender); err != nil {
+		return "", err
+	}
+	return sender, nil
+}
+
+// checkGrant 校验操作员持有委托人授予的、未过期且覆盖本次调用的代理权
+func checkGrant(stub shim.CMStubInterface, method, principal, operator string) error {
+	grant, err := getOperatorGrant(stub, principal, operator)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		return newError(ErrForbidden, operatorAccountParam, "%s has no operator grant from %s", operator, principal)
+	}
+	expiresAt, err := parseISODate(grant.ExpiresAt, true)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return err
+	}
+	if now.After(expiresAt) {
+		return newError(ErrForbidden, operatorAccountParam, "operator grant from %s expired at %s", principal, grant.ExpiresAt)
+	}
+	return grant.Scope.check(method, stub.GetArgs())
+}
+
+// check 校验调用在代理范围内
+func (s OperatorScope) check(method string, args map[string][]byte) error {
+	if len(s.Methods) > 0 && !containsId(s.Methods, method) {
+		return newError(ErrForbidden, operatorAccountParam, "operator grant does not cover method %s", method)
+	}
+	if len(s.TokenIds) > 0 {
+		covered := false
+		for _, name := range []string{"tokenId", "referenceID", "referenceId"} {
+			if id := string(args[name]); id != "" && containsId(s.TokenIds, id) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return newError(ErrForbidden, operatorAccountParam, "operator grant does not cover the token")
+		}
+	}
+	if method == "buildPublishApproveTokenTx" && (len(s.ApproveChannels) > 0 || len(s.ApproveAreas) > 0) {
+		var constraints []ApproveConstraint
+		if err := json.Unmarshal(args["approveConstraints"], &constraints); err != nil || len(constraints) == 0 {
+			return newError(ErrForbidden, "approveConstraints", "operator grant requires approveConstraints within the granted channels and areas")
+		}
+		for _, c := range constraints {
+			if len(s.ApproveChannels) > 0 && !containsInt(s.ApproveChannels, c.ApproveChannel) {
+				return newError(ErrForbidden, "approveConstraints", "operator grant does not cover approveChannel %d", c.ApproveChannel)
+			}
+			if len(s.ApproveAreas) > 0 && !containsInt(s.ApproveAreas, c.ApproveArea) {
+				return newError(ErrForbidden, "approveConstraints", "operator grant does not cover approveArea %d", c.ApproveArea)
+			}
+		}
+	}
+	return nil
+}
+
+// GrantOperator 授予或更新操作员代理权, 同一委托人与操作员之间只保留一条授权
+// 文档: grantOperator({account, operator, scope?, expiresAt})
+func (tc *TokenContract) GrantOperator(stub shim.CMStubInterface) protogo.Response {
+	const method = "grantOperator"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	operator := string(args["operator"])
+	expiresAt := string(args["expiresAt"])
+	if name := missingArg(args, "account", "operator", "expiresAt"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkSender(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if operator == account {
+		return errorResponse(stub, method, ErrInvalidParam, "operator", "grant to self: "+operator)
+	}
+
+	var scope OperatorScope
+	if scopeStr := string(args["scope"]); scopeStr != "" {
+		if err := json.Unmarshal([]byte(scopeStr), &scope); err != nil {
+			return errorResponse(stub, method, ErrInvalidParam, "scope", "fail to parse 'scope': "+err.Error())
+		}
+	}
+	for _, name := range scope.Methods {
+		spec, ok := findMethodSpec(name)
+		if !ok || !delegable(spec) {
+			return errorResponse(stub, method, ErrInvalidParam, "scope", "method can not be delegated: "+name)
+		}
+	}
+	for _, v := range scope.ApproveChannels {
+		if !validEnum(stub, "approveChannel", v) {
+			return errorResponse(stub, method, ErrInvalidEnum, "scope", fmt.Sprintf("approveChannel must be %s, got: %d", enumRange(stub, "approveChannel"), v))
+		}
+	}
+	for _, v := range scope.ApproveAreas {
+		if !validEnum(stub, "approveArea", v) {
+			return errorResponse(stub, method, ErrInvalidEnum, "scope", fmt.Sprintf("approveArea must be %s, got: %d", enumRange(stub, "approveArea"), v))
+		}
+	}
+
+	if err := validateDate("expiresAt", expiresAt); err != nil {
+		return failResponse(stub, method, err)
+	}
+	expires, _ := parseISODate(expiresAt, true)
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if expires.Before(now) {
+		return errorResponse(stub, method, ErrInvalidDate, "expiresAt", "expiresAt is in the past: "+expiresAt)
+	}
+
+	old, err := getOperatorGrant(stub, account, operator)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	currentVersion := 0
+	if old != nil {
+		currentVersion = old.RecordVersion
+	}
+	if err := checkExpectedVersion(args, currentVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	grant := &OperatorGrant{
+		Principal:     account,
+		Operator:      operator,
+		Scope:         scope,
+		ExpiresAt:     expiresAt,
+		GrantedAt:     now.Format(time.RFC3339),
+		TxId:          txId,
+		RecordVersion: currentVersion + 1,
+	}
+	grantBytes, err := json.Marshal(grant)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal operator grant error: %s", err.Error()))
+	}
+	key := operatorGrantKeyPrefix + account
+	if err := stub.PutStateByte(key, operator, grantBytes); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to save operator grant: %s", err.Error()))
+	}
+	scopeBytes, _ := json.Marshal(scope)
+	if _, _, err := audit(stub, method, account, operator, string(scopeBytes)+" until "+expiresAt); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_operator_grant", []string{account, operator, expiresAt})
+	return rec.success(method, operator, key+"#"+operator, grant.RecordVersion, grant)
+}
+
+// RevokeOperator 撤销操作员代理权
+// 文档: revokeOperator({account, operator})
+func (tc *TokenContract) RevokeOperator(stub shim.CMStubInterface) protogo.Response {
+	const method = "revokeOperator"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	operator := string(args["operator"])
+	if name := missingArg(args, "account", "operator"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkSender(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	grant, err := getOperatorGrant(stub, account, operator)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if grant == nil {
+		return errorResponse(stub, method, ErrNotFound, "operator", fmt.Sprintf("%s has no operator grant from %s", operator, account))
+	}
+	key := operatorGrantKeyPrefix + account
+	if err := stub.DelState(key, operator); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to delete operator grant: %s", err.Error()))
+	}
+	if _, _, err := audit(stub, method, account, operator, ""); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_operator_revoke", []string{account, operator})
+	return rec.success(method, operator, key+"#"+operator, grant.RecordVersion+1, grant)
+}
+
+// RequestOperatorGrants 查询委托人授予的全部操作员代理权(含已过期的)
+// 文档: requestOperatorGrants({account})
+func (tc *TokenContract) RequestOperatorGrants(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestOperatorGrants"
+	account := string(stub.GetArgs()["account"])
+	if account == "" {
+		return missingParam(stub, method, "account")
+	}
+	iter, err := stub.NewIteratorPrefixWithKeyField(operatorGrantKeyPrefix+account, "")
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to create iterator for operator grants of %s: %s", account, err.Error()))
+	}
+	defer iter.Close()
+	grants := []OperatorGrant{}
+	for iter.HasNext() {
+		_, _, value, err := iter.Next()
+		if err != nil {
+			return failResponse(stub, method, fmt.Errorf("fail to iterate operator grants of %s: %s", account, err.Error()))
+		}
+		var grant OperatorGrant
+		if err := json.Unmarshal(value, &grant); err != nil {
+			return failResponse(stub, method, fmt.Errorf("unmarshal operator grant error: %s", err.Error()))
+		}
+		grants = append(grants, grant)
+	}
+	grantsBytes, err := json.Marshal(grants)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal operator grants error: %s", err.Error()))
+	}
+	return shim.Success(grantsBytes)
+}
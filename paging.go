@@ -0,0 +1,121 @@
+package main
+
+import (
+	"chainmaker/shim"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 50   // 未指定 pageSize 时的每页条数
+	maxPageSize     = 200  // 每页条数上限
+	maxPageScan     = 1000 // 单次调用最多读取的记录数, 过滤条件命中率低时提前返回游标
+)
+
+// AccountTokenPage requestAccountToken 分页结果
+type AccountTokenPage struct {
+	Tokens     []TokenDetail `json:"tokens"`
+	NextCursor string        `json:"nextCursor,omitempty"` // 为空表示已无更多数据
+	Total      int           `json:"total"`                // 账户持有的满足过滤条件的通证总数
+}
+
+// accountTokenFilter requestAccountToken 的过滤条件
+type accountTokenFilter struct {
+	version string
+	flag    int
+	frozen  *bool
+	token   string
+	workId  string
+}
+
+// match 过滤属性是否满足条件, version 为 v1 时不比较 flag
+func (f *accountTokenFilter) match(g *holdingGroup) bool {
+	return g.Version == f.version &&
+		(f.version != "v2" || g.Flag == f.flag) &&
+		(f.frozen == nil || g.Frozen == *f.frozen) &&
+		(f.token == "" || g.Token == f.token) &&
+		(f.workId == "" || g.WorkId == f.workId)
+}
+
+// countMatched 按账户的持有分组计数统计满足条件的通证总数, 读取量与分组数相关而与持有数无关
+func (f *accountTokenFilter) countMatched(stub shim.CMStubInterface, account string) (int, error) {
+	groups, err := holdingGroups(stub, account)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for i := range groups {
+		if f.match(&groups[i]) {
+			total += groups[i].Count
+		}
+	}
+	return total, nil
+}
+
+// parsePageSize 解析 pageSize 参数, 为空时取默认值
+func parsePageSize(pageSizeStr string) (int, error) {
+	if pageSizeStr == "" {
+		return defaultPageSize, nil
+	}
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		return 0, newError(ErrInvalidParam, "pageSize", "pageSize must be integer 1-%d, got: %s", maxPageSize, pageSizeStr)
+	}
+	return pageSize, nil
+}
+
+// encodeCursor 游标: 下一条记录的序号字段, 对调用方不透明
+// 序号不随其他记录的增删变化, 翻页期间索引有增删时游标仍然有效
+func encodeCursor(field string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(field))
+}
+
+// decodeCursor 解析游标, 返回 width 位数字的序号字段, 游标为空时返回空串
+func decodeCursor(cursor string, width int) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", newError(ErrInvalidParam, "cursor", "invalid cursor: %s", cursor)
+	}
+	field := string(raw)
+	if len(field) != width || strings.Trim(field, "0123456789") != "" {
+		return "", newError(ErrInvalidParam, "cursor", "invalid cursor: %s", cursor)
+	}
+	return field, nil
+}
+
+// holdingEntry 账户持有关系的一条记录
+type holdingEntry struct {
+	field   string
+	tokenId string
+}
+
+// scanHoldings 自游标处按发行时间(desc 时倒序)读取至多 limit 条持有记录
+// 迭代器只能正向遍历, 倒序时遍历按倒序字段存储的 account_holding_desc_, 游标为倒序字段, 两个方向都只读取一页
+func scanHoldings(stub shim.CMStubInterface, account, cursorField string, desc bool, limit int) ([]holdingEntry, error) {
+	key := holdingKeyPrefix + account
+	if desc {
+		key = holdingDescKeyPrefix + account
+	}
+	var entries []holdingEntry
+	err := rangeFields(stub, key, cursorField, holdingFieldEnd, func(field string, value []byte) bool {
+		entries = append(entries, holdingEntry{field: field, tokenId: string(value)})
+		return len(entries) < limit
+	})
+	return entries, err
+}
+
+// parseBoolFilter 解析可选的布尔过滤参数, 为空时返回 nil
+func parseBoolFilter(field, value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, newError(ErrInvalidParam, field, "%s must be bool, got: %s", field, value)
+	}
+	return &b, nil
+}
@@ -0,0 +1,596 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 优先购买权: 通证设置优先购买规则后, 权利主体向非现有权利主体转让份额时先发出通知
+// 通知期内其他权利主体可按同等条件主张优先购买, 按各自份额比例分得转让给非权利主体的部分
+// 通知期满(或全部权利主体已答复)后结算: 无人主张时按原转让完成; 有人主张时按转让价格比例向各主张者生成挂单(sale.go),
+// 主张者付款成交后才转移份额, 转让方的份额锁定至这些挂单均已成交、放弃或过期
+// 规则生效期间, 共有通证的持有权及份额不能经 transferFrom、两步变更、挂单出售或修改版权单元地址直接转给非权利主体;
+// 持有者可随时设置或延长通知期, 缩短或取消须持有者及全部权利主体分别同意
+//
+//	preemption_rule_<tokenId>                 -> PreemptionRule
+//	preemption_notice_<noticeId>              -> PreemptionNotice, noticeId 为转让交易的 txId
+//	preemption_notice_token_<tokenId>#<noticeId> -> noticeId   通证下的通知
+//	preemption_lock_<tokenId>#<转让方>          -> noticeId   通知期内转让方的份额不可另行转移
+const (
+	preemptionRuleKeyPrefix        = "preemption_rule_"
+	preemptionNoticeKeyPrefix      = "preemption_notice_"
+	preemptionNoticeTokenKeyPrefix = "preemption_notice_token_"
+	preemptionLockKeyPrefix        = "preemption_lock_"
+
+	maxNoticeDays = 365
+
+	noticePending   = "pending"   // 通知期内
+	noticeCompleted = "completed" // 无人主张, 已按原转让完成
+	noticePreempted = "preempted" // 有人主张, 已按比例向主张者生成挂单
+
+	decisionClaim = "claim"
+	decisionWaive = "waive"
+)
+
+// PreemptionRule 通证的优先购买规则
+type PreemptionRule struct {
+	TokenId       string `json:"tokenId"`
+	NoticeDays    int    `json:"noticeDays"` // 通知期天数, 0 表示不设优先购买权
+	SetBy         string `json:"setBy"`
+	UpdatedAt     string `json:"updatedAt"`
+	TxId          string `json:"txId"`
+	RecordVersion int    `json:"recordVersion"` // 记录版本号, 每次写入递增
+	// Pending 缩短或取消通知期的提议, 持有者及全部权利主体均同意后生效
+	Pending *PreemptionRelax `json:"pending,omitempty"`
+}
+
+// PreemptionRelax 缩短或取消通知期的提议
+type PreemptionRelax struct {
+	NoticeDays int      `json:"noticeDays"`
+	ProposedBy string   `json:"proposedBy"`
+	Consents   []string `json:"consents"` // 已同意的持有者及权利主体
+}
+
+// PreemptionNotice 份额转让的优先购买通知
+type PreemptionNotice struct {
+	NoticeId   string          `json:"noticeId"`
+	TokenId    string          `json:"tokenId"`
+	Seller     string          `json:"seller"`
+	Proportion string          `json:"proportion"`      // 转让方转出的份额
+	Units      []CopyrightUnit `json:"units"`           // 原转让的受让方及份额
+	Terms      string          `json:"terms,omitempty"` // 转让条件(价格等), 主张优先购买即按此条件受让
+	Price      string          `json:"price"`           // 转给非权利主体部分的总价, 主张者按受让份额比例支付
+	Asset      string          `json:"asset,omitempty"` // 计价资产
+	Holders    []CopyrightUnit `json:"holders"`         // 发出通知时享有优先购买权的权利主体及其份额
+	Claims     []string        `json:"claims"`          // 主张优先购买的权利主体
+	Waivers    []string        `json:"waivers"`         // 放弃优先购买的权利主体
+	Deadline   string          `json:"deadline"`        // 通知期截止时间(RFC3339)
+	Status     string          `json:"status"`
+	CreatedAt  string          `json:"createdAt"`
+	SettledAt  string          `json:"settledAt,omitempty"`
+	// SettleTxId 结算交易
+	SettleTxId    string `json:"settleTxId,omitempty"`
+	RecordVersion int    `json:"recordVersion"` // 记录版本号, 每次写入递增
+	// Offers 结算时向主张者生成的挂单
+	Offers []string `json:"offers,omitempty"`
+}
+
+// getPreemptionRule 读取优先购买规则, 不存在时返回 nil
+func getPreemptionRule(stub shim.CMStubInterface, tokenId string) (*PreemptionRule, error) {
+	ruleBytes, err := stub.GetStateFromKeyByte(preemptionRuleKeyPrefix + tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState pre-emption rule of %s: %s", tokenId, err.Error())
+	}
+	if len(ruleBytes) == 0 {
+		return nil, nil
+	}
+	var rule PreemptionRule
+	if err := json.Unmarshal(ruleBytes, &rule); err != nil {
+		return nil, fmt.Errorf("unmarshal pre-emption rule of %s error: %s", tokenId, err.Error())
+	}
+	return &rule, nil
+}
+
+// noticeDays 通证的优先购买通知期天数, 未设置规则时为 0
+func noticeDays(stub shim.CMStubInterface, tokenId string) (int, error) {
+	rule, err := getPreemptionRule(stub, tokenId)
+	if err != nil || rule == nil {
+		return 0, err
+	}
+	return rule.NoticeDays, nil
+}
+
+func getPreemptionNotice(stub shim.CMStubInterface, noticeId string) (*PreemptionNotice, error) {
+	noticeBytes, err := stub.GetStateFromKeyByte(preemptionNoticeKeyPrefix + noticeId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState pre-emption notice %s: %s", noticeId, err.Error())
+	}
+	if len(noticeBytes) == 0 {
+		return nil, newError(ErrNotFound, "noticeId", "no pre-emption notice found for noticeId: %s", noticeId)
+	}
+	var notice PreemptionNotice
+	if err := json.Unmarshal(noticeBytes, &notice); err != nil {
+		return nil, fmt.Errorf("unmarshal pre-emption notice %s error: %s", noticeId, err.Error())
+	}
+	return &notice, nil
+}
+
+func putPreemptionNotice(stub shim.CMStubInterface, notice *PreemptionNotice) error {
+	notice.RecordVersion++
+	noticeBytes, err := json.Marshal(notice)
+	if err != nil {
+		return fmt.Errorf("marshal pre-emption notice error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(preemptionNoticeKeyPrefix+notice.NoticeId, noticeBytes); err != nil {
+		return fmt.Errorf("fail to PutState pre-emption notice %s: %s", notice.NoticeId, err.Error())
+	}
+	return nil
+}
+
+// checkPreemptionLock 转让方的份额处于优先购买通知期, 或结算生成的挂单尚待主张者付款时返回 IN_ESCROW
+func checkPreemptionLock(stub shim.CMStubInterface, tokenId, holder string) error {
+	noticeId, err := stub.GetStateByte(preemptionLockKeyPrefix+tokenId, holder)
+	if err != nil {
+		return fmt.Errorf("fail to GetState pre-emption lock of %s: %s", tokenId, err.Error())
+	}
+	if len(noticeId) == 0 {
+		return nil
+	}
+	notice, err := getPreemptionNotice(stub, string(noticeId))
+	if err != nil {
+		return err
+	}
+	if notice.Status == noticePreempted {
+		now, err := txTime(stub)
+		if err != nil {
+			return err
+		}
+		for _, offerId := range notice.Offers {
+			offer, err := getSaleOffer(stub, offerId)
+			if err != nil {
+				return err
+			}
+			if (offer.Status == offerOpen || offer.Status == offerAccepted) && !offer.expired(now) {
+				return newError(ErrInEscrow, "tokenId", "proportion of %s in token %s is held by pre-emption offer %s", holder, tokenId, offerId)
+			}
+		}
+		return nil
+	}
+	return newError(ErrInEscrow, "tokenId", "proportion of %s in token %s is pending pre-emption notice %s", holder, tokenId, string(noticeId))
+}
+
+// checkPreemptionBuyer 设有优先购买规则的通证, 份额只能直接出售给现有权利主体
+func checkPreemptionBuyer(stub shim.CMStubInterface, detail *TokenDetail, buyer string) error {
+	days, err := noticeDays(stub, detail.TokenId)
+	if err != nil || days == 0 {
+		return err
+	}
+	held, err := unitProportion(detail, buyer)
+	if err != nil {
+		return err
+	}
+	if held < 0 {
+		return newError(ErrForbidden, "account", "token %s has a pre-emption rule, transfer shares to %s with buildTransferProportionTx", detail.TokenId, buyer)
+	}
+	return nil
+}
+
+// checkPreemptionOutsider 设有优先购买规则的共有通证, from 的持有权或份额不能绕过通知直接转给非权利主体 to
+func checkPreemptionOutsider(stub shim.CMStubInterface, detail *TokenDetail, from, to string) error {
+	days, err := noticeDays(stub, detail.TokenId)
+	if err != nil || days == 0 {
+		return err
+	}
+	coHeld := false
+	for _, unit := range detail.CopyrightUnits {
+		if unit.Address != from {
+			coHeld = true
+			break
+		}
+	}
+	if !coHeld || holdsUnit(detail.CopyrightUnits, to) {
+		return nil
+	}
+	return newError(ErrForbidden, "to", "token %s has a pre-emption rule, transfer to non-holder %s with buildTransferProportionTx", detail.TokenId, to)
+}
+
+// rightsHolders 通证的持有者及全部权利主体
+func rightsHolders(detail *TokenDetail) []string {
+	holders := []string{detail.OwnerAccount}
+	for _, unit := range detail.CopyrightUnits {
+		if !containsId(holders, unit.Address) {
+			holders = append(holders, unit.Address)
+		}
+	}
+	return holders
+}
+
+// openPreemptionNotice 向非现有权利主体转让份额时发出优先购买通知, 锁定转让方的份额, 须给出转给非权利主体部分的价格
+// 其他权利主体均为受让方(或不存在)时返回 nil, 转让直接完成
+func openPreemptionNotice(stub shim.CMStubInterface, detail *TokenDetail, seller string, proportion float64, units []CopyrightUnit, terms, price, asset string, days int) (*PreemptionNotice, error) {
+	var holders []CopyrightUnit
+	for _, unit := range detail.CopyrightUnits {
+		if unit.Address == seller {
+			continue
+		}
+		if _, err := strconv.ParseFloat(unit.Proportion, 64); err != nil {
+			return nil, fmt.Errorf("proportion of %s is not numeric: %s", unit.Address, unit.Proportion)
+		}
+		holders = append(holders, unit)
+	}
+	outsider := false
+	for _, unit := range units {
+		if !holdsUnit(holders, unit.Address) {
+			outsider = true
+			break
+		}
+	}
+	if !outsider || len(holders) == 0 {
+		return nil, nil
+	}
+	if price == "" {
+		return nil, newError(ErrMissingParam, "price", "price is required to transfer to a non-holder under the pre-emption rule")
+	}
+	if p, err := strconv.ParseFloat(price, 64); err != nil || p <= 0 {
+		return nil, newError(ErrInvalidParam, "price", "price must be a positive number, got: %s", price)
+	}
+
+	now, err := txTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return nil, fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	notice := &PreemptionNotice{
+		NoticeId:   txId,
+		TokenId:    detail.TokenId,
+		Seller:     seller,
+		Proportion: formatProportion(proportion),
+		Units:      units,
+		Terms:      terms,
+		Price:      price,
+		Asset:      asset,
+		Holders:    holders,
+		Claims:     []string{},
+		Waivers:    []string{},
+		Deadline:   now.AddDate(0, 0, days).Format(time.RFC3339),
+		Status:     noticePending,
+		CreatedAt:  now.Format(time.RFC3339),
+	}
+	if err := putPreemptionNotice(stub, notice); err != nil {
+		return nil, err
+	}
+	if err := stub.PutStateByte(preemptionNoticeTokenKeyPrefix+detail.TokenId, txId, []byte(txId)); err != nil {
+		return nil, fmt.Errorf("fail to PutState pre-emption notice index of %s: %s", detail.TokenId, err.Error())
+	}
+	if err := stub.PutStateByte(preemptionLockKeyPrefix+detail.TokenId, seller, []byte(txId)); err != nil {
+		return nil, fmt.Errorf("fail to PutState pre-emption lock of %s: %s", detail.TokenId, err.Error())
+	}
+	stub.EmitEvent("event_preemption_notice", []string{txId, detail.TokenId, seller, notice.Deadline})
+	return notice, nil
+}
+
+// holdsUnit 判断版权单元列表中是否有该地址
+func holdsUnit(units []CopyrightUnit, address string) bool {
+	for _, unit := range units {
+		if unit.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// holdsRights 判断账户是否为版权通证的持有者或权利主体
+func holdsRights(detail *TokenDetail, account string) bool {
+	return detail.OwnerAccount == account || holdsUnit(detail.CopyrightUnits, account)
+}
+
+// SetPreemptionRule 持有者设置通证的优先购买规则, noticeDays 为 0 时取消
+// 设置或延长通知期立即生效; 缩短或取消时, 持有者及权利主体各自以相同的 noticeDays 调用表示同意, 全部同意后生效
+// 文档: setPreemptionRule({account, tokenId, noticeDays, expectedVersion?})
+func (tc *TokenContract) SetPreemptionRule(stub shim.CMStubInterface) protogo.Response {
+	const method = "setPreemptionRule"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	tokenId := string(args["tokenId"])
+	daysStr := string(args["noticeDays"])
+	if name := missingArg(args, "account", "tokenId", "noticeDays"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days < 0 || days > maxNoticeDays {
+		return errorResponse(stub, method, ErrInvalidParam, "noticeDays", fmt.Sprintf("noticeDays must be integer 0-%d, got: %s", maxNoticeDays, daysStr))
+	}
+	detail, err := loadCopyrightToken(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	old, err := getPreemptionRule(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	rule := &PreemptionRule{TokenId: tokenId}
+	if old != nil {
+		rule = old
+	}
+	if err := checkExpectedVersion(args, rule.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+	holders := rightsHolders(detail)
+	relax := days < rule.NoticeDays && len(holders) > 1
+	if !relax && detail.OwnerAccount != account {
+		return errorResponse(stub, method, ErrForbidden, "account", "only the token owner can set the pre-emption rule: "+detail.OwnerAccount)
+	}
+	if relax && !containsId(holders, account) {
+		return errorResponse(stub, method, ErrForbidden, "account", "only the token owner or a copyright unit holder can consent to relaxing the pre-emption rule: "+account)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	applied := true
+	if relax {
+		if rule.Pending == nil || rule.Pending.NoticeDays != days {
+			rule.Pending = &PreemptionRelax{NoticeDays: days, ProposedBy: account, Consents: []string{}}
+		}
+		if containsId(rule.Pending.Consents, account) {
+			return errorResponse(stub, method, ErrAlreadyExists, "account", "account has already consented: "+account)
+		}
+		rule.Pending.Consents = append(rule.Pending.Consents, account)
+		for _, holder := range holders {
+			if !containsId(rule.Pending.Consents, holder) {
+				applied = false
+			}
+		}
+	}
+	if applied {
+		rule.NoticeDays = days
+		rule.SetBy = account
+		rule.Pending = nil
+	}
+	rule.UpdatedAt = now.Format(time.RFC3339)
+	rule.TxId = txId
+	rule.RecordVersion++
+	ruleBytes, err := json.Marshal(rule)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal pre-emption rule error: %s", err.Error()))
+	}
+	key := preemptionRuleKeyPrefix + tokenId
+	if err := stub.PutStateFromKeyByte(key, ruleBytes); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to save pre-emption rule: %s", err.Error()))
+	}
+
+	if applied {
+		stub.EmitEvent("event_preemption_rule", []string{tokenId, daysStr})
+	} else {
+		stub.EmitEvent("event_preemption_rule_consent", []string{tokenId, daysStr, account})
+	}
+	return rec.success(method, tokenId, key, rule.RecordVersion, rule)
+}
+
+// RespondPreemption 权利主体在通知期内主张(claim)或放弃(waive)优先购买, 每个权利主体只能答复一次
+// 文档: respondPreemption({account, noticeId, decision})
+func (tc *TokenContract) RespondPreemption(stub shim.CMStubInterface) protogo.Response {
+	const method = "respondPreemption"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	noticeId := string(args["noticeId"])
+	decision := string(args["decision"])
+	if name := missingArg(args, "account", "noticeId", "decision"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if decision != decisionClaim && decision != decisionWaive {
+		return errorResponse(stub, method, ErrInvalidParam, "decision", "decision must be 'claim' or 'waive', got: "+decision)
+	}
+	notice, err := getPreemptionNotice(stub, noticeId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	deadline, _ := time.Parse(time.RFC3339, notice.Deadline)
+	if notice.Status != noticePending || now.After(deadline) {
+		return errorResponse(stub, method, ErrNotActive, "noticeId", "pre-emption notice period has ended: "+noticeId)
+	}
+	if !holdsUnit(notice.Holders, account) {
+		return errorResponse(stub, method, ErrForbidden, "account", "account has no pre-emption right in this notice: "+account)
+	}
+	if containsId(notice.Claims, account) || containsId(notice.Waivers, account) {
+		return errorResponse(stub, method, ErrAlreadyExists, "account", "account has already responded: "+account)
+	}
+	if decision == decisionClaim {
+		notice.Claims = append(notice.Claims, account)
+	} else {
+		notice.Waivers = append(notice.Waivers, account)
+	}
+	if err := putPreemptionNotice(stub, notice); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_preemption_"+decision, []string{noticeId, notice.TokenId, account})
+	return rec.success(method, noticeId, preemptionNoticeKeyPrefix+noticeId, notice.RecordVersion, notice)
+}
+
+// SettlePreemption 通知期满或全部权利主体已答复后结算, 任何账户均可调用
+// 无人主张时按原转让完成; 有人主张时, 转让给非权利主体的份额按主张者发出通知时的份额比例分配,
+// 以通知的价格按比例向各主张者生成指定买方的挂单, 通知期同样长度内 acceptOffer 并付款后成交; 转给现有权利主体的部分不变
+// 文档: settlePreemption({noticeId})
+func (tc *TokenContract) SettlePreemption(stub shim.CMStubInterface) protogo.Response {
+	const method = "settlePreemption"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	noticeId := string(args["noticeId"])
+	if noticeId == "" {
+		return missingParam(stub, method, "noticeId")
+	}
+	notice, err := getPreemptionNotice(stub, noticeId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if notice.Status != noticePending {
+		return errorResponse(stub, method, ErrNotActive, "noticeId", "pre-emption notice is already settled: "+noticeId)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	deadline, _ := time.Parse(time.RFC3339, notice.Deadline)
+	if !now.After(deadline) && len(notice.Claims)+len(notice.Waivers) < len(notice.Holders) {
+		return errorResponse(stub, method, ErrForbidden, "noticeId", "pre-emption notice period ends at "+notice.Deadline)
+	}
+
+	detail, err := loadCopyrightToken(stub, notice.TokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkTransferable(detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	// 转给现有权利主体的部分照常转让, 转给非权利主体的部分在有人主张时由主张者按比例受让
+	var outsiderTotal float64
+	for _, unit := range notice.Units {
+		p, _ := strconv.ParseFloat(unit.Proportion, 64)
+		if len(notice.Claims) > 0 && !holdsUnit(notice.Holders, unit.Address) {
+			outsiderTotal += p
+			continue
+		}
+		if err := moveProportion(detail, notice.Seller, unit.Address, p); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+	if outsiderTotal > 0 {
+		var claimed float64
+		weights := make([]float64, len(notice.Claims))
+		for i, claimant := range notice.Claims {
+			for _, holder := range notice.Holders {
+				if holder.Address == claimant {
+					weights[i], _ = strconv.ParseFloat(holder.Proportion, 64)
+					claimed += weights[i]
+				}
+			}
+		}
+		price, _ := strconv.ParseFloat(notice.Price, 64)
+		payment, err := offerPayment(stub, "")
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		created, _ := time.Parse(time.RFC3339, notice.CreatedAt)
+		expiresAt := now.Add(deadline.Sub(created)).Format(time.RFC3339)
+		remaining, remainingPrice := outsiderTotal, price
+		for i, claimant := range notice.Claims {
+			amount, amountPrice := outsiderTotal*weights[i]/claimed, price*weights[i]/claimed
+			if i == len(notice.Claims)-1 {
+				amount, amountPrice = remaining, remainingPrice
+			}
+			remaining -= amount
+			remainingPrice -= amountPrice
+			// 转让方已在转让时签名, 主张者接受挂单并付款后成交
+			offer := &SaleOffer{
+				OfferId:    fmt.Sprintf("%s-%d", txId, i+1),
+				Side:       offerSideAsk,
+				Kind:       offerKindShare,
+				TokenId:    notice.TokenId,
+				Proportion: formatProportion(amount),
+				Price:      formatProportion(amountPrice),
+				Asset:      notice.Asset,
+				Seller:     notice.Seller,
+				Buyer:      claimant,
+				Payment:    payment,
+				Status:     offerOpen,
+				ExpiresAt:  expiresAt,
+				CreatedAt:  now.Format(time.RFC3339),
+				NoticeId:   noticeId,
+			}
+			if err := addOffer(stub, offer); err != nil {
+				return failResponse(stub, method, err)
+			}
+			notice.Offers = append(notice.Offers, offer.OfferId)
+		}
+	}
+	if err := putTokenDetail(stub, detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	// 生成的挂单待付款期间保留锁定, 由 checkPreemptionLock 按挂单状态判断
+	if len(notice.Offers) == 0 {
+		if err := stub.DelState(preemptionLockKeyPrefix+notice.TokenId, notice.Seller); err != nil {
+			return failResponse(stub, method, fmt.Errorf("fail to DelState pre-emption lock of %s: %s", notice.TokenId, err.Error()))
+		}
+	}
+
+	notice.Status = noticeCompleted
+	if len(notice.Claims) > 0 {
+		notice.Status = noticePreempted
+	}
+	notice.SettledAt = now.Format(time.RFC3339)
+	notice.SettleTxId = txId
+	if err := putPreemptionNotice(stub, notice); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_preemption_settled", []string{noticeId, notice.TokenId, notice.Status})
+	stub.EmitEvent("event_transfer_proportion", []string{notice.TokenId, notice.Seller})
+	return rec.success(method, noticeId, preemptionNoticeKeyPrefix+noticeId, notice.RecordVersion, notice)
+}
+
+// RequestPreemptionNotices 查询通证下的优先购买通知
+// 文档: requestPreemptionNotices({tokenId})
+func (tc *TokenContract) RequestPreemptionNotices(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestPreemptionNotices"
+	tokenId := string(stub.GetArgs()["tokenId"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+	iter, err := stub.NewIteratorPrefixWithKeyField(preemptionNoticeTokenKeyPrefix+tokenId, "")
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to create iterator for pre-emption notices of %s: %s", tokenId, err.Error()))
+	}
+	var noticeIds []string
+	for iter.HasNext() {
+		_, _, value, err := iter.Next()
+		if err != nil {
+			iter.Close()
+			return failResponse(stub, method, fmt.Errorf("fail to iterate pre-emption notices of %s: %s", tokenId, err.Error()))
+		}
+		noticeIds = append(noticeIds, string(value))
+	}
+	iter.Close()
+
+	notices := []PreemptionNotice{}
+	for _, noticeId := range noticeIds {
+		notice, err := getPreemptionNotice(stub, noticeId)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		notices = append(notices, *notice)
+	}
+	noticesBytes, err := json.Marshal(notices)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal pre-emption notices error: %s", err.Error()))
+	}
+	return shim.Success(noticesBytes)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// TxResult 写方法的返回结果, 客户端据此更新缓存, 无需再次查询
+type TxResult struct {
+	Method        string          `json:"method"`
+	TxId          string          `json:"txId"`
+	Id            string          `json:"id"`            // 主记录ID(tokenId、workId、通证名称或文件哈希)
+	Key           string          `json:"key"`           // 主记录状态键
+	Keys          []string        `json:"keys"`          // 本次交易写入或删除的全部状态键
+	RecordVersion int             `json:"recordVersion"` // 主记录写入后的版本号
+	Record        json.RawMessage `json:"record"`        // 写入后的主记录
+	Digest        string          `json:"digest"`        // 主记录 sha256
+	Events        []EventRef      `json:"events"`
+}
+
+// EventRef 本次交易发出的合约事件, id 为 <txId>#<序号>
+type EventRef struct {
+	Id    string `json:"id"`
+	Topic string `json:"topic"`
+}
+
+// txRecorder 包装 stub, 记录本次交易写入/删除的状态键及发出的事件
+type txRecorder struct {
+	shim.CMStubInterface
+	keys   []string
+	seen   map[string]bool
+	events []EventRef
+}
+
+// recordTx 包装 stub, 写方法开始处调用, 之后的读写均经由返回的 recorder
+func recordTx(stub shim.CMStubInterface) *txRecorder {
+	return &txRecorder{CMStubInterface: stub, keys: []string{}, seen: map[string]bool{}, events: []EventRef{}}
+}
+
+func (r *txRecorder) touch(key string) {
+	if !r.seen[key] {
+		r.seen[key] = true
+		r.keys = append(r.keys, key)
+	}
+}
+
+func (r *txRecorder) PutStateFromKeyByte(key string, value []byte) error {
+	r.touch(key)
+	return r.CMStubInterface.PutStateFromKeyByte(key, value)
+}
+
+func (r *txRecorder) PutStateFromKey(key string, value string) error {
+	r.touch(key)
+	return r.CMStubInterface.PutStateFromKey(key, value)
+}
+
+func (r *txRecorder) DelStateFromKey(key string) error {
+	r.touch(key)
+	return r.CMStubInterface.DelStateFromKey(key)
+}
+
+func (r *txRecorder) PutStateByte(key, field string, value []byte) error {
+	r.touch(key + "#" + field)
+	return r.CMStubInterface.PutStateByte(key, field, value)
+}
+
+func (r *txRecorder) PutState(key, field string, value string) error {
+	r.touch(key + "#" + field)
+	return r.CMStubInterface.PutState(key, field, value)
+}
+
+func (r *txRecorder) DelState(key, field string) error {
+	r.touch(key + "#" + field)
+	return r.CMStubInterface.DelState(key, field)
+}
+
+func (r *txRecorder) EmitEvent(topic string, data []string) {
+	txId, _ := r.GetTxId()
+	r.events = append(r.events, EventRef{Id: fmt.Sprintf("%s#%d", txId, len(r.events)), Topic: topic})
+	r.CMStubInterface.EmitEvent(topic, data)
+}
+
+// success 返回写方法的结构化结果, record 为写入后的主记录
+func (r *txRecorder) success(method, id, key string, version int, record interface{}) protogo.Response {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return failResponse(r, method, fmt.Errorf("marshal result record error: %s", err.Error()))
+	}
+	txId, _ := r.GetTxId()
+	sum := sha256.Sum256(recordBytes)
+	result := TxResult{
+		Method:        method,
+		TxId:          txId,
+		Id:            id,
+		Key:           key,
+		Keys:          r.keys,
+		RecordVersion: version,
+		Record:        recordBytes,
+		Digest:        hex.EncodeToString(sum[:]),
+		Events:        r.events,
+	}
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return failResponse(r, method, fmt.Errorf("marshal result error: %s", err.Error()))
+	}
+	r.Log("[" + method + "] success, key=" + key)
+	return shim.Success(resultBytes)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+)
+
+// saveRegulators 根据初始化参数 regulators 保存监管机构白名单
+func saveRegulators(stub shim.CMStubInterface) error {
+	regulatorsStr := string(stub.GetArgs()["regulators"])
+	if regulatorsStr == "" {
+		return nil
+	}
+	var regulators []string
+	if err := json.Unmarshal([]byte(regulatorsStr), &regulators); err != nil {
+		return newError(ErrInvalidParam, "regulators", "fail to parse 'regulators' json array: %s", err.Error())
+	}
+	return putRegulators(stub, regulators)
+}
+
+// putRegulators 保存监管机构白名单
+func putRegulators(stub shim.CMStubInterface, regulators []string) error {
+	if regulators == nil {
+		regulators = []string{}
+	}
+	regulatorBytes, err := json.Marshal(regulators)
+	if err != nil {
+		return fmt.Errorf("marshal regulators error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte("config_regulators", regulatorBytes); err != nil {
+		return fmt.Errorf("fail to save regulators: %s", err.Error())
+	}
+	return nil
+}
+
+// isRegulator 判断账户是否在监管机构白名单内
+func isRegulator(stub shim.CMStubInterface, account string) (bool, error) {
+	regulators, err := readIndex(stub, "config_regulators")
+	if err != nil {
+		return false, err
+	}
+	for _, regulator := range regulators {
+		if regulator == account {
+			return true, nil
+		}
+	}
+	return false, nil
+}
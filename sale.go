@@ -0,0 +1,628 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// 版权通证及份额的挂单/出价交易: 双方签名(创建与接受), 付款确认后才完成转移
+// 创建、接受、撤销及付款见证的 account 均须为交易发送者
+//
+//	sale_offer_<offerId>                 -> SaleOffer, offerId 为创建交易的 txId
+//	sale_offer_token_<tokenId>#<offerId> -> offerId   通证下的挂单/出价
+//	sale_escrow_<tokenId>#<卖方>          -> offerId   托管锁定: 卖方的持有权/份额在成交或撤销前不可另行转移
+//
+// 付款方式:
+//
+//	contract  配置了 paymentContract 时, 接受即调用资产合约 transferFrom(买方 -> 卖方), 成功即成交
+//	attested  由配置的 paymentAttestors 登记线下/链外付款记录后成交
+const (
+	saleOfferKeyPrefix      = "sale_offer_"
+	saleOfferTokenKeyPrefix = "sale_offer_token_"
+	saleEscrowKeyPrefix     = "sale_escrow_"
+
+	offerSideAsk = "ask" // 卖方挂单, 买方接受
+	offerSideBid = "bid" // 买方出价, 卖方接受
+
+	offerKindToken = "token" // 持有权(OwnerAccount 及其权利主体份额)
+	offerKindShare = "share" // 部分版权份额
+
+	paymentContract = "contract"
+	paymentAttested = "attested"
+
+	offerOpen      = "open"      // 一方已签名, 等待对方接受
+	offerAccepted  = "accepted"  // 双方已签名, 等待付款确认
+	offerSettled   = "settled"   // 已付款并完成转移
+	offerCancelled = "cancelled" // 已撤销
+	offerExpired   = "expired"   // 已过期(查询时根据 expiresAt 给出)
+)
+
+// SaleOffer 挂单或出价
+type SaleOffer struct {
+	OfferId    string `json:"offerId"`
+	Side       string `json:"side"` // ask / bid
+	Kind       string `json:"kind"` // token / share
+	TokenId    string `json:"tokenId"`
+	Proportion string `json:"proportion,omitempty"` // 出售的份额, 仅 share
+	Price      string `json:"price"`
+	Asset      string `json:"asset,omitempty"` // 计价资产
+	Seller     string `json:"seller"`
+	Buyer      string `json:"buyer,omitempty"` // ask 未指定买方时, 接受后填写
+	Payment    string `json:"payment"`         // contract / attested
+	PaymentRef string `json:"paymentRef,omitempty"`
+	Status     string `json:"status"`
+	ExpiresAt  string `json:"expiresAt"`
+	CreatedAt  string `json:"createdAt"`
+	AcceptedAt string `json:"acceptedAt,omitempty"`
+	SettledAt  string `json:"settledAt,omitempty"`
+	// SettleTxId 成交或撤销交易
+	SettleTxId    string `json:"settleTxId,omitempty"`
+	RecordVersion int    `json:"recordVersion"` // 记录版本号, 每次写入递增
+	// NoticeId 优先购买结算时向主张者生成的挂单所属通知, 见 preemption.go
+	NoticeId string `json:"noticeId,omitempty"`
+}
+
+// maker 创建(首先签名)的一方
+func (o *SaleOffer) maker() string {
+	if o.Side == offerSideAsk {
+		return o.Seller
+	}
+	return o.Buyer
+}
+
+// expired 挂单/出价是否已过期
+func (o *SaleOffer) expired(now time.Time) bool {
+	expiresAt, err := parseISODate(o.ExpiresAt, true)
+	return err == nil && now.After(expiresAt)
+}
+
+func getSaleOffer(stub shim.CMStubInterface, offerId string) (*SaleOffer, error) {
+	offerBytes, err := stub.GetStateFromKeyByte(saleOfferKeyPrefix + offerId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState offer %s: %s", offerId, err.Error())
+	}
+	if len(offerBytes) == 0 {
+		return nil, newError(ErrNotFound, "offerId", "no offer found for offerId: %s", offerId)
+	}
+	var offer SaleOffer
+	if err := json.Unmarshal(offerBytes, &offer); err != nil {
+		return nil, fmt.Errorf("unmarshal offer %s error: %s", offerId, err.Error())
+	}
+	return &offer, nil
+}
+
+func putSaleOffer(stub shim.CMStubInterface, offer *SaleOffer) error {
+	offer.RecordVersion++
+	offerBytes, err := json.Marshal(offer)
+	if err != nil {
+		return fmt.Errorf("marshal offer error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(saleOfferKeyPrefix+offer.OfferId, offerBytes); err != nil {
+		return fmt.Errorf("fail to PutState offer %s: %s", offer.OfferId, err.Error())
+	}
+	return nil
+}
+
+// addOffer 保存新建的挂单/出价并写入通证索引
+func addOffer(stub shim.CMStubInterface, offer *SaleOffer) error {
+	if err := putSaleOffer(stub, offer); err != nil {
+		return err
+	}
+	if err := stub.PutStateByte(saleOfferTokenKeyPrefix+offer.TokenId, offer.OfferId, []byte(offer.OfferId)); err != nil {
+		return fmt.Errorf("fail to PutState offer index of %s: %s", offer.TokenId, err.Error())
+	}
+	stub.EmitEvent("event_offer_created", []string{offer.OfferId, offer.Side, offer.Kind, offer.TokenId, offer.Price})
+	return nil
+}
+
+// checkEscrow 卖方的持有权/份额被未过期的挂单/出价托管锁定时返回 IN_ESCROW, exceptOffer 为正在成交的挂单
+func checkEscrow(stub shim.CMStubInterface, tokenId, holder, exceptOffer string) error {
+	if err := checkPreemptionLock(stub, tokenId, holder); err != nil {
+		return err
+	}
+	offerId, err := stub.GetStateByte(saleEscrowKeyPrefix+tokenId, holder)
+	if err != nil {
+		return fmt.Errorf("fail to GetState escrow of %s: %s", tokenId, err.Error())
+	}
+	if len(offerId) == 0 || string(offerId) == exceptOffer {
+		return nil
+	}
+	offer, err := getSaleOffer(stub, string(offerId))
+	if err != nil {
+		return err
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return err
+	}
+	if offer.expired(now) {
+		return nil
+	}
+	return newError(ErrInEscrow, "tokenId", "token %s of %s is held in escrow by offer %s", tokenId, holder, offer.OfferId)
+}
+
+// lockEscrow 托管锁定卖方的持有权/份额
+func lockEscrow(stub shim.CMStubInterface, offer *SaleOffer) error {
+	if err := checkEscrow(stub, offer.TokenId, offer.Seller, offer.OfferId); err != nil {
+		return err
+	}
+	if err := stub.PutStateByte(saleEscrowKeyPrefix+offer.TokenId, offer.Seller, []byte(offer.OfferId)); err != nil {
+		return fmt.Errorf("fail to PutState escrow of %s: %s", offer.TokenId, err.Error())
+	}
+	return nil
+}
+
+// releaseEscrow 解除挂单/出价的托管锁定, 锁定已被其他挂单接替时忽略
+func releaseEscrow(stub shim.CMStubInterface, offer *SaleOffer) error {
+	offerId, err := stub.GetStateByte(saleEscrowKeyPrefix+offer.TokenId, offer.Seller)
+	if err != nil {
+		return fmt.Errorf("fail to GetState escrow of %s: %s", offer.TokenId, err.Error())
+	}
+	if string(offerId) != offer.OfferId {
+		return nil
+	}
+	if err := stub.DelState(saleEscrowKeyPrefix+offer.TokenId, offer.Seller); err != nil {
+		return fmt.Errorf("fail to DelState escrow of %s: %s", offer.TokenId, err.Error())
+	}
+	return nil
+}
+
+// unitProportion 账户在通证中的权利主体份额, 不持有时返回 -1
+func unitProportion(detail *TokenDetail, account string) (float64, error) {
+	for _, unit := range detail.CopyrightUnits {
+		if unit.Address == account {
+			p, err := strconv.ParseFloat(unit.Proportion, 64)
+			if err != nil {
+				return 0, fmt.Errorf("proportion of %s is not numeric: %s", account, unit.Proportion)
+			}
+			return p, nil
+		}
+	}
+	return -1, nil
+}
+
+// formatProportion 份额保留 9 位小数, 消除浮点误差
+func formatProportion(p float64) string {
+	return strconv.FormatFloat(math.Round(p*1e9)/1e9, 'f', -1, 64)
+}
+
+// moveProportion 自 from 的权利主体份额中转出 amount 给 to, to 已持有份额时合并; from 份额转完时移除
+func moveProportion(detail *TokenDetail, from, to string, amount float64) error {
+	var units []CopyrightUnit
+	received := false
+	for _, unit := range detail.CopyrightUnits {
+		p, err := strconv.ParseFloat(unit.Proportion, 64)
+		if err != nil {
+			return fmt.Errorf("proportion of %s is not numeric: %s", unit.Address, unit.Proportion)
+		}
+		switch unit.Address {
+		case from:
+			if p-amount < -1e-9 {
+				return newError(ErrSumMismatch, "proportion", "%s holds %s, less than %s", from, unit.Proportion, formatProportion(amount))
+			}
+			if p-amount <= 1e-9 {
+				continue
+			}
+			unit.Proportion = formatProportion(p - amount)
+		case to:
+			unit.Proportion = formatProportion(p + amount)
+			received = true
+		}
+		units = append(units, unit)
+	}
+	if !received {
+		units = append(units, CopyrightUnit{Address: to, Proportion: formatProportion(amount)})
+	}
+	detail.CopyrightUnits = units
+	return nil
+}
+
+// settleOffer 付款确认后完成转移, 并解除托管锁定
+func settleOffer(stub shim.CMStubInterface, offer *SaleOffer, now time.Time) (*TokenDetail, error) {
+	detail, err := loadCopyrightToken(stub, offer.TokenId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransferable(detail); err != nil {
+		return nil, err
+	}
+	switch offer.Kind {
+	case offerKindToken:
+		if detail.OwnerAccount != offer.Seller {
+			return nil, newError(ErrForbidden, "offerId", "seller %s no longer owns token %s", offer.Seller, offer.TokenId)
+		}
+		if err := transferOwnership(stub, detail, offer.Buyer); err != nil {
+			return nil, err
+		}
+	case offerKindShare:
+		amount, _ := strconv.ParseFloat(offer.Proportion, 64)
+		if err := moveProportion(detail, offer.Seller, offer.Buyer, amount); err != nil {
+			return nil, err
+		}
+		if err := putTokenDetail(stub, detail); err != nil {
+			return nil, err
+		}
+	}
+	if err := releaseEscrow(stub, offer); err != nil {
+		return nil, err
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return nil, fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	offer.Status = offerSettled
+	offer.SettledAt = now.Format(time.RFC3339)
+	offer.SettleTxId = txId
+	if err := putSaleOffer(stub, offer); err != nil {
+		return nil, err
+	}
+	stub.EmitEvent("event_offer_settled", []string{offer.OfferId, offer.TokenId, offer.Seller, offer.Buyer, offer.Price})
+	return detail, nil
+}
+
+// CreateOffer 创建挂单(卖方, side=ask)或出价(买方, side=bid), 创建者即为首先签名的一方
+// 挂单创建时即托管锁定卖方的持有权/份额, 出价在卖方接受时锁定
+// 文档: createOffer({account, side, kind, tokenId, proportion?, price, asset?, seller?, buyer?, payment?, expiresAt})
+func (tc *TokenContract) CreateOffer(stub shim.CMStubInterface) protogo.Response {
+	const method = "createOffer"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	side := string(args["side"])
+	kind := string(args["kind"])
+	tokenId := string(args["tokenId"])
+	proportionStr := string(args["proportion"])
+	priceStr := string(args["price"])
+	expiresAt := string(args["expiresAt"])
+	if name := missingArg(args, "account", "side", "kind", "tokenId", "price", "expiresAt"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkSender(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	offer := &SaleOffer{Side: side, Kind: kind, TokenId: tokenId, Price: priceStr, Asset: string(args["asset"]), ExpiresAt: expiresAt, Status: offerOpen}
+	switch side {
+	case offerSideAsk:
+		offer.Seller, offer.Buyer = account, string(args["buyer"])
+	case offerSideBid:
+		offer.Seller, offer.Buyer = string(args["seller"]), account
+	default:
+		return errorResponse(stub, method, ErrInvalidParam, "side", "side must be 'ask' or 'bid', got: "+side)
+	}
+	if kind != offerKindToken && kind != offerKindShare {
+		return errorResponse(stub, method, ErrInvalidParam, "kind", "kind must be 'token' or 'share', got: "+kind)
+	}
+	if price, err := strconv.ParseFloat(priceStr, 64); err != nil || price <= 0 {
+		return errorResponse(stub, method, ErrInvalidParam, "price", "price must be a positive number, got: "+priceStr)
+	}
+	if err := validateDate("expiresAt", expiresAt); err != nil {
+		return failResponse(stub, method, err)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if offer.expired(now) {
+		return errorResponse(stub, method, ErrInvalidDate, "expiresAt", "expiresAt is in the past: "+expiresAt)
+	}
+	if offer.Payment, err = offerPayment(stub, string(args["payment"])); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	detail, err := loadCopyrightToken(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkTransferable(detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if kind == offerKindToken {
+		if offer.Seller == "" {
+			offer.Seller = detail.OwnerAccount
+		}
+		if offer.Seller != detail.OwnerAccount {
+			return errorResponse(stub, method, ErrForbidden, "seller", "seller is not the token owner: "+offer.Seller)
+		}
+	} else {
+		if offer.Seller == "" {
+			return missingParam(stub, method, "seller")
+		}
+		held, err := unitProportion(detail, offer.Seller)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if held < 0 {
+			return errorResponse(stub, method, ErrForbidden, "seller", "seller does not hold any proportion: "+offer.Seller)
+		}
+		proportion, err := strconv.ParseFloat(proportionStr, 64)
+		if err != nil || proportion <= 0 || proportion-held > 1e-9 {
+			return errorResponse(stub, method, ErrInvalidParam, "proportion", fmt.Sprintf("proportion must be in (0, %s], got: %s", formatProportion(held), proportionStr))
+		}
+		if offer.Seller == detail.OwnerAccount && held-proportion <= 1e-9 {
+			return errorResponse(stub, method, ErrInvalidParam, "proportion", "owner can not sell the whole proportion as a share, use kind=token")
+		}
+		offer.Proportion = formatProportion(proportion)
+	}
+	if offer.Buyer == offer.Seller {
+		return errorResponse(stub, method, ErrInvalidParam, "account", "buyer and seller are the same account: "+account)
+	}
+	if kind == offerKindShare && offer.Buyer != "" {
+		if err := checkPreemptionBuyer(stub, detail, offer.Buyer); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+	if kind == offerKindToken && offer.Buyer != "" {
+		if err := checkPreemptionOutsider(stub, detail, offer.Seller, offer.Buyer); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+
+	if offer.OfferId, err = stub.GetTxId(); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	offer.CreatedAt = now.Format(time.RFC3339)
+	if side == offerSideAsk {
+		if err := lockEscrow(stub, offer); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+	if err := addOffer(stub, offer); err != nil {
+		return failResponse(stub, method, err)
+	}
+	return rec.success(method, offer.OfferId, saleOfferKeyPrefix+offer.OfferId, offer.RecordVersion, offer)
+}
+
+// offerPayment 付款方式, 未指定时配置了 paymentContract 则为 contract, 否则为 attested
+func offerPayment(stub shim.CMStubInterface, payment string) (string, error) {
+	contract, err := getConfigString(stub, paymentContractKey)
+	if err != nil {
+		return "", err
+	}
+	switch payment {
+	case "":
+		if contract != "" {
+			return paymentContract, nil
+		}
+		return paymentAttested, nil
+	case paymentContract:
+		if contract == "" {
+			return "", newError(ErrInvalidParam, "payment", "paymentContract is not configured")
+		}
+		return payment, nil
+	case paymentAttested:
+		return payment, nil
+	default:
+		return "", newError(ErrInvalidParam, "payment", "payment must be 'contract' or 'attested', got: %s", payment)
+	}
+}
+
+// AcceptOffer 对方接受挂单(买方)或出价(卖方), 双方签名完成
+// contract 付款方式下随即调用资产合约付款并成交, attested 方式下等待 attestPayment
+// 文档: acceptOffer({account, offerId, expectedVersion?})
+func (tc *TokenContract) AcceptOffer(stub shim.CMStubInterface) protogo.Response {
+	const method = "acceptOffer"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	offerId := string(args["offerId"])
+	if name := missingArg(args, "account", "offerId"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkSender(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	offer, err := getSaleOffer(stub, offerId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkExpectedVersion(args, offer.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if offer.Status != offerOpen || offer.expired(now) {
+		return errorResponse(stub, method, ErrNotActive, "offerId", "offer is not open: "+offerId)
+	}
+	if offer.Side == offerSideAsk {
+		if offer.Buyer != "" && offer.Buyer != account {
+			return errorResponse(stub, method, ErrForbidden, "account", "offer is reserved for buyer: "+offer.Buyer)
+		}
+		if account == offer.Seller {
+			return errorResponse(stub, method, ErrInvalidParam, "account", "seller can not accept own offer")
+		}
+		offer.Buyer = account
+	} else {
+		if account != offer.Seller {
+			return errorResponse(stub, method, ErrForbidden, "account", "only the seller can accept the bid: "+offer.Seller)
+		}
+		if err := lockEscrow(stub, offer); err != nil {
+			return failResponse(stub, method, err)
+		}
+	}
+	detail, err := loadCopyrightToken(stub, offer.TokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if offer.Kind == offerKindShare {
+		if err := checkPreemptionBuyer(stub, detail, offer.Buyer); err != nil {
+			return failResponse(stub, method, err)
+		}
+	} else if err := checkPreemptionOutsider(stub, detail, offer.Seller, offer.Buyer); err != nil {
+		return failResponse(stub, method, err)
+	}
+	offer.Status = offerAccepted
+	offer.AcceptedAt = now.Format(time.RFC3339)
+	stub.EmitEvent("event_offer_accepted", []string{offer.OfferId, offer.Seller, offer.Buyer})
+
+	if offer.Payment == paymentContract {
+		contract, err := getConfigString(stub, paymentContractKey)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		resp := stub.CallContract(contract, "", map[string][]byte{
+			"method": []byte("transferFrom"),
+			"from":   []byte(offer.Buyer),
+			"to":     []byte(offer.Seller),
+			"amount": []byte(offer.Price),
+			"asset":  []byte(offer.Asset),
+			"memo":   []byte(offer.OfferId),
+		})
+		if resp.Status != shim.OK {
+			return errorResponse(stub, method, ErrPaymentFailed, "offerId", "payment contract rejected transfer: "+resp.Message)
+		}
+		offer.PaymentRef = contract
+		if _, err := settleOffer(stub, offer, now); err != nil {
+			return failResponse(stub, method, err)
+		}
+	} else if err := putSaleOffer(stub, offer); err != nil {
+		return failResponse(stub, method, err)
+	}
+	return rec.success(method, offer.OfferId, saleOfferKeyPrefix+offer.OfferId, offer.RecordVersion, offer)
+}
+
+// AttestPayment 付款见证机构登记已接受的挂单/出价的付款记录, 随即成交
+// 文档: attestPayment({account, offerId, paymentRef})
+func (tc *TokenContract) AttestPayment(stub shim.CMStubInterface) protogo.Response {
+	const method = "attestPayment"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	offerId := string(args["offerId"])
+	paymentRef := string(args["paymentRef"])
+	if name := missingArg(args, "account", "offerId", "paymentRef"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkSender(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	attestors, err := readIndex(stub, paymentAttestorsKey)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if !containsId(attestors, account) {
+		return errorResponse(stub, method, ErrForbidden, "account", "account is not a payment attestor: "+account)
+	}
+	offer, err := getSaleOffer(stub, offerId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if offer.Status != offerAccepted || offer.Payment != paymentAttested || offer.expired(now) {
+		return errorResponse(stub, method, ErrNotActive, "offerId", "offer is not awaiting attested payment: "+offerId)
+	}
+	offer.PaymentRef = paymentRef
+	if _, err := settleOffer(stub, offer, now); err != nil {
+		return failResponse(stub, method, err)
+	}
+	return rec.success(method, offer.OfferId, saleOfferKeyPrefix+offer.OfferId, offer.RecordVersion, offer)
+}
+
+// CancelOffer 撤销挂单/出价: 创建者可撤销未接受的; 已接受但过期未付款的, 买卖双方均可撤销
+// 优先购买结算生成的挂单由主张者(买方)放弃, 卖方不能在到期前撤销
+// 文档: cancelOffer({account, offerId})
+func (tc *TokenContract) CancelOffer(stub shim.CMStubInterface) protogo.Response {
+	const method = "cancelOffer"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	offerId := string(args["offerId"])
+	if name := missingArg(args, "account", "offerId"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if err := checkSender(stub, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	offer, err := getSaleOffer(stub, offerId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	switch {
+	case offer.Status == offerOpen && offer.NoticeId != "" && account == offer.Buyer:
+	case offer.Status == offerOpen && ((account == offer.maker() && offer.NoticeId == "") || offer.expired(now)):
+	case offer.Status == offerAccepted && offer.expired(now) && (account == offer.Seller || account == offer.Buyer):
+	case offer.Status == offerOpen || offer.Status == offerAccepted:
+		return errorResponse(stub, method, ErrForbidden, "account", "account can not cancel the offer now: "+account)
+	default:
+		return errorResponse(stub, method, ErrNotActive, "offerId", "offer is already "+offer.Status)
+	}
+	if err := releaseEscrow(stub, offer); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if offer.SettleTxId, err = stub.GetTxId(); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	offer.Status = offerCancelled
+	if err := putSaleOffer(stub, offer); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_offer_cancelled", []string{offer.OfferId, account})
+	return rec.success(method, offer.OfferId, saleOfferKeyPrefix+offer.OfferId, offer.RecordVersion, offer)
+}
+
+// RequestOffers 查询通证下的挂单/出价, 过期未成交的状态为 expired
+// 文档: requestOffers({tokenId, status?})
+func (tc *TokenContract) RequestOffers(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestOffers"
+	args := stub.GetArgs()
+	tokenId := string(args["tokenId"])
+	status := string(args["status"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	iter, err := stub.NewIteratorPrefixWithKeyField(saleOfferTokenKeyPrefix+tokenId, "")
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to create iterator for offers of %s: %s", tokenId, err.Error()))
+	}
+	defer iter.Close()
+	offers := []SaleOffer{}
+	for iter.HasNext() {
+		_, offerId, _, err := iter.Next()
+		if err != nil {
+			return failResponse(stub, method, fmt.Errorf("fail to iterate offers of %s: %s", tokenId, err.Error()))
+		}
+		offer, err := getSaleOffer(stub, offerId)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		if (offer.Status == offerOpen || offer.Status == offerAccepted) && offer.expired(now) {
+			offer.Status = offerExpired
+		}
+		if status == "" || offer.Status == status {
+			offers = append(offers, *offer)
+		}
+	}
+	offersBytes, err := json.Marshal(offers)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal offers error: %s", err.Error()))
+	}
+	return shim.Success(offersBytes)
+}
@@ -0,0 +1,137 @@
+//go:build simulator
+
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// simStateFile 状态文件内容
+type simStateFile struct {
+	TxSeq int               `json:"txSeq"`
+	State map[string]string `json:"state"`
+}
+
+// simStub 模拟器桩: 共用的内存状态(mem_stub.go)加本地状态文件
+type simStub struct {
+	*memStub
+
+	path    string
+	txSeq   int
+	verbose bool
+}
+
+// loadSimStub 从状态文件加载, 文件不存在时以空状态开始
+func loadSimStub(path string) (*simStub, error) {
+	stub := &simStub{memStub: newMemStub(), path: path}
+	stub.orgId = "sim-org"
+	if path == "" {
+		return stub, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stub, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state file %s: %s", path, err.Error())
+	}
+	var file simStateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse state file %s: %s", path, err.Error())
+	}
+	for k, v := range file.State {
+		stub.state[k] = []byte(v)
+	}
+	stub.txSeq = file.TxSeq
+	return stub, nil
+}
+
+// save 写回状态文件
+func (s *simStub) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(&simStateFile{TxSeq: s.txSeq, State: stringState(s.state)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0644)
+}
+
+// stringState 状态转为字符串值, 用于状态文件及状态差异
+func stringState(state map[string][]byte) map[string]string {
+	copied := make(map[string]string, len(state))
+	for k, v := range state {
+		copied[k] = string(v)
+	}
+	return copied
+}
+
+// call 执行一次合约调用: kind 为 init / upgrade / invoke, 调用失败时回滚本次写入
+func (s *simStub) call(kind, method string, args map[string]string) protogo.Response {
+	if kind != "invoke" {
+		method = ""
+	}
+	s.setArgs(method, args)
+	s.txSeq++
+	s.height = s.txSeq
+	s.txId = "sim-tx-" + strconv.Itoa(s.txSeq)
+
+	contract := new(TokenContract)
+	return s.commit(func() protogo.Response {
+		switch kind {
+		case "init":
+			return contract.InitContract(s)
+		case "upgrade":
+			return contract.UpgradeContract(s)
+		default:
+			return contract.InvokeContract(s)
+		}
+	})
+}
+
+// stateDiff 状态差异
+type stateDiff struct {
+	Added   map[string]string `json:"added,omitempty"`
+	Changed map[string]string `json:"changed,omitempty"`
+	Deleted []string          `json:"deleted,omitempty"`
+}
+
+// diffState 比较调用前后的状态
+func diffState(before, after map[string]string) stateDiff {
+	diff := stateDiff{Added: map[string]string{}, Changed: map[string]string{}}
+	for k, v := range after {
+		old, ok := before[k]
+		if !ok {
+			diff.Added[k] = v
+		} else if old != v {
+			diff.Changed[k] = v
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			diff.Deleted = append(diff.Deleted, k)
+		}
+	}
+	sort.Strings(diff.Deleted)
+	return diff
+}
+
+// CallContract 模拟器只加载本合约, 跨合约调用一律失败
+func (s *simStub) CallContract(contractName string, contractVersion string, args map[string][]byte) protogo.Response {
+	return shim.Error("cross-contract call is not supported in simulator: " + contractName)
+}
+
+func (s *simStub) Log(message string) {
+	s.memStub.Log(message)
+	if s.verbose {
+		fmt.Fprintln(os.Stderr, "[log] "+message)
+	}
+}
@@ -0,0 +1,300 @@
+//go:build simulator
+
+// 本地模拟器: 不部署长安链, 直接在本地状态文件上调用 TokenContract
+//
+// 构建: go build -tags simulator -o tokensim .
+//
+// 单次调用:
+//
+//	tokensim -state state.json -sender 0xalice -timestamp 2025-01-01T00:00:00Z \
+//	    -method buildTokenIssueTx -args '{"account":"0xalice","token":"MediaToken",...}'
+//	tokensim -state state.json -method requestTokenInfo -arg tokenId=... -arg version=v2
+//
+// 回放场景文件(演示及回归检查, 任一步骤不符合预期时退出码为1):
+//
+//	tokensim -state state.json -scenario scenarios/lifecycle.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// argFlags 可重复的 -arg key=value 参数
+type argFlags map[string]string
+
+func (a argFlags) String() string {
+	return fmt.Sprint(map[string]string(a))
+}
+
+func (a argFlags) Set(value string) error {
+	idx := strings.Index(value, "=")
+	if idx <= 0 {
+		return fmt.Errorf("expect key=value, got: %s", value)
+	}
+	a[value[:idx]] = value[idx+1:]
+	return nil
+}
+
+// scenarioStep 场景文件中的一步调用
+type scenarioStep struct {
+	Name      string                     `json:"name"`
+	Call      string                     `json:"call"` // init / upgrade / invoke(默认)
+	Method    string                     `json:"method"`
+	Sender    string                     `json:"sender"`
+	Timestamp string                     `json:"timestamp"`
+	Args      map[string]json.RawMessage `json:"args"`
+	// Expect 预期结果: "ok" / "error", 为空不校验
+	Expect string `json:"expect"`
+	// ExpectContains 预期出现在错误信息(失败时)或返回内容(成功时)中的子串
+	ExpectContains string `json:"expectContains"`
+}
+
+// scenario 场景文件
+type scenario struct {
+	Description string         `json:"description"`
+	Sender      string         `json:"sender"`    // 默认调用者
+	Timestamp   string         `json:"timestamp"` // 默认区块时间
+	Steps       []scenarioStep `json:"steps"`
+}
+
+// callResult 单次调用的输出
+type callResult struct {
+	Step    string          `json:"step,omitempty"`
+	Call    string          `json:"call"`
+	Method  string          `json:"method,omitempty"`
+	Sender  string          `json:"sender"`
+	TxId    string          `json:"txId"`
+	Status  int32           `json:"status"`
+	Message string          `json:"message,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Events  []stubEvent     `json:"events,omitempty"`
+	Diff    stateDiff       `json:"diff"`
+}
+
+func main() {
+	statePath := flag.String("state", "sim_state.json", "state file path, empty keeps state in memory only")
+	call := flag.String("call", "invoke", "call kind: init, upgrade or invoke")
+	method := flag.String("method", "", "contract method for invoke")
+	argsJSON := flag.String("args", "", "method args as a JSON object; object/array values are passed as JSON strings")
+	sender := flag.String("sender", "0xsim", "simulated sender address")
+	timestamp := flag.String("timestamp", "", "simulated block time, unix seconds or RFC3339 (default: now)")
+	scenarioPath := flag.String("scenario", "", "replay a scenario file instead of a single call")
+	dryRun := flag.Bool("dry-run", false, "do not write the state file")
+	verbose := flag.Bool("v", false, "print contract logs to stderr")
+	kv := argFlags{}
+	flag.Var(kv, "arg", "method arg key=value, repeatable")
+	flag.Parse()
+
+	stub, err := loadSimStub(*statePath)
+	if err != nil {
+		fatal(err)
+	}
+	stub.verbose = *verbose
+
+	ok := true
+	if *scenarioPath != "" {
+		ok, err = runScenario(stub, *scenarioPath, *sender, *timestamp)
+		if err != nil {
+			fatal(err)
+		}
+	} else {
+		if *call == "invoke" && *method == "" {
+			fatal(fmt.Errorf("-method is required for invoke"))
+		}
+		args := map[string]string{}
+		if *argsJSON != "" {
+			var raw map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(*argsJSON), &raw); err != nil {
+				fatal(fmt.Errorf("parse -args: %s", err.Error()))
+			}
+			if args, err = flattenArgs(raw); err != nil {
+				fatal(err)
+			}
+		}
+		for k, v := range kv {
+			args[k] = v
+		}
+		stub.sender = *sender
+		if stub.timestamp, err = parseSimTime(*timestamp); err != nil {
+			fatal(err)
+		}
+		result := runCall(stub, "", *call, *method, args)
+		printResult(result)
+		ok = result.Status == 200
+	}
+
+	if !*dryRun {
+		if err := stub.save(); err != nil {
+			fatal(err)
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// runScenario 依次回放场景中的调用, 返回是否全部符合预期
+func runScenario(stub *simStub, path, defaultSender, defaultTime string) (bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	var sc scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return false, fmt.Errorf("parse scenario %s: %s", path, err.Error())
+	}
+	if sc.Sender != "" {
+		defaultSender = sc.Sender
+	}
+	if sc.Timestamp != "" {
+		defaultTime = sc.Timestamp
+	}
+	if sc.Description != "" {
+		fmt.Fprintln(os.Stderr, "# "+sc.Description)
+	}
+
+	for i, step := range sc.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		kind := step.Call
+		if kind == "" {
+			kind = "invoke"
+		}
+		args, err := flattenArgs(step.Args)
+		if err != nil {
+			return false, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		stub.sender = defaultSender
+		if step.Sender != "" {
+			stub.sender = step.Sender
+		}
+		ts := defaultTime
+		if step.Timestamp != "" {
+			ts = step.Timestamp
+		}
+		if stub.timestamp, err = parseSimTime(ts); err != nil {
+			return false, fmt.Errorf("%s: %s", name, err.Error())
+		}
+
+		result := runCall(stub, name, kind, step.Method, args)
+		printResult(result)
+		if msg := checkExpectation(step, result); msg != "" {
+			fmt.Fprintf(os.Stderr, "FAIL %s: %s\n", name, msg)
+			return false, nil
+		}
+	}
+	fmt.Fprintf(os.Stderr, "PASS %d steps\n", len(sc.Steps))
+	return true, nil
+}
+
+// runCall 执行一次调用并收集返回、事件及状态差异
+func runCall(stub *simStub, step, kind, method string, args map[string]string) callResult {
+	before := stringState(stub.state)
+	resp := stub.call(kind, method, args)
+	txId, _ := stub.GetTxId()
+
+	result := callResult{
+		Step:    step,
+		Call:    kind,
+		Method:  method,
+		Sender:  stub.sender,
+		TxId:    txId,
+		Status:  resp.Status,
+		Message: resp.Message,
+		Events:  stub.events,
+		Diff:    diffState(before, stringState(stub.state)),
+	}
+	if len(resp.Payload) > 0 {
+		if json.Valid(resp.Payload) {
+			result.Payload = resp.Payload
+		} else {
+			quoted, _ := json.Marshal(string(resp.Payload))
+			result.Payload = quoted
+		}
+	}
+	return result
+}
+
+// checkExpectation 校验单步结果, 返回不符合预期的原因
+func checkExpectation(step scenarioStep, result callResult) string {
+	success := result.Status == 200
+	switch step.Expect {
+	case "ok":
+		if !success {
+			return "expected ok, got error: " + result.Message
+		}
+	case "error":
+		if success {
+			return "expected error, got ok"
+		}
+	case "":
+	default:
+		return "unknown expect value: " + step.Expect
+	}
+	if step.ExpectContains != "" {
+		text := result.Message
+		if success {
+			text = string(result.Payload)
+		}
+		if !strings.Contains(text, step.ExpectContains) {
+			return fmt.Sprintf("expected %q in %s", step.ExpectContains, text)
+		}
+	}
+	return ""
+}
+
+// flattenArgs 将 JSON 参数转为合约参数: 字符串原样传入, 对象/数组/数字以 JSON 文本传入
+func flattenArgs(raw map[string]json.RawMessage) (map[string]string, error) {
+	args := make(map[string]string, len(raw))
+	for k, v := range raw {
+		trimmed := bytes.TrimSpace(v)
+		if len(trimmed) > 0 && trimmed[0] == '"' {
+			var s string
+			if err := json.Unmarshal(trimmed, &s); err != nil {
+				return nil, fmt.Errorf("arg %s: %s", k, err.Error())
+			}
+			args[k] = s
+			continue
+		}
+		args[k] = string(trimmed)
+	}
+	return args, nil
+}
+
+// parseSimTime 解析模拟区块时间: 空为当前时间, 支持 unix 秒或 RFC3339
+func parseSimTime(s string) (int64, error) {
+	if s == "" {
+		return time.Now().Unix(), nil
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return sec, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q, expect unix seconds or RFC3339", s)
+	}
+	return t.Unix(), nil
+}
+
+func printResult(result callResult) {
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Println(string(out))
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "tokensim: "+err.Error())
+	os.Exit(2)
+}
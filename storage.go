@@ -0,0 +1,407 @@
+package main
+
+import (
+	"chainmaker/shim"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 复合键存储布局: 每个关系一条 key/field 记录, 写入只改动一条, 读取按字段范围迭代
+//
+//	account_holding_<account>#<发行时间><序号> -> tokenId     账户持有的版权通证, 按通证首次发行时间排列, 同一时间按登记先后
+//	account_holding_desc_<account>#<倒序字段> -> tokenId     同上, 字段逐位取 9 的补数, 正向迭代即为倒序
+//	account_holding_pos_<account>#<tokenId>  -> holdingPos  定位、去重及所属分组
+//	account_holding_meta_<account>           -> {"nextSeq":..,"count":..}
+//	account_holding_group_<account>#<分组ID>  -> holdingGroup 过滤属性相同的持有通证数, 用于过滤后的总数
+//	approve_ref_<版权通证ID>#<授权通证ID>      -> 授权通证ID
+const (
+	holdingKeyPrefix      = "account_holding_"
+	holdingDescKeyPrefix  = "account_holding_desc_"
+	holdingPosKeyPrefix   = "account_holding_pos_"
+	holdingMetaKeyPrefix  = "account_holding_meta_"
+	holdingGroupKeyPrefix = "account_holding_group_"
+	approveRefKeyPrefix   = "approve_ref_"
+
+	holdingFieldEnd = "z" // 大于任何序号字段, 作为范围迭代的上界
+)
+
+// holdingMeta 账户持有关系的计数
+type holdingMeta struct {
+	NextSeq int `json:"nextSeq"`
+	Count   int `json:"count"`
+}
+
+// holdingField 序号字段, 定长补零以保证字典序与数值序一致
+func holdingField(seq int) string {
+	return fmt.Sprintf("%012d", seq)
+}
+
+// holdingSortField 持有记录字段: 通证首次发行时间(Unix 秒, 未记录时为 0)加账户内登记序号
+func holdingSortField(detail *TokenDetail, seq int) string {
+	var published int64
+	if t, err := time.Parse(time.RFC3339, detail.PublishedAt); err == nil && t.Unix() > 0 {
+		published = t.Unix()
+	}
+	return fmt.Sprintf("%012d", published) + holdingField(seq)
+}
+
+// reverseField 倒序字段: 定长数字字段逐位取 9 的补数, 字典序与原字段相反
+func reverseField(field string) string {
+	reversed := []byte(field)
+	for i, c := range reversed {
+		reversed[i] = '9' - (c - '0')
+	}
+	return string(reversed)
+}
+
+// holdingPos 账户持有某通证的记录位置及所属分组
+type holdingPos struct {
+	Field string `json:"field"`
+	Group string `json:"group"`
+}
+
+// holdingGroup requestAccountToken 可过滤属性相同的一组持有通证及其数量
+type holdingGroup struct {
+	Version string `json:"version"`
+	Flag    int    `json:"flag"`
+	Frozen  bool   `json:"frozen"`
+	Token   string `json:"token"`
+	WorkId  string `json:"workId"`
+	Count   int    `json:"count"`
+}
+
+// groupOf 通证所属的分组
+func groupOf(detail *TokenDetail) *holdingGroup {
+	return &holdingGroup{Version: detail.Version, Flag: detail.Flag, Frozen: detail.Frozen, Token: detail.Token, WorkId: detail.WorkId}
+}
+
+// id 分组ID: 过滤属性的摘要, 作为状态字段
+func (g *holdingGroup) id() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%d\n%t\n%s\n%s", g.Version, g.Flag, g.Frozen, g.Token, g.WorkId)))
+	return hex.EncodeToString(sum[:16])
+}
+
+func getHoldingPos(stub shim.CMStubInterface, account, tokenId string) (*holdingPos, error) {
+	posBytes, err := stub.GetStateByte(holdingPosKeyPrefix+account, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState holding for %s: %s", tokenId, err.Error())
+	}
+	if len(posBytes) == 0 {
+		return nil, nil
+	}
+	var pos holdingPos
+	if err := json.Unmarshal(posBytes, &pos); err != nil {
+		return nil, fmt.Errorf("unmarshal holding of %s for %s error: %s", tokenId, account, err.Error())
+	}
+	return &pos, nil
+}
+
+func putHoldingPos(stub shim.CMStubInterface, account, tokenId string, pos *holdingPos) error {
+	posBytes, err := json.Marshal(pos)
+	if err != nil {
+		return fmt.Errorf("marshal holding error: %s", err.Error())
+	}
+	if err := stub.PutStateByte(holdingPosKeyPrefix+account, tokenId, posBytes); err != nil {
+		return fmt.Errorf("fail to PutState holding for %s: %s", tokenId, err.Error())
+	}
+	return nil
+}
+
+// countHoldingGroup 调整分组计数, 计数归零时删除分组
+func countHoldingGroup(stub shim.CMStubInterface, account, groupId string, group *holdingGroup, delta int) error {
+	key := holdingGroupKeyPrefix + account
+	groupBytes, err := stub.GetStateByte(key, groupId)
+	if err != nil {
+		return fmt.Errorf("fail to GetState holding group for %s: %s", account, err.Error())
+	}
+	if len(groupBytes) > 0 {
+		group = &holdingGroup{}
+		if err := json.Unmarshal(groupBytes, group); err != nil {
+			return fmt.Errorf("unmarshal holding group for %s error: %s", account, err.Error())
+		}
+	}
+	if group == nil {
+		return nil
+	}
+	group.Count += delta
+	if group.Count <= 0 {
+		if err := stub.DelState(key, groupId); err != nil {
+			return fmt.Errorf("fail to DelState holding group for %s: %s", account, err.Error())
+		}
+		return nil
+	}
+	if groupBytes, err = json.Marshal(group); err != nil {
+		return fmt.Errorf("marshal holding group error: %s", err.Error())
+	}
+	if err := stub.PutStateByte(key, groupId, groupBytes); err != nil {
+		return fmt.Errorf("fail to PutState holding group for %s: %s", account, err.Error())
+	}
+	return nil
+}
+
+// holdingGroups 读取账户的全部分组
+func holdingGroups(stub shim.CMStubInterface, account string) ([]holdingGroup, error) {
+	var groups []holdingGroup
+	var decodeErr error
+	err := rangeFields(stub, holdingGroupKeyPrefix+account, "", holdingFieldEnd, func(field string, value []byte) bool {
+		var group holdingGroup
+		if decodeErr = json.Unmarshal(value, &group); decodeErr != nil {
+			return false
+		}
+		groups = append(groups, group)
+		return true
+	})
+	if err == nil && decodeErr != nil {
+		err = fmt.Errorf("unmarshal holding group for %s error: %s", account, decodeErr.Error())
+	}
+	return groups, err
+}
+
+func getHoldingMeta(stub shim.CMStubInterface, account string) (*holdingMeta, error) {
+	metaBytes, err := stub.GetStateFromKeyByte(holdingMetaKeyPrefix + account)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState holding meta for %s: %s", account, err.Error())
+	}
+	meta := &holdingMeta{}
+	if len(metaBytes) == 0 {
+		return meta, nil
+	}
+	if err := json.Unmarshal(metaBytes, meta); err != nil {
+		return nil, fmt.Errorf("unmarshal holding meta for %s error: %s", account, err.Error())
+	}
+	return meta, nil
+}
+
+func putHoldingMeta(stub shim.CMStubInterface, account string, meta *holdingMeta) error {
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("marshal holding meta error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(holdingMetaKeyPrefix+account, metaBytes); err != nil {
+		return fmt.Errorf("fail to PutState holding meta for %s: %s", account, err.Error())
+	}
+	return nil
+}
+
+// addHolding 登记账户持有的通证, 已登记时忽略
+func addHolding(stub shim.CMStubInterface, account string, detail *TokenDetail) error {
+	pos, err := getHoldingPos(stub, account, detail.TokenId)
+	if err != nil || pos != nil {
+		return err
+	}
+	meta, err := getHoldingMeta(stub, account)
+	if err != nil {
+		return err
+	}
+	group := groupOf(detail)
+	pos = &holdingPos{Field: holdingSortField(detail, meta.NextSeq), Group: group.id()}
+	if err := stub.PutStateByte(holdingKeyPrefix+account, pos.Field, []byte(detail.TokenId)); err != nil {
+		return fmt.Errorf("fail to PutState holding for %s: %s", detail.TokenId, err.Error())
+	}
+	if err := stub.PutStateByte(holdingDescKeyPrefix+account, reverseField(pos.Field), []byte(detail.TokenId)); err != nil {
+		return fmt.Errorf("fail to PutState holding for %s: %s", detail.TokenId, err.Error())
+	}
+	if err := putHoldingPos(stub, account, detail.TokenId, pos); err != nil {
+		return err
+	}
+	if err := countHoldingGroup(stub, account, pos.Group, group, 1); err != nil {
+		return err
+	}
+	meta.NextSeq++
+	meta.Count++
+	return putHoldingMeta(stub, account, meta)
+}
+
+// removeHolding 移除账户持有的通证, 未登记时忽略
+func removeHolding(stub shim.CMStubInterface, account, tokenId string) error {
+	pos, err := getHoldingPos(stub, account, tokenId)
+	if err != nil || pos == nil {
+		return err
+	}
+	if err := stub.DelState(holdingKeyPrefix+account, pos.Field); err != nil {
+		return fmt.Errorf("fail to DelState holding for %s: %s", tokenId, err.Error())
+	}
+	if err := stub.DelState(holdingDescKeyPrefix+account, reverseField(pos.Field)); err != nil {
+		return fmt.Errorf("fail to DelState holding for %s: %s", tokenId, err.Error())
+	}
+	if err := stub.DelState(holdingPosKeyPrefix+account, tokenId); err != nil {
+		return fmt.Errorf("fail to DelState holding for %s: %s", tokenId, err.Error())
+	}
+	if err := countHoldingGroup(stub, account, pos.Group, nil, -1); err != nil {
+		return err
+	}
+	meta, err := getHoldingMeta(stub, account)
+	if err != nil {
+		return err
+	}
+	meta.Count--
+	return putHoldingMeta(stub, account, meta)
+}
+
+// regroupHolding 通证的过滤属性(冻结状态)变化后, 将持有者的记录移入新的分组
+func regroupHolding(stub shim.CMStubInterface, account string, detail *TokenDetail) error {
+	pos, err := getHoldingPos(stub, account, detail.TokenId)
+	if err != nil || pos == nil {
+		return err
+	}
+	group := groupOf(detail)
+	groupId := group.id()
+	if pos.Group == groupId {
+		return nil
+	}
+	if err := countHoldingGroup(stub, account, pos.Group, nil, -1); err != nil {
+		return err
+	}
+	if err := countHoldingGroup(stub, account, groupId, group, 1); err != nil {
+		return err
+	}
+	pos.Group = groupId
+	return putHoldingPos(stub, account, detail.TokenId, pos)
+}
+
+// holdingCount 账户持有的通证数
+func holdingCount(stub shim.CMStubInterface, account string) (int, error) {
+	meta, err := getHoldingMeta(stub, account)
+	if err != nil {
+		return 0, err
+	}
+	return meta.Count, nil
+}
+
+// rangeFields 按字段顺序遍历 key 下 [startField, limitField) 的记录, fn 返回 false 时停止
+func rangeFields(stub shim.CMStubInterface, key, startField, limitField string, fn func(field string, value []byte) bool) error {
+	iter, err := stub.NewIteratorWithField(key, startField, limitField)
+	if err != nil {
+		return fmt.Errorf("fail to create iterator for %s: %s", key, err.Error())
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		_, field, value, err := iter.Next()
+		if err != nil {
+			return fmt.Errorf("fail to iterate %s: %s", key, err.Error())
+		}
+		if !fn(field, value) {
+			break
+		}
+	}
+	return nil
+}
+
+// addApproveRef 登记版权通证下的授权通证
+func addApproveRef(stub shim.CMStubInterface, copyrightId, approveId string) error {
+	if err := stub.PutStateByte(approveRefKeyPrefix+copyrightId, approveId, []byte(approveId)); err != nil {
+		return fmt.Errorf("fail to PutState approve ref for %s: %s", approveId, err.Error())
+	}
+	return nil
+}
+
+// approveRefs 读取版权通证下的全部授权通证ID
+func approveRefs(stub shim.CMStubInterface, copyrightId string) ([]string, error) {
+	iter, err := stub.NewIteratorPrefixWithKeyField(approveRefKeyPrefix+copyrightId, "")
+	if err != nil {
+		return nil, fmt.Errorf("fail to create iterator for approve refs of %s: %s", copyrightId, err.Error())
+	}
+	defer iter.Close()
+	ids := []string{}
+	for iter.HasNext() {
+		_, field, _, err := iter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("fail to iterate approve refs of %s: %s", copyrightId, err.Error())
+		}
+		ids = append(ids, field)
+	}
+	return ids, nil
+}
+
+// nextLegacyIndex 读取旧布局中第一个以 JSON 数组存储的索引, 返回键后缀及 ID 列表, 不存在时 ok 为 false
+func nextLegacyIndex(stub shim.CMStubInterface, prefix string) (string, []string, bool, error) {
+	iter, err := stub.NewIteratorPrefixWithKey(prefix)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("fail to create iterator for %s: %s", prefix, err.Error())
+	}
+	defer iter.Close()
+	for iter.HasNext() {
+		key, field, value, err := iter.Next()
+		if err != nil {
+			return "", nil, false, fmt.Errorf("fail to iterate %s: %s", prefix, err.Error())
+		}
+		if field != "" || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		var ids []string
+		if err := json.Unmarshal(value, &ids); err != nil {
+			return "", nil, false, fmt.Errorf("fail to Unmarshal index %s: %s", key, err.Error())
+		}
+		return strings.TrimPrefix(key, prefix), ids, true, nil
+	}
+	return "", nil, false, nil
+}
+
+// addLegacyHolding 按通证详情登记旧索引中的持有关系, 通证已不存在时忽略
+func addLegacyHolding(stub shim.CMStubInterface, account, tokenId string) error {
+	detail, err := getTokenDetail(stub, tokenId)
+	if err != nil || detail == nil {
+		return err
+	}
+	return addHolding(stub, account, detail)
+}
+
+// migrateIndexLayout 迁移步骤: 将旧的 account_tokens_ / approve_index_ JSON 数组转换为复合键布局并删除旧键
+// 每批至多转换 budget 个条目(空数组按一条计): 数组整体转换后删除旧键, 超出预算时写回尚未转换的部分,
+// 下一批自然从剩余的旧键及条目继续, 游标不需要记录
+// 账户内按通证发行时间排列, 同一时间按原数组顺序, 已转换的条目重复执行时忽略
+func migrateIndexLayout(stub shim.CMStubInterface, cursor string, budget int) (string, int, bool, error) {
+	legacies := []struct {
+		prefix string
+		add    func(stub shim.CMStubInterface, owner, id string) error
+	}{
+		{"account_tokens_", addLegacyHolding},
+		{"approve_index_", addApproveRef},
+	}
+	converted := 0
+	for _, legacy := range legacies {
+		for converted < budget {
+			owner, ids, ok, err := nextLegacyIndex(stub, legacy.prefix)
+			if err != nil {
+				return "", converted, false, err
+			}
+			if !ok {
+				break
+			}
+			n := len(ids)
+			if n > budget-converted {
+				n = budget - converted
+			}
+			for _, id := range ids[:n] {
+				if err := legacy.add(stub, owner, id); err != nil {
+					return "", converted, false, err
+				}
+			}
+			if n < len(ids) {
+				restBytes, err := json.Marshal(ids[n:])
+				if err != nil {
+					return "", converted, false, fmt.Errorf("fail to marshal index %s%s: %s", legacy.prefix, owner, err.Error())
+				}
+				if err := stub.PutStateFromKeyByte(legacy.prefix+owner, restBytes); err != nil {
+					return "", converted, false, fmt.Errorf("fail to PutState for %s%s: %s", legacy.prefix, owner, err.Error())
+				}
+				return "", converted + n, false, nil
+			}
+			if err := stub.DelStateFromKey(legacy.prefix + owner); err != nil {
+				return "", converted, false, fmt.Errorf("fail to DelState %s%s: %s", legacy.prefix, owner, err.Error())
+			}
+			if n == 0 {
+				n = 1
+			}
+			converted += n
+		}
+		if converted >= budget {
+			return "", converted, false, nil
+		}
+	}
+	return "", converted, true, nil
+}
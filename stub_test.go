@@ -0,0 +1,66 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"strconv"
+)
+
+// mockStub 测试桩, 在共用的内存状态(mem_stub.go)上按交易调用合约
+type mockStub struct {
+	*memStub
+
+	txSeq int
+	as    string // invokeAs 指定的交易发送者
+}
+
+// newMockStub 新建空状态的测试桩, 区块时间默认为 2025-01-01T00:00:00Z
+func newMockStub() *mockStub {
+	s := &mockStub{memStub: newMemStub()}
+	s.sender = "0xsender"
+	s.timestamp = 1735689600
+	return s
+}
+
+// invoke 以给定参数调用合约方法, 每次调用生成新的交易ID
+// 交易发送者为 operatorAccount, 否则为方法的调用者参数(account / publisher 等), 均未传入时为默认发送者
+func (s *mockStub) invoke(method string, args map[string]string) protogo.Response {
+	s.setArgs(method, args)
+	s.sender = s.as
+	if s.sender == "" {
+		s.sender = callerOf(method, args)
+	}
+	s.txSeq++
+	s.height++
+	s.txId = "tx" + strconv.Itoa(s.txSeq)
+	return s.commit(func() protogo.Response { return new(TokenContract).InvokeContract(s) })
+}
+
+// invokeAs 以指定的交易发送者调用合约方法, 用于校验冒用他人账户的调用被拒绝
+func (s *mockStub) invokeAs(sender, method string, args map[string]string) protogo.Response {
+	s.as = sender
+	defer func() { s.as = "" }()
+	return s.invoke(method, args)
+}
+
+// callerOf 调用参数中的调用者账户
+func callerOf(method string, args map[string]string) string {
+	if operator := args[operatorAccountParam]; operator != "" {
+		return operator
+	}
+	if spec, ok := findMethodSpec(method); ok && args[spec.Principal] != "" {
+		return args[spec.Principal]
+	}
+	if account := args["account"]; account != "" {
+		return account
+	}
+	return "0xsender"
+}
+
+// init 以给定参数调用 InitContract
+func (s *mockStub) init(args map[string]string) protogo.Response {
+	s.setArgs("", args)
+	s.sender = "0xsender"
+	s.txSeq++
+	s.txId = "tx" + strconv.Itoa(s.txSeq)
+	return s.commit(func() protogo.Response { return new(TokenContract).InitContract(s) })
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxExpiringDays 到期查询允许的最大天数
+const maxExpiringDays = 366
+
+// ExpiringToken 即将到期的通证
+type ExpiringToken struct {
+	TokenId   string `json:"tokenId"`
+	TokenType int    `json:"tokenType"` // 1=版权通证(保护期),2=授权通证,3=许可
+	Window    string `json:"window"`    // 期限, ISO-8601 区间 start/end
+	EndDate   string `json:"endDate"`   // 到期日 YYYY-MM-DD
+}
+
+// RequestExpiringTokens 查询 N 天内到期的通证(以区块时间为准)
+// 文档: requestExpiringTokens({days, tokenType?})
+func (tc *TokenContract) RequestExpiringTokens(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestExpiringTokens"
+	args := stub.GetArgs()
+
+	daysStr := string(args["days"])
+	tokenTypeStr := string(args["tokenType"])
+	if daysStr == "" {
+		return missingParam(stub, method, "days")
+	}
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days < 0 || days > maxExpiringDays {
+		return errorResponse(stub, method, ErrInvalidParam, "days", fmt.Sprintf("days must be integer in 0-%d, got: %s", maxExpiringDays, daysStr))
+	}
+	tokenTypes := []int{TokenTypeCopyright, TokenTypeApprove, TokenTypeLicense}
+	if tokenTypeStr != "" {
+		tokenType, err := strconv.Atoi(tokenTypeStr)
+		if err != nil || !validEnum(stub, "tokenType", tokenType) {
+			return errorResponse(stub, method, ErrInvalidEnum, "tokenType", "tokenType must be 1, 2 or 3, got: "+tokenTypeStr)
+		}
+		tokenTypes = []int{tokenType}
+	}
+
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	result := []ExpiringToken{}
+	for d := 0; d <= days; d++ {
+		endDate := now.AddDate(0, 0, d).Format(isoDateLayout)
+		for _, tokenType := range tokenTypes {
+			ids, err := readIndex(stub, expiryIndexKey(tokenType, endDate))
+			if err != nil {
+				return failResponse(stub, method, err)
+			}
+			for _, id := range ids {
+				// 通证可能已被销毁或重新发行修改了期限, 以当前数据为准
+				window, err := tokenWindow(stub, tokenType, id)
+				if err != nil {
+					stub.Log("[requestExpiringTokens] skip tokenId=" + id + ", error: " + err.Error())
+					continue
+				}
+				if window == "" {
+					continue
+				}
+				w, err := parseTimeWindow(window)
+				if err != nil || w.end.Format(isoDateLayout) != endDate || w.end.Before(now) {
+					continue
+				}
+				result = append(result, ExpiringToken{
+					TokenId:   id,
+					TokenType: tokenType,
+					Window:    window,
+					EndDate:   endDate,
+				})
+			}
+		}
+	}
+
+	retBytes, err := json.Marshal(result)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to marshal result: %s", err.Error()))
+	}
+	return shim.Success(retBytes)
+}
+
+// isoDateLayout ISO-8601 日期格式
+const isoDateLayout = "2006-01-02"
+
+// timeWindow 解析后的时间区间, 均为闭区间
+type timeWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// parseISODate 解析 ISO-8601 日期(YYYY-MM-DD)或日期时间(RFC3339)
+// 仅有日期时, end 为 true 表示取当天结束时刻
+func parseISODate(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse(isoDateLayout, s); err == nil {
+		if end {
+			return t.Add(24*time.Hour - time.Nanosecond), nil
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid ISO-8601 date %q, expect YYYY-MM-DD or RFC3339", s)
+	}
+	return t.UTC(), nil
+}
+
+// parseTimeWindow 解析 ISO-8601 时间区间 "start/end"
+func parseTimeWindow(s string) (timeWindow, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return timeWindow{}, fmt.Errorf("invalid ISO-8601 interval %q, expect start/end", s)
+	}
+	start, err := parseISODate(parts[0], false)
+	if err != nil {
+		return timeWindow{}, err
+	}
+	end, err := parseISODate(parts[1], true)
+	if err != nil {
+		return timeWindow{}, err
+	}
+	if end.Before(start) {
+		return timeWindow{}, fmt.Errorf("interval %q ends before it starts", s)
+	}
+	return timeWindow{start: start, end: end}, nil
+}
+
+// validateDate 校验可选日期字段
+func validateDate(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := parseISODate(value, false); err != nil {
+		return newError(ErrInvalidDate, field, "%s: %s", field, err.Error())
+	}
+	return nil
+}
+
+// validateWindow 校验可选时间区间字段
+func validateWindow(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := parseTimeWindow(value); err != nil {
+		return newError(ErrInvalidDate, field, "%s: %s", field, err.Error())
+	}
+	return nil
+}
+
+// validateTokenObjectDates 校验 tokenObject 中的日期及时间区间
+func validateTokenObjectDates(tokenObj *TokenObject) error {
+	if err := validateWindow("term", tokenObj.Term); err != nil {
+		return err
+	}
+	for i, status := range tokenObj.CopyrightStatus {
+		if err := validateDate(fmt.Sprintf("copyrightStatus[%d].publishDate", i), status.PublishDate); err != nil {
+			return err
+		}
+		if err := validateDate(fmt.Sprintf("copyrightStatus[%d].comeoutDate", i), status.ComeoutDate); err != nil {
+			return err
+		}
+		if err := validateDate(fmt.Sprintf("copyrightStatus[%d].issueDate", i), status.IssueDate); err != nil {
+			return err
+		}
+	}
+	for i, c := range tokenObj.ApprConstraint {
+		if err := validateWindow(fmt.Sprintf("apprConstraint[%d].time", i), c.Time); err != nil {
+			return err
+		}
+	}
+	for i, c := range tokenObj.LicenseConstraint {
+		if err := validateWindow(fmt.Sprintf("licenseConstraint[%d].time", i), c.Time); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// txTime 读取交易(区块)时间戳
+func txTime(stub shim.CMStubInterface) (time.Time, error) {
+	timestampStr, err := stub.GetTxTimeStamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fail to get tx timestamp: %s", err.Error())
+	}
+	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid tx timestamp: %s", timestampStr)
+	}
+	return time.Unix(timestamp, 0).UTC(), nil
+}
+
+// windowActive 判断当前区块时间是否处于时间区间内, 空区间视为长期有效
+func windowActive(stub shim.CMStubInterface, window string) (bool, error) {
+	if window == "" {
+		return true, nil
+	}
+	w, err := parseTimeWindow(window)
+	if err != nil {
+		return false, err
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return false, err
+	}
+	return !now.Before(w.start) && !now.After(w.end), nil
+}
+
+// windowWithin 判断区间 inner 是否完全落在区间 outer 内, 空 outer 视为不限
+func windowWithin(inner, outer string) (bool, error) {
+	if outer == "" {
+		return true, nil
+	}
+	if inner == "" {
+		return false, nil
+	}
+	in, err := parseTimeWindow(inner)
+	if err != nil {
+		return false, err
+	}
+	out, err := parseTimeWindow(outer)
+	if err != nil {
+		return false, err
+	}
+	return !in.start.Before(out.start) && !in.end.After(out.end), nil
+}
+
+// expiryIndexKey 到期日索引键
+func expiryIndexKey(tokenType int, endDate string) string {
+	return "expiry_index_" + strconv.Itoa(tokenType) + "_" + strings.Replace(endDate, "-", "", -1)
+}
+
+// indexExpiry 将带期限的通证登记到其到期日索引
+func indexExpiry(stub shim.CMStubInterface, tokenType int, tokenId, window string) error {
+	if window == "" {
+		return nil
+	}
+	w, err := parseTimeWindow(window)
+	if err != nil {
+		return err
+	}
+	return appendIndex(stub, expiryIndexKey(tokenType, w.end.Format(isoDateLayout)), tokenId)
+}
+
+// tokenWindow 读取通证当前的期限, 通证不存在时返回空
+func tokenWindow(stub shim.CMStubInterface, tokenType int, tokenId string) (string, error) {
+	switch tokenType {
+	case TokenTypeCopyright:
+		detail, err := getTokenDetail(stub, tokenId)
+		if err != nil || detail == nil {
+			return "", err
+		}
+		return detail.Term, nil
+	case TokenTypeApprove:
+		approveToken, err := getApproveToken(stub, tokenId)
+		if err != nil || approveToken == nil {
+			return "", err
+		}
+		return approveToken.Time, nil
+	default:
+		pubTx, err := getPubTokenTx(stub, tokenId)
+		if err != nil || pubTx == nil {
+			return "", err
+		}
+		return pubTx.Time, nil
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 持有者变更: 当前持有者以 buildTokenChangeTx 指定受让方发起, 受让方在期限内 acceptTokenChange 后才变更 OwnerAccount 及持有索引
+//
+//	token_change_<tokenId> -> TokenChange   每个通证同时只有一笔进行中的变更
+const (
+	tokenChangeKeyPrefix = "token_change_"
+
+	defaultTokenChangeDays = 7 // 未指定 expiresAt 时的接受期限
+
+	tokenChangePending   = "pending"
+	tokenChangeAccepted  = "accepted"
+	tokenChangeCancelled = "cancelled" // 持有者撤回或受让方拒绝
+	tokenChangeExpired   = "expired"   // 查询时根据 expiresAt 给出
+)
+
+// TokenChange 持有者变更记录
+type TokenChange struct {
+	TokenId     string `json:"tokenId"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Status      string `json:"status"`
+	ExpiresAt   string `json:"expiresAt"` // 接受期限, 当天结束失效
+	CreatedAt   string `json:"createdAt"`
+	TxId        string `json:"txId"`
+	CompletedAt string `json:"completedAt,omitempty"`
+	// CompleteTxId 接受或撤回交易
+	CompleteTxId  string `json:"completeTxId,omitempty"`
+	RecordVersion int    `json:"recordVersion"` // 记录版本号, 每次写入递增
+}
+
+// expired 变更是否已超过接受期限
+func (c *TokenChange) expired(now time.Time) bool {
+	expiresAt, err := parseISODate(c.ExpiresAt, true)
+	return err == nil && now.After(expiresAt)
+}
+
+// getTokenChange 读取通证最近一笔持有者变更, 不存在时返回 nil
+func getTokenChange(stub shim.CMStubInterface, tokenId string) (*TokenChange, error) {
+	changeBytes, err := stub.GetStateFromKeyByte(tokenChangeKeyPrefix + tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState token change of %s: %s", tokenId, err.Error())
+	}
+	if len(changeBytes) == 0 {
+		return nil, nil
+	}
+	var change TokenChange
+	if err := json.Unmarshal(changeBytes, &change); err != nil {
+		return nil, fmt.Errorf("unmarshal token change of %s error: %s", tokenId, err.Error())
+	}
+	return &change, nil
+}
+
+func putTokenChange(stub shim.CMStubInterface, change *TokenChange) error {
+	change.RecordVersion++
+	changeBytes, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("marshal token change error: %s", err.Error())
+	}
+	if err := stub.PutStateFromKeyByte(tokenChangeKeyPrefix+change.TokenId, changeBytes); err != nil {
+		return fmt.Errorf("fail to PutState token change of %s: %s", change.TokenId, err.Error())
+	}
+	return nil
+}
+
+// checkOwnerTransfer 校验持有权可由当前持有者转给 to: 通证可流通、未被托管锁定, to 尚不是权利主体
+func checkOwnerTransfer(stub shim.CMStubInterface, detail *TokenDetail, to string) error {
+	if err := checkTransferable(detail); err != nil {
+		return err
+	}
+	if err := checkEscrow(stub, detail.TokenId, detail.OwnerAccount, ""); err != nil {
+		return err
+	}
+	held, err := unitProportion(detail, to)
+	if err != nil {
+		return err
+	}
+	if held >= 0 {
+		return newError(ErrInvalidParam, "to", "%s already holds a copyright unit, use buildTransferProportionTx", to)
+	}
+	return checkPreemptionOutsider(stub, detail, detail.OwnerAccount, to)
+}
+
+// openTokenChange 发起持有者变更, 已有未过期的进行中变更时返回 ALREADY_EXISTS
+func openTokenChange(stub shim.CMStubInterface, detail *TokenDetail, to, expiresAt string) (*TokenChange, error) {
+	if to == detail.OwnerAccount {
+		return nil, newError(ErrInvalidParam, "to", "to is already the owner: %s", to)
+	}
+	if err := checkOwnerTransfer(stub, detail, to); err != nil {
+		return nil, err
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt == "" {
+		expiresAt = now.AddDate(0, 0, defaultTokenChangeDays).Format("2006-01-02")
+	} else if err := validateDate("expiresAt", expiresAt); err != nil {
+		return nil, err
+	}
+	old, err := getTokenChange(stub, detail.TokenId)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil && old.Status == tokenChangePending && !old.expired(now) {
+		return nil, newError(ErrAlreadyExists, "to", "token %s has a pending change to %s, cancel it first", detail.TokenId, old.To)
+	}
+	txId, err := stub.GetTxId()
+	if err != nil {
+		return nil, fmt.Errorf("fail to get txId: %s", err.Error())
+	}
+	change := &TokenChange{
+		TokenId:   detail.TokenId,
+		From:      detail.OwnerAccount,
+		To:        to,
+		Status:    tokenChangePending,
+		ExpiresAt: expiresAt,
+		CreatedAt: now.Format(time.RFC3339),
+		TxId:      txId,
+	}
+	if change.expired(now) {
+		return nil, newError(ErrInvalidDate, "expiresAt", "expiresAt is in the past: %s", expiresAt)
+	}
+	if old != nil {
+		change.RecordVersion = old.RecordVersion
+	}
+	if err := putTokenChange(stub, change); err != nil {
+		return nil, err
+	}
+	stub.EmitEvent("event_token_change_init", []string{detail.TokenId, change.From, to, expiresAt})
+	return change, nil
+}
+
+// AcceptTokenChange 受让方在期限内接受持有者变更, 随即变更 OwnerAccount、权利主体份额及持有索引
+// 文档: acceptTokenChange({account, tokenId, expectedVersion?})
+func (tc *TokenContract) AcceptTokenChange(stub shim.CMStubInterface) protogo.Response {
+	const method = "acceptTokenChange"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	tokenId := string(args["tokenId"])
+	if name := missingArg(args, "account", "tokenId"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	change, err := getTokenChange(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if change == nil || change.Status != tokenChangePending || change.expired(now) {
+		return errorResponse(stub, method, ErrNotActive, "tokenId", "no pending token change for tokenId: "+tokenId)
+	}
+	if err := checkExpectedVersion(args, change.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if account != change.To {
+		return errorResponse(stub, method, ErrForbidden, "account", "only the recipient can accept the token change: "+change.To)
+	}
+	detail, err := loadCopyrightToken(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if detail.OwnerAccount != change.From {
+		return errorResponse(stub, method, ErrForbidden, "tokenId", "token owner has changed since the change was initiated: "+detail.OwnerAccount)
+	}
+	if err := checkOwnerTransfer(stub, detail, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := transferOwnership(stub, detail, account); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	change.Status = tokenChangeAccepted
+	change.CompletedAt = now.Format(time.RFC3339)
+	if change.CompleteTxId, err = stub.GetTxId(); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	if err := putTokenChange(stub, change); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_token_change", []string{tokenId, account})
+	return rec.success(method, tokenId, "publish_token_"+tokenId, detail.RecordVersion, detail)
+}
+
+// CancelTokenChange 持有者撤回或受让方拒绝进行中的持有者变更
+// 文档: cancelTokenChange({account, tokenId})
+func (tc *TokenContract) CancelTokenChange(stub shim.CMStubInterface) protogo.Response {
+	const method = "cancelTokenChange"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	tokenId := string(args["tokenId"])
+	if name := missingArg(args, "account", "tokenId"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	change, err := getTokenChange(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if change == nil || change.Status != tokenChangePending {
+		return errorResponse(stub, method, ErrNotActive, "tokenId", "no pending token change for tokenId: "+tokenId)
+	}
+	if account != change.From && account != change.To {
+		return errorResponse(stub, method, ErrForbidden, "account", "only the owner or the recipient can cancel the token change")
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	change.Status = tokenChangeCancelled
+	change.CompletedAt = now.Format(time.RFC3339)
+	if change.CompleteTxId, err = stub.GetTxId(); err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to get txId: %s", err.Error()))
+	}
+	if err := putTokenChange(stub, change); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_token_change_cancel", []string{tokenId, account})
+	return rec.success(method, tokenId, tokenChangeKeyPrefix+tokenId, change.RecordVersion, change)
+}
+
+// RequestTokenChange 查询通证最近一笔持有者变更, 超过期限未接受的状态为 expired
+// 文档: requestTokenChange({tokenId})
+func (tc *TokenContract) RequestTokenChange(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestTokenChange"
+	tokenId := string(stub.GetArgs()["tokenId"])
+	if tokenId == "" {
+		return missingParam(stub, method, "tokenId")
+	}
+	change, err := getTokenChange(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if change == nil {
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "no token change found for tokenId: "+tokenId)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if change.Status == tokenChangePending && change.expired(now) {
+		change.Status = tokenChangeExpired
+	}
+	changeBytes, err := json.Marshal(change)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal token change error: %s", err.Error()))
+	}
+	return shim.Success(changeBytes)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+)
+
+// getTokenIssue 读取通证类别(token_issue_<token>), 不存在时返回 nil
+func getTokenIssue(stub shim.CMStubInterface, tokenName string) (*TokenIssue, error) {
+	issueBytes, err := stub.GetStateFromKeyByte("token_issue_" + tokenName)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for token class %s: %s", tokenName, err.Error())
+	}
+	if len(issueBytes) == 0 {
+		return nil, nil
+	}
+	var tokenIssue TokenIssue
+	if err := json.Unmarshal(issueBytes, &tokenIssue); err != nil {
+		return nil, fmt.Errorf("unmarshal token class %s error: %s", tokenName, err.Error())
+	}
+	return &tokenIssue, nil
+}
+
+// putTokenIssue 写回通证类别, 记录版本号递增
+func putTokenIssue(stub shim.CMStubInterface, tokenIssue *TokenIssue) error {
+	tokenIssue.RecordVersion++
+	issueBytes, err := json.Marshal(tokenIssue)
+	if err != nil {
+		return fmt.Errorf("marshal token class %s error: %s", tokenIssue.Token, err.Error())
+	}
+	if err := stub.PutStateFromKeyByte("token_issue_"+tokenIssue.Token, issueBytes); err != nil {
+		return fmt.Errorf("fail to PutState token class %s: %s", tokenIssue.Token, err.Error())
+	}
+	return nil
+}
+
+// recordIssued 发行一个通证时累计类别的已发行数量, 超出发行总量时报错
+func recordIssued(stub shim.CMStubInterface, tokenName string) (*TokenIssue, error) {
+	tokenIssue, err := getTokenIssue(stub, tokenName)
+	if err != nil {
+		return nil, err
+	}
+	if tokenIssue == nil {
+		return nil, newError(ErrNotFound, "token", "token class not issued: %s", tokenName)
+	}
+	if tokenIssue.Number > 0 && tokenIssue.Issued >= tokenIssue.Number {
+		return nil, newError(ErrSupplyExhausted, "token", "token class %s supply exhausted (number=%d)", tokenName, tokenIssue.Number)
+	}
+	tokenIssue.Issued++
+	if err := putTokenIssue(stub, tokenIssue); err != nil {
+		return nil, err
+	}
+	return tokenIssue, nil
+}
+
+// recordBurned 销毁一个通证时累计类别的已销毁数量
+func recordBurned(stub shim.CMStubInterface, tokenName string) error {
+	tokenIssue, err := getTokenIssue(stub, tokenName)
+	if err != nil {
+		return err
+	}
+	if tokenIssue == nil {
+		// 历史数据可能没有类别记录, 不影响销毁
+		return nil
+	}
+	tokenIssue.Burned++
+	return putTokenIssue(stub, tokenIssue)
+}
+
+// getTokenDetail 读取通证详情(publish_token_<tokenId>), 不存在时返回 nil
+func getTokenDetail(stub shim.CMStubInterface, tokenId string) (*TokenDetail, error) {
+	detailBytes, err := stub.GetStateFromKeyByte("publish_token_" + tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for tokenId %s: %s", tokenId, err.Error())
+	}
+	if len(detailBytes) == 0 {
+		return nil, nil
+	}
+	var detail TokenDetail
+	if err := json.Unmarshal(detailBytes, &detail); err != nil {
+		return nil, fmt.Errorf("unmarshal token %s error: %s", tokenId, err.Error())
+	}
+	return &detail, nil
+}
+
+// putTokenDetail 写回通证详情, 记录版本号递增
+func putTokenDetail(stub shim.CMStubInterface, detail *TokenDetail) error {
+	detail.RecordVersion++
+	detailBytes, err := json.Marshal(detail)
+	if err != nil {
+		return fmt.Errorf("marshal token %s error: %s", detail.TokenId, err.Error())
+	}
+	if err := stub.PutStateFromKeyByte("publish_token_"+detail.TokenId, detailBytes); err != nil {
+		return fmt.Errorf("fail to PutState token %s: %s", detail.TokenId, err.Error())
+	}
+	return nil
+}
+
+// getApproveToken 读取授权通证(approve_token_<tokenId>), 不存在时返回 nil
+func getApproveToken(stub shim.CMStubInterface, tokenId string) (*ApproveToken, error) {
+	approveBytes, err := stub.GetStateFromKeyByte("approve_token_" + tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for approve token %s: %s", tokenId, err.Error())
+	}
+	if len(approveBytes) == 0 {
+		return nil, nil
+	}
+	var approveToken ApproveToken
+	if err := json.Unmarshal(approveBytes, &approveToken); err != nil {
+		return nil, fmt.Errorf("unmarshal approve token %s error: %s", tokenId, err.Error())
+	}
+	return &approveToken, nil
+}
+
+// getPubTokenTx 读取许可(pub_token_tx_<tokenId>), 不存在时返回 nil
+func getPubTokenTx(stub shim.CMStubInterface, tokenId string) (*PubTokenTx, error) {
+	txBytes, err := stub.GetStateFromKeyByte("pub_token_tx_" + tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for license %s: %s", tokenId, err.Error())
+	}
+	if len(txBytes) == 0 {
+		return nil, nil
+	}
+	var pubTx PubTokenTx
+	if err := json.Unmarshal(txBytes, &pubTx); err != nil {
+		return nil, fmt.Errorf("unmarshal license %s error: %s", tokenId, err.Error())
+	}
+	return &pubTx, nil
+}
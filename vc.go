@@ -0,0 +1,506 @@
+package main
+
+import (
+	"bytes"
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// W3C 可验证凭证(Verifiable Credential)导入为确权信息
+// 凭证的 issuer 须为已登记机构的 issuers 之一, proof 须为 Data Integrity 证明,
+// 以 proof.verificationMethod 在机构 verificationMethods 中登记的公钥验证:
+//
+//	cryptosuite eddsa-jcs-2022 (Ed25519) / ecdsa-jcs-2019 (ECDSA P-256)
+//	签名原文 = SHA-256(JCS(proof 去掉 proofValue, 附凭证 @context)) || SHA-256(JCS(凭证去掉 proof))
+//	proofValue 为 multibase base58btc('z' 前缀)
+//
+// 链上无法进行 JSON-LD RDF 规范化, 因此只支持基于 JCS 的密码套件
+const (
+	credentialContextV1 = "https://www.w3.org/2018/credentials/v1"
+	credentialContextV2 = "https://www.w3.org/ns/credentials/v2"
+)
+
+// credentialClaims 从凭证中提取的确权信息
+type credentialClaims struct {
+	Issuer            string
+	CredentialId      string
+	AuthenticationId  string
+	AuthenticatedDate string
+}
+
+// canonicalJSON 按 JCS(RFC 8785) 规范化: 对象键按 UTF-16 码元排序, 字符串只转义 '"'、'\\' 及控制字符,
+// 数字按 ECMAScript Number.prototype.toString 输出
+func canonicalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch value := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case string:
+		writeCanonicalString(buf, value)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return fmt.Errorf("number %s is not an IEEE 754 double: %s", value, err.Error())
+		}
+		return writeCanonicalNumber(buf, f)
+	case float64:
+		return writeCanonicalNumber(buf, value)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return utf16Less(keys[i], keys[j]) })
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, value[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		// 其他 Go 值先按 JSON 编码再解析为上述类型
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var generic interface{}
+		if err := dec.Decode(&generic); err != nil {
+			return err
+		}
+		return writeCanonical(buf, generic)
+	}
+	return nil
+}
+
+// utf16Less 按 UTF-16 码元比较字符串
+func utf16Less(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// writeCanonicalString 只转义 '"'、'\\' 及 U+0000~U+001F, 其余字符(含 U+2028、U+2029、HTML 字符)原样输出
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// writeCanonicalNumber 按 ECMAScript Number.prototype.toString 输出: 最短往返表示, 指数 21 以上或 -7 以下用科学计数法
+func writeCanonicalNumber(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("number %v is not allowed in JSON", f)
+	}
+	if f == 0 {
+		buf.WriteByte('0')
+		return nil
+	}
+	if f < 0 {
+		buf.WriteByte('-')
+		f = -f
+	}
+	// 'e' 格式为 d.ddde±xx: digits 为有效数字, n 为小数点位置
+	formatted := strconv.FormatFloat(f, 'e', -1, 64)
+	i := strings.IndexByte(formatted, 'e')
+	digits := strings.Replace(formatted[:i], ".", "", 1)
+	e, _ := strconv.Atoi(formatted[i+1:])
+	k, n := len(digits), e+1
+	switch {
+	case k <= n && n <= 21:
+		buf.WriteString(digits + strings.Repeat("0", n-k))
+	case 0 < n && n <= 21:
+		buf.WriteString(digits[:n] + "." + digits[n:])
+	case -6 < n && n <= 0:
+		buf.WriteString("0." + strings.Repeat("0", -n) + digits)
+	default:
+		buf.WriteString(digits[:1])
+		if k > 1 {
+			buf.WriteString("." + digits[1:])
+		}
+		if n-1 < 0 {
+			buf.WriteString("e-" + strconv.Itoa(1-n))
+		} else {
+			buf.WriteString("e+" + strconv.Itoa(n-1))
+		}
+	}
+	return nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodeMultibase 解码 multibase base58btc 字符串
+func decodeMultibase(s string) ([]byte, error) {
+	if len(s) < 2 || s[0] != 'z' {
+		return nil, errors.New("proofValue must be multibase base58btc (prefix 'z')")
+	}
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	zeros := 0
+	for i, c := range s[1:] {
+		idx := bytes.IndexRune([]byte(base58Alphabet), c)
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		if idx == 0 && i == zeros {
+			zeros++
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// stringOrId 取字符串, 或对象的 id 字段(issuer 可为 URL 或 {id, name})
+func stringOrId(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		id, _ := value["id"].(string)
+		return id
+	}
+	return ""
+}
+
+// containsValue 字符串或字符串数组中是否包含 want
+func containsValue(v interface{}, want string) bool {
+	switch value := v.(type) {
+	case string:
+		return value == want
+	case []interface{}:
+		for _, item := range value {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseCredential 解析凭证并校验结构, 返回凭证对象及提取的确权信息
+func parseCredential(raw []byte) (map[string]interface{}, *credentialClaims, error) {
+	const field = "credential"
+	var vc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&vc); err != nil {
+		return nil, nil, newError(ErrInvalidParam, field, "fail to parse credential: %s", err.Error())
+	}
+	if !containsValue(vc["@context"], credentialContextV1) && !containsValue(vc["@context"], credentialContextV2) {
+		return nil, nil, newError(ErrInvalidParam, field, "@context must include %s or %s", credentialContextV1, credentialContextV2)
+	}
+	if !containsValue(vc["type"], "VerifiableCredential") {
+		return nil, nil, newError(ErrInvalidParam, field, "type must include VerifiableCredential")
+	}
+	claims := &credentialClaims{Issuer: stringOrId(vc["issuer"])}
+	claims.CredentialId, _ = vc["id"].(string)
+	if claims.Issuer == "" {
+		return nil, nil, newError(ErrMissingParam, field, "missing required field: 'issuer'")
+	}
+	subject, ok := vc["credentialSubject"].(map[string]interface{})
+	if !ok {
+		return nil, nil, newError(ErrInvalidParam, field, "credentialSubject must be a single object")
+	}
+	claims.AuthenticationId, _ = subject["authenticationId"].(string)
+	if claims.AuthenticationId == "" {
+		claims.AuthenticationId = claims.CredentialId
+	}
+	if claims.AuthenticationId == "" {
+		return nil, nil, newError(ErrMissingParam, field, "missing credentialSubject.authenticationId or credential id")
+	}
+	claims.AuthenticatedDate, _ = subject["authenticatedDate"].(string)
+	if claims.AuthenticatedDate == "" {
+		for _, name := range []string{"validFrom", "issuanceDate"} {
+			if s, _ := vc[name].(string); len(s) >= len(isoDateLayout) {
+				claims.AuthenticatedDate = s[:len(isoDateLayout)]
+				break
+			}
+		}
+	}
+	if err := validateDate(field+".authenticatedDate", claims.AuthenticatedDate); err != nil {
+		return nil, nil, err
+	}
+	return vc, claims, nil
+}
+
+// checkCredentialSubject 凭证须指明所确权的通证: credentialSubject.tokenId 或 contentHash 与通证一致
+func checkCredentialSubject(vc map[string]interface{}, detail *TokenDetail) error {
+	subject := vc["credentialSubject"].(map[string]interface{})
+	tokenId, _ := subject["tokenId"].(string)
+	contentHash, _ := subject["contentHash"].(string)
+	switch {
+	case tokenId != "":
+		if tokenId != detail.TokenId {
+			return newError(ErrInvalidParam, "credential", "credential is issued for tokenId %s, not %s", tokenId, detail.TokenId)
+		}
+	case contentHash != "":
+		if contentHash != detail.ContentHash {
+			return newError(ErrInvalidParam, "credential", "credentialSubject.contentHash does not match the token")
+		}
+	default:
+		return newError(ErrMissingParam, "credential", "credentialSubject must name the token by 'tokenId' or 'contentHash'")
+	}
+	return nil
+}
+
+// checkCredentialValidity 校验凭证在区块时间处于有效期内
+func checkCredentialValidity(vc map[string]interface{}, now time.Time) error {
+	for _, name := range []string{"validFrom", "issuanceDate"} {
+		if s, _ := vc[name].(string); s != "" {
+			from, err := parseISODate(s, false)
+			if err != nil {
+				return newError(ErrInvalidDate, "credential."+name, "%s", err.Error())
+			}
+			if now.Before(from) {
+				return newError(ErrNotActive, "credential."+name, "credential is not valid until %s", s)
+			}
+		}
+	}
+	for _, name := range []string{"validUntil", "expirationDate"} {
+		if s, _ := vc[name].(string); s != "" {
+			until, err := parseISODate(s, true)
+			if err != nil {
+				return newError(ErrInvalidDate, "credential."+name, "%s", err.Error())
+			}
+			if now.After(until) {
+				return newError(ErrNotActive, "credential."+name, "credential expired at %s", s)
+			}
+		}
+	}
+	return nil
+}
+
+// verifyCredentialProof 以 proof.verificationMethod 对应的机构公钥验证凭证的 Data Integrity 证明, 多个 proof 时任一通过即可
+func verifyCredentialProof(vc map[string]interface{}, institution *AuthInstitution) error {
+	const field = "credential.proof"
+	var proofs []interface{}
+	switch proof := vc["proof"].(type) {
+	case map[string]interface{}:
+		proofs = []interface{}{proof}
+	case []interface{}:
+		proofs = proof
+	default:
+		return newError(ErrMissingParam, field, "credential has no proof")
+	}
+	document := make(map[string]interface{}, len(vc))
+	for k, v := range vc {
+		if k != "proof" {
+			document[k] = v
+		}
+	}
+	documentBytes, err := canonicalJSON(document)
+	if err != nil {
+		return newError(ErrInvalidParam, field, "fail to canonicalize credential: %s", err.Error())
+	}
+	documentHash := sha256.Sum256(documentBytes)
+
+	lastErr := newError(ErrForbidden, field, "proof does not verify against the verification methods of institution %s", institution.InstitutionId)
+	for _, p := range proofs {
+		proof, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if proof["type"] != "DataIntegrityProof" || (proof["cryptosuite"] != "eddsa-jcs-2022" && proof["cryptosuite"] != "ecdsa-jcs-2019") {
+			lastErr = newError(ErrInvalidParam, field, "unsupported proof %v/%v, expect DataIntegrityProof with eddsa-jcs-2022 or ecdsa-jcs-2019", proof["type"], proof["cryptosuite"])
+			continue
+		}
+		if proof["proofPurpose"] != "assertionMethod" {
+			lastErr = newError(ErrInvalidParam, field, "proofPurpose must be assertionMethod")
+			continue
+		}
+		vm, _ := proof["verificationMethod"].(string)
+		key, ok := institution.VerificationMethods[vm]
+		if !ok {
+			lastErr = newError(ErrForbidden, field, "verificationMethod %q is not registered by institution %s", vm, institution.InstitutionId)
+			continue
+		}
+		pub, err := parsePublicKey(key)
+		if err != nil {
+			lastErr = newError(ErrInvalidParam, field, "invalid public key of verificationMethod %s: %s", vm, err.Error())
+			continue
+		}
+		proofValue, _ := proof["proofValue"].(string)
+		sig, err := decodeMultibase(proofValue)
+		if err != nil {
+			lastErr = newError(ErrInvalidParam, field, "%s", err.Error())
+			continue
+		}
+		config := make(map[string]interface{}, len(proof))
+		for k, v := range proof {
+			if k != "proofValue" {
+				config[k] = v
+			}
+		}
+		if ctx, ok := vc["@context"]; ok {
+			config["@context"] = ctx
+		}
+		configBytes, err := canonicalJSON(config)
+		if err != nil {
+			lastErr = newError(ErrInvalidParam, field, "fail to canonicalize proof: %s", err.Error())
+			continue
+		}
+		configHash := sha256.Sum256(configBytes)
+		if verifySignature(pub, append(configHash[:], documentHash[:]...), sig) {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// findInstitutionByIssuer 按凭证 issuer 查找已登记的确权机构
+func findInstitutionByIssuer(stub shim.CMStubInterface, issuer string) (*AuthInstitution, error) {
+	ids, err := readIndex(stub, institutionIdsKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		institution, err := getInstitution(stub, id)
+		if err != nil {
+			return nil, err
+		}
+		if institution != nil && containsId(institution.Issuers, issuer) {
+			return institution, nil
+		}
+	}
+	return nil, newError(ErrNotFound, "credential.issuer", "credential issuer is not a registered institution: %s", issuer)
+}
+
+// BuildImportCredentialTx
+// 导入确权机构签发的 W3C 可验证凭证作为确权信息, 验证 issuer 及 proof 后记录凭证哈希
+// 文档: buildImportCredentialTx({account, tokenId, credential, action?, reason?, expectedVersion?})
+func (tc *TokenContract) BuildImportCredentialTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildImportCredentialTx"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"])
+	tokenId := string(args["tokenId"])
+	action := string(args["action"]) // add(默认) / correct
+	reason := string(args["reason"])
+	if name := missingArg(args, "account", "tokenId", "credential"); name != "" {
+		return missingParam(stub, method, name)
+	}
+	if action == "" {
+		action = authActionAdd
+	} else if action != authActionAdd && action != authActionCorrect {
+		return errorResponse(stub, method, ErrInvalidParam, "action", "action must be 'add' or 'correct', got: "+action)
+	}
+
+	vc, claims, err := parseCredential(args["credential"])
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	detail, err := getTokenDetail(stub, tokenId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if detail == nil {
+		return errorResponse(stub, method, ErrNotFound, "tokenId", "token not found for tokenId="+tokenId)
+	}
+	if err := checkExpectedVersion(args, detail.RecordVersion); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkCredentialSubject(vc, detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+	now, err := txTime(stub)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := checkCredentialValidity(vc, now); err != nil {
+		return failResponse(stub, method, err)
+	}
+	institution, err := findInstitutionByIssuer(stub, claims.Issuer)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := verifyCredentialProof(vc, institution); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	credentialHash := sha256.Sum256(args["credential"])
+	info := AuthenticationInfo{
+		InstitutionId:     institution.InstitutionId,
+		AuthenticationId:  claims.AuthenticationId,
+		AuthenticatedDate: claims.AuthenticatedDate,
+		CredentialId:      claims.CredentialId,
+		CredentialHash:    hex.EncodeToString(credentialHash[:]),
+	}
+	if err := applyAuthAction(stub, detail, action, account, reason, info, true); err != nil {
+		return failResponse(stub, method, err)
+	}
+	if err := putTokenDetail(stub, detail); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	stub.EmitEvent("event_import_credential", []string{tokenId, account, info.AuthenticationId, info.CredentialHash})
+	return rec.success(method, tokenId, "publish_token_"+tokenId, detail.RecordVersion, detail)
+}
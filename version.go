@@ -0,0 +1,20 @@
+package main
+
+import "strconv"
+
+// checkExpectedVersion 乐观并发控制: 传入可选参数 expectedVersion 时,
+// 须与记录当前版本号一致, 否则返回 VERSION_CONFLICT. 记录不存在时当前版本号为 0
+func checkExpectedVersion(args map[string][]byte, current int) error {
+	expectedStr := string(args["expectedVersion"])
+	if expectedStr == "" {
+		return nil
+	}
+	expected, err := strconv.Atoi(expectedStr)
+	if err != nil {
+		return newError(ErrInvalidParam, "expectedVersion", "expectedVersion must be integer, got: %s", expectedStr)
+	}
+	if expected != current {
+		return newError(ErrVersionConflict, "expectedVersion", "record version is %d, but expectedVersion=%d", current, expected)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"chainmaker/pb/protogo"
+	"chainmaker/shim"
+	"encoding/json"
+	"fmt"
+)
+
+// Work 作品登记信息, 以 TokenObject.WorkId 为键
+type Work struct {
+	WorkId        string   `json:"workId"`        // 作品ID
+	Title         string   `json:"title"`         // 作品名称
+	Creators      []string `json:"creators"`      // 创作者列表
+	CopyrightType int      `json:"copyrightType"` // 作品类型, 取值同 TokenObject.CopyrightType (0~16)
+	CreationDate  string   `json:"creationDate"`  // 创作完成日期
+	Registrant    string   `json:"registrant"`    // 登记账户
+}
+
+// WorkTokenTree 作品下的全部通证: 版权通证 -> 授权通证 -> 许可
+type WorkTokenTree struct {
+	Work            Work                 `json:"work"`
+	CopyrightTokens []CopyrightTokenNode `json:"copyrightTokens"`
+}
+
+// CopyrightTokenNode 版权通证及其授权通证
+type CopyrightTokenNode struct {
+	TokenId   string          `json:"tokenId"`
+	Token     json.RawMessage `json:"token"`
+	Approvals []ApprovalNode  `json:"approvals"`
+}
+
+// ApprovalNode 授权通证及其许可
+type ApprovalNode struct {
+	ApproveToken ApproveToken  `json:"approveToken"`
+	Active       bool          `json:"active"` // 区块时间是否处于授权期限内
+	Licenses     []LicenseNode `json:"licenses"`
+}
+
+// LicenseNode 许可
+type LicenseNode struct {
+	License PubTokenTx `json:"license"`
+	Active  bool       `json:"active"` // 区块时间是否处于许可期限内
+}
+
+// BuildRegisterWorkTx 作品登记
+// 文档: buildRegisterWorkTx({account, work})
+func (tc *TokenContract) BuildRegisterWorkTx(stub shim.CMStubInterface) protogo.Response {
+	const method = "buildRegisterWorkTx"
+	rec := recordTx(stub)
+	stub = rec
+	args := stub.GetArgs()
+
+	account := string(args["account"]) // 登记账户
+	workStr := string(args["work"])    // JSON对象
+
+	if field := missingArg(args, "account", "work"); field != "" {
+		return missingParam(stub, method, field)
+	}
+
+	var work Work
+	if err := json.Unmarshal([]byte(workStr), &work); err != nil {
+		return errorResponse(stub, method, ErrInvalidParam, "work", "fail to parse work JSON, err: "+err.Error())
+	}
+
+	if work.WorkId == "" || work.Title == "" || len(work.Creators) == 0 || work.CreationDate == "" {
+		return errorResponse(stub, method, ErrMissingParam, "work", "work missing required fields: 'workId','title','creators','creationDate'")
+	}
+	if !validEnum(stub, "copyrightType", work.CopyrightType) {
+		return errorResponse(stub, method, ErrInvalidEnum, "work.copyrightType", fmt.Sprintf("copyrightType out of valid range (%s), got: %d", enumRange(stub, "copyrightType"), work.CopyrightType))
+	}
+	if err := validateDate("work.creationDate", work.CreationDate); err != nil {
+		return failResponse(stub, method, err)
+	}
+
+	storeKey := "work_" + work.WorkId
+	oldData, err := stub.GetStateFromKeyByte(storeKey)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("GetState failed: %s", err.Error()))
+	}
+	if len(oldData) > 0 {
+		return errorResponse(stub, method, ErrAlreadyExists, "work.workId", "work already registered: "+work.WorkId)
+	}
+
+	work.Registrant = account
+	workBytes, err := json.Marshal(work)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal work failed: %s", err.Error()))
+	}
+	if err := stub.PutStateFromKeyByte(storeKey, workBytes); err != nil {
+		return failResponse(stub, method, fmt.Errorf("PutState failed: %s", err.Error()))
+	}
+
+	stub.EmitEvent("event_register_work", []string{work.WorkId, account})
+	// 作品登记不可修改, 版本号恒为 1
+	return rec.success(method, work.WorkId, storeKey, 1, work)
+}
+
+// RequestWorkInfo 查询作品登记信息
+// 文档: requestWorkInfo({workId})
+func (tc *TokenContract) RequestWorkInfo(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestWorkInfo"
+	workId := string(stub.GetArgs()["workId"])
+	if workId == "" {
+		return missingParam(stub, method, "workId")
+	}
+
+	work, err := getWork(stub, workId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if work == nil {
+		return errorResponse(stub, method, ErrNotFound, "workId", "no work found for workId: "+workId)
+	}
+
+	workBytes, err := json.Marshal(work)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("marshal work failed: %s", err.Error()))
+	}
+	return shim.Success(workBytes)
+}
+
+// RequestWorkTokens 查询作品下的版权通证, 以及其下全部授权通证与许可
+// 文档: requestWorkTokens({workId})
+func (tc *TokenContract) RequestWorkTokens(stub shim.CMStubInterface) protogo.Response {
+	const method = "requestWorkTokens"
+	workId := string(stub.GetArgs()["workId"])
+	if workId == "" {
+		return missingParam(stub, method, "workId")
+	}
+
+	work, err := getWork(stub, workId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	if work == nil {
+		return errorResponse(stub, method, ErrNotFound, "workId", "no work found for workId: "+workId)
+	}
+
+	tree := WorkTokenTree{Work: *work, CopyrightTokens: []CopyrightTokenNode{}}
+
+	// 1. 作品 -> 版权通证
+	copyrightIds, err := readIndex(stub, "work_tokens_"+workId)
+	if err != nil {
+		return failResponse(stub, method, err)
+	}
+	for _, cid := range copyrightIds {
+		tokenBytes, err := stub.GetStateFromKeyByte("publish_token_" + cid)
+		if err != nil {
+			stub.Log("[requestWorkTokens] skip tokenId=" + cid + ", error: " + err.Error())
+			continue
+		}
+		if len(tokenBytes) == 0 {
+			continue
+		}
+		node := CopyrightTokenNode{TokenId: cid, Token: tokenBytes, Approvals: []ApprovalNode{}}
+
+		// 2. 版权通证 -> 授权通证
+		approveIds, err := approveRefs(stub, cid)
+		if err != nil {
+			return failResponse(stub, method, err)
+		}
+		for _, aid := range approveIds {
+			approveBytes, err := stub.GetStateFromKeyByte("approve_token_" + aid)
+			if err != nil || len(approveBytes) == 0 {
+				continue
+			}
+			var approveToken ApproveToken
+			if err := json.Unmarshal(approveBytes, &approveToken); err != nil {
+				stub.Log("[requestWorkTokens] skip approve tokenId=" + aid + ", unmarshal error: " + err.Error())
+				continue
+			}
+			active, err := windowActive(stub, approveToken.Time)
+			if err != nil {
+				stub.Log("[requestWorkTokens] approve tokenId=" + aid + " window error: " + err.Error())
+			}
+			approvalNode := ApprovalNode{ApproveToken: approveToken, Active: active, Licenses: []LicenseNode{}}
+
+			// 3. 授权通证 -> 许可
+			licenseIds, err := readIndex(stub, "license_index_"+aid)
+			if err != nil {
+				return failResponse(stub, method, err)
+			}
+			for _, lid := range licenseIds {
+				licenseBytes, err := stub.GetStateFromKeyByte("pub_token_tx_" + lid)
+				if err != nil || len(licenseBytes) == 0 {
+					continue
+				}
+				var pubTx PubTokenTx
+				if err := json.Unmarshal(licenseBytes, &pubTx); err != nil {
+					stub.Log("[requestWorkTokens] skip license tokenId=" + lid + ", unmarshal error: " + err.Error())
+					continue
+				}
+				licenseActive, err := windowActive(stub, pubTx.Time)
+				if err != nil {
+					stub.Log("[requestWorkTokens] license tokenId=" + lid + " window error: " + err.Error())
+				}
+				approvalNode.Licenses = append(approvalNode.Licenses, LicenseNode{
+					License: pubTx,
+					Active:  active && licenseActive,
+				})
+			}
+			node.Approvals = append(node.Approvals, approvalNode)
+		}
+		tree.CopyrightTokens = append(tree.CopyrightTokens, node)
+	}
+
+	retBytes, err := json.Marshal(tree)
+	if err != nil {
+		return failResponse(stub, method, fmt.Errorf("fail to marshal result: %s", err.Error()))
+	}
+	return shim.Success(retBytes)
+}
+
+// getWork 读取作品登记信息, 不存在时返回 nil
+func getWork(stub shim.CMStubInterface, workId string) (*Work, error) {
+	workBytes, err := stub.GetStateFromKeyByte("work_" + workId)
+	if err != nil {
+		return nil, fmt.Errorf("fail to GetState for work %s: %s", workId, err.Error())
+	}
+	if len(workBytes) == 0 {
+		return nil, nil
+	}
+	var work Work
+	if err := json.Unmarshal(workBytes, &work); err != nil {
+		return nil, fmt.Errorf("unmarshal work error: %s", err.Error())
+	}
+	return &work, nil
+}